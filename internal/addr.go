@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/jhalter/mobius/hotline"
+)
+
+// Default Hotline server ports; file transfers use the next port up
+const (
+	defaultPort    = 5500
+	defaultTLSPort = 5600
+)
+
+// normalizeAddr validates a server address as typed by the user and returns it
+// in host:port form. Accepted forms are host, host:port, IPv4 and IPv6 literals
+// with or without a port ("::1", "[fe80::1%en0]", "[2001:db8::1]:5500"). The
+// default port depends on whether the connection uses TLS.
+func normalizeAddr(addr string, useTLS bool) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return "", errors.New("server address is required")
+	}
+
+	port := strconv.Itoa(defaultPort)
+	if useTLS {
+		port = strconv.Itoa(defaultTLSPort)
+	}
+
+	var host string
+	switch {
+	case strings.HasPrefix(addr, "["):
+		end := strings.Index(addr, "]")
+		if end < 0 {
+			return "", fmt.Errorf("missing ] in address %q", addr)
+		}
+		host = addr[1:end]
+		if rest := addr[end+1:]; rest != "" {
+			if !strings.HasPrefix(rest, ":") {
+				return "", fmt.Errorf("unexpected %q after ]", rest)
+			}
+			port = rest[1:]
+		}
+		if _, err := netip.ParseAddr(host); err != nil || !strings.Contains(host, ":") {
+			return "", fmt.Errorf("invalid IPv6 address %q", host)
+		}
+
+	case strings.Count(addr, ":") > 1:
+		// A bare IPv6 literal can't carry a port
+		if _, err := netip.ParseAddr(addr); err != nil {
+			return "", fmt.Errorf("invalid IPv6 address %q; use [address]:port to give a port", addr)
+		}
+		host = addr
+
+	default:
+		host = addr
+		if i := strings.LastIndex(addr, ":"); i >= 0 {
+			host, port = addr[:i], addr[i+1:]
+		}
+		if err := validateHostname(host); err != nil {
+			return "", err
+		}
+	}
+
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("invalid port %q", port)
+	}
+
+	return net.JoinHostPort(host, port), nil
+}
+
+// validateHostname checks that host is an IPv4 address or a DNS name
+func validateHostname(host string) error {
+	if host == "" {
+		return errors.New("missing host name")
+	}
+	if _, err := netip.ParseAddr(host); err == nil {
+		return nil
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(host, "."), ".") {
+		if label == "" || len(label) > 63 {
+			return fmt.Errorf("invalid host name %q", host)
+		}
+		for _, r := range label {
+			isAlnum := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+			if !isAlnum && r != '-' && r != '_' {
+				return fmt.Errorf("invalid character %q in host name", r)
+			}
+		}
+	}
+	return nil
+}
+
+// validateHost checks that host is an IP address or a DNS name, without a port
+func validateHost(host string) error {
+	host = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(host), "["), "]")
+	if strings.Contains(host, ":") {
+		if _, err := netip.ParseAddr(host); err != nil {
+			return fmt.Errorf("invalid IPv6 address %q", host)
+		}
+		return nil
+	}
+	return validateHostname(host)
+}
+
+// parsePort parses an optional port number; an empty string gives zero
+func parsePort(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return n, nil
+}
+
+// transferEndpoint overrides the host and port file transfers connect to.
+// Zero values fall back to the server's host and port + 1.
+type transferEndpoint struct {
+	host string
+	port int
+}
+
+// addr returns the file transfer address for the normalized server address
+// serverAddr. The server's hostname is kept rather than its resolved IP, and TLS
+// transfers always verify against the server's name and pinned certificate.
+func (e transferEndpoint) addr(serverAddr string) (string, error) {
+	host, port, err := net.SplitHostPort(serverAddr)
+	if err != nil {
+		return "", err
+	}
+
+	if e.host != "" {
+		host = strings.TrimSuffix(strings.TrimPrefix(e.host, "["), "]")
+	}
+	if e.port != 0 {
+		return net.JoinHostPort(host, strconv.Itoa(e.port)), nil
+	}
+
+	n, err := strconv.Atoi(port)
+	if err != nil || n >= 65535 {
+		return "", fmt.Errorf("no transfer port for %s", serverAddr)
+	}
+	return net.JoinHostPort(host, strconv.Itoa(n+1)), nil
+}
+
+// serverRecordAddr returns the address of a server listed by a tracker
+func serverRecordAddr(srv hotline.ServerRecord) string {
+	ip := netip.AddrFrom4(srv.IPAddr)
+	return net.JoinHostPort(ip.String(), strconv.Itoa(int(binary.BigEndian.Uint16(srv.Port[:]))))
+}
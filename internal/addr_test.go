@@ -0,0 +1,67 @@
+package internal
+
+import "testing"
+
+func TestNormalizeAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		useTLS  bool
+		want    string
+		wantErr bool
+	}{
+		{addr: "hotline.example.com", want: "hotline.example.com:5500"},
+		{addr: "hotline.example.com", useTLS: true, want: "hotline.example.com:5600"},
+		{addr: " hotline.example.com:5000 ", want: "hotline.example.com:5000"},
+		{addr: "192.168.1.10", want: "192.168.1.10:5500"},
+		{addr: "192.168.1.10:5501", want: "192.168.1.10:5501"},
+		{addr: "::1", want: "[::1]:5500"},
+		{addr: "2001:db8::1", useTLS: true, want: "[2001:db8::1]:5600"},
+		{addr: "[2001:db8::1]", want: "[2001:db8::1]:5500"},
+		{addr: "[2001:db8::1]:5000", want: "[2001:db8::1]:5000"},
+		{addr: "[fe80::1%en0]", want: "[fe80::1%en0]:5500"},
+		{addr: "[fe80::1%en0]:5000", want: "[fe80::1%en0]:5000"},
+
+		{addr: "", wantErr: true},
+		{addr: "   ", wantErr: true},
+		{addr: ":5500", wantErr: true},
+		{addr: "hotline.example.com:", wantErr: true},
+		{addr: "hotline.example.com:0", wantErr: true},
+		{addr: "hotline.example.com:65536", wantErr: true},
+		{addr: "hotline.example.com:http", wantErr: true},
+		{addr: "bad host.example.com", wantErr: true},
+		{addr: "hotline..example.com", wantErr: true},
+		{addr: "2001:db8::1:5500:x", wantErr: true},
+		{addr: "[2001:db8::1", wantErr: true},
+		{addr: "[2001:db8::1]5500", wantErr: true},
+		{addr: "[192.168.1.10]:5500", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeAddr(tt.addr, tt.useTLS)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("normalizeAddr(%q) = %q, want error", tt.addr, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("normalizeAddr(%q) error: %v", tt.addr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("normalizeAddr(%q, %v) = %q, want %q", tt.addr, tt.useTLS, got, tt.want)
+		}
+	}
+}
+
+func TestValidateHost(t *testing.T) {
+	for _, host := range []string{"example.com", "example.com.", "10.0.0.1", "::1", "[2001:db8::1]", "my_server"} {
+		if err := validateHost(host); err != nil {
+			t.Errorf("validateHost(%q) error: %v", host, err)
+		}
+	}
+	for _, host := range []string{"", "example.com:5500", "exa mple.com", "[2001:db8::zz]"} {
+		if err := validateHost(host); err == nil {
+			t.Errorf("validateHost(%q) = nil, want error", host)
+		}
+	}
+}
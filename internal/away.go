@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jhalter/mobius-hotline-client/internal/style"
+	"github.com/jhalter/mobius/hotline"
+)
+
+// awayCheckInterval is how often keyboard idle time is checked against AutoAwayMinutes
+const awayCheckInterval = 15 * time.Second
+
+// instantMsgAutoResponse marks a private message as an automatic response
+var instantMsgAutoResponse = []byte{0x00, 0x04}
+
+// awayState is a session's away status
+type awayState struct {
+	auto    bool             // Set by the idle timer; cleared by the next keypress
+	message string           // Auto-reply sent to private messages, if any
+	replied map[[2]byte]bool // Users who have already been sent the auto-reply
+}
+
+// autoAway returns the configured keyboard idle time before going away, or zero if disabled
+func (cp *Settings) autoAway() time.Duration {
+	if cp.AutoAwayMinutes <= 0 {
+		return 0
+	}
+	return time.Duration(cp.AutoAwayMinutes) * time.Minute
+}
+
+// awayCheckMsg triggers a check of the keyboard idle time
+type awayCheckMsg struct{}
+
+func awayCheck() tea.Cmd {
+	return tea.Tick(awayCheckInterval, func(time.Time) tea.Msg {
+		return awayCheckMsg{}
+	})
+}
+
+// checkIdle marks every logged in session away once the keyboard has been idle
+// for the configured time. Keyboard activity is global, so this spans all sessions.
+func (m *Model) checkIdle() tea.Cmd {
+	idle := m.prefs.autoAway()
+	if idle > 0 && time.Since(m.lastInput) >= idle {
+		for _, s := range m.sessions {
+			if s.activeConnection != nil && s.reconnect == nil && s.away == nil {
+				m.setAway(s, &awayState{auto: true, message: m.prefs.AwayMessage})
+			}
+		}
+	}
+	return awayCheck()
+}
+
+// userActive records keyboard input, bringing back sessions that went away automatically
+func (m *Model) userActive() {
+	m.lastInput = time.Now()
+	for _, s := range m.sessions {
+		if s.away != nil && s.away.auto {
+			m.setAway(s, nil)
+		}
+	}
+}
+
+// setAway changes a session's away status and tells the server. A nil state
+// means we are back.
+func (m *Model) setAway(s *Session, away *awayState) {
+	if away != nil && away.replied == nil {
+		away.replied = make(map[[2]byte]bool)
+	}
+	s.away = away
+	m.sendUserInfo(s)
+
+	if s.serverScreen != nil {
+		s.serverScreen.SetAway(away != nil)
+		if away != nil {
+			s.serverScreen.AddChatMessage(style.NoticeStyle.Render("You are away"))
+		} else {
+			s.serverScreen.AddChatMessage(style.NoticeStyle.Render("You are back"))
+		}
+	}
+}
+
+// sendUserInfo tells a session's server our name, icon and away status
+func (m *Model) sendUserInfo(s *Session) {
+	var flags hotline.UserFlags
+	if s.away != nil {
+		flags.Set(hotline.UserFlagAway, 1)
+	}
+	t := hotline.NewTransaction(
+		hotline.TranSetClientUserInfo,
+		[2]byte{},
+		hotline.NewField(hotline.FieldUserName, s.encodeText(m.prefs.Username)),
+		hotline.NewField(hotline.FieldUserIconID, m.prefs.IconBytes()),
+		hotline.NewField(hotline.FieldUserFlags, flags[:]),
+		hotline.NewField(hotline.FieldOptions, []byte{0x00, 0x00}),
+	)
+	if err := s.sendTransaction(t); err != nil {
+		m.logger.Error("Error sending user info", "err", err)
+	}
+}
+
+// toggleAway goes away with message, or comes back if already away. An empty
+// message falls back to AwayMessage.
+func (m *Model) toggleAway(message string) {
+	if m.away != nil {
+		m.setAway(m.Session, nil)
+		return
+	}
+
+	if message == "" {
+		message = m.prefs.AwayMessage
+	}
+	m.setAway(m.Session, &awayState{message: message})
+}
+
+// autoReply answers a private message with the away message, once per user
+func (m *Model) autoReply(pm serverMsgMsg) {
+	if m.away == nil || m.away.message == "" || pm.userID == [2]byte{} || pm.automatic {
+		return
+	}
+	if m.away.replied[pm.userID] {
+		return
+	}
+	m.away.replied[pm.userID] = true
+
+	t := hotline.NewTransaction(
+		hotline.TranSendInstantMsg,
+		[2]byte{},
+		hotline.NewField(hotline.FieldUserID, pm.userID[:]),
+		hotline.NewField(hotline.FieldOptions, instantMsgAutoResponse),
+		hotline.NewField(hotline.FieldData, m.encodeText(m.away.message)),
+	)
+	if err := m.sendTransaction(t); err != nil {
+		m.logger.Error("Error sending away auto-reply", "err", err)
+	}
+}
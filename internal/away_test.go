@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jhalter/mobius/hotline"
+)
+
+// sentAway reports whether a TranSetClientUserInfo set the away flag
+func sentAway(t *testing.T, tran hotline.Transaction) bool {
+	t.Helper()
+	if tran.Type != hotline.TranSetClientUserInfo {
+		t.Fatalf("sent transaction type %v, want TranSetClientUserInfo", tran.Type)
+	}
+	flags := hotline.UserFlags(tran.GetField(hotline.FieldUserFlags).Data)
+	return flags.IsSet(hotline.UserFlagAway)
+}
+
+func TestCheckIdle(t *testing.T) {
+	tests := []struct {
+		name         string
+		autoAway     int
+		idle         time.Duration
+		reconnect    bool
+		disconnected bool
+		wantAway     bool
+	}{
+		{name: "idle", autoAway: 5, idle: 6 * time.Minute, wantAway: true},
+		{name: "active", autoAway: 5, idle: 4 * time.Minute},
+		{name: "disabled", autoAway: 0, idle: time.Hour},
+		{name: "reconnecting", autoAway: 5, idle: time.Hour, reconnect: true},
+		{name: "not connected", autoAway: 5, idle: time.Hour, disconnected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, s := newTestModel(t, &Settings{Username: "tester", AutoAwayMinutes: tt.autoAway, AwayMessage: "brb"})
+			conn := recordSent(s)
+			if !tt.disconnected {
+				s.activeConnection = &connectionParams{}
+			}
+			if tt.reconnect {
+				s.reconnect = &reconnectState{}
+			}
+			m.lastInput = time.Now().Add(-tt.idle)
+
+			if m.checkIdle() == nil {
+				t.Error("checkIdle didn't schedule the next check")
+			}
+			if (s.away != nil) != tt.wantAway {
+				t.Fatalf("away = %+v, want away %v", s.away, tt.wantAway)
+			}
+			sent := conn.sent(t)
+			if !tt.wantAway {
+				if len(sent) > 0 {
+					t.Errorf("sent %d transactions", len(sent))
+				}
+				return
+			}
+			if !s.away.auto || s.away.message != "brb" {
+				t.Errorf("away = %+v, want automatic with the away message", s.away)
+			}
+			if len(sent) != 1 || !sentAway(t, sent[0]) {
+				t.Errorf("sent %+v, want the away flag", sent)
+			}
+
+			// Already away, so the next check sends nothing
+			m.checkIdle()
+			if sent := conn.sent(t); len(sent) > 0 {
+				t.Errorf("second check sent %d transactions", len(sent))
+			}
+		})
+	}
+}
+
+func TestUserActive(t *testing.T) {
+	m, auto := newTestModel(t, &Settings{Username: "tester"})
+	manual := m.newSession()
+	m.sessions = append(m.sessions, manual)
+	autoConn, manualConn := recordSent(auto), recordSent(manual)
+	m.setAway(auto, &awayState{auto: true})
+	m.setAway(manual, &awayState{message: "at lunch"})
+	autoConn.sent(t)
+	manualConn.sent(t)
+
+	// A keypress brings back sessions the idle timer sent away, but not /away
+	m.userActive()
+	if auto.away != nil {
+		t.Error("automatic away wasn't cleared")
+	}
+	if sent := autoConn.sent(t); len(sent) != 1 || sentAway(t, sent[0]) {
+		t.Errorf("sent %+v, want the away flag cleared", sent)
+	}
+	if manual.away == nil || len(manualConn.sent(t)) > 0 {
+		t.Error("keypress ended /away")
+	}
+	if time.Since(m.lastInput) > time.Second {
+		t.Error("lastInput wasn't updated")
+	}
+}
+
+func TestAutoReply(t *testing.T) {
+	m, s := newTestModel(t, &Settings{Username: "tester"})
+	conn := recordSent(s)
+	bob, ann := [2]byte{0, 1}, [2]byte{0, 2}
+
+	// Not away yet
+	m.autoReply(serverMsgMsg{userID: bob, text: "hi"})
+	if sent := conn.sent(t); len(sent) > 0 {
+		t.Fatalf("replied while not away: %+v", sent)
+	}
+
+	m.setAway(s, &awayState{message: "at lunch"})
+	conn.sent(t)
+	for _, pm := range []serverMsgMsg{
+		{userID: bob, text: "hi"},
+		{userID: bob, text: "hello?"},              // Already answered
+		{userID: ann, text: "hi", automatic: true}, // Don't answer another auto-reply
+		{text: "server message"},                   // Not from a user
+		{userID: ann, text: "hi"},
+	} {
+		m.autoReply(pm)
+	}
+
+	sent := conn.sent(t)
+	if len(sent) != 2 {
+		t.Fatalf("sent %d replies, want one each to bob and ann", len(sent))
+	}
+	for i, want := range [][2]byte{bob, ann} {
+		tran := sent[i]
+		if tran.Type != hotline.TranSendInstantMsg || [2]byte(tran.GetField(hotline.FieldUserID).Data) != want {
+			t.Errorf("reply %d = %v to %v, want an instant message to %v", i, tran.Type, tran.GetField(hotline.FieldUserID).Data, want)
+		}
+		if got := string(tran.GetField(hotline.FieldData).Data); got != "at lunch" {
+			t.Errorf("reply %d text = %q", i, got)
+		}
+		if opts := tran.GetField(hotline.FieldOptions).Data; string(opts) != string(instantMsgAutoResponse) {
+			t.Errorf("reply %d options = %v, want the auto-response flag", i, opts)
+		}
+	}
+
+	// Without an away message nobody is answered
+	m.setAway(s, &awayState{})
+	conn.sent(t)
+	m.autoReply(serverMsgMsg{userID: [2]byte{0, 3}, text: "hi"})
+	if sent := conn.sent(t); len(sent) > 0 {
+		t.Errorf("replied without an away message: %+v", sent)
+	}
+}
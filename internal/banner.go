@@ -0,0 +1,199 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Banner formats used by Hotline servers
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jhalter/mobius/hotline"
+)
+
+// bannerCacheTTL is how long a cached banner is shown before it is downloaded again
+const bannerCacheTTL = 24 * time.Hour
+
+// maxBannerSize limits banner downloads; real banners are a few kilobytes
+const maxBannerSize = 4 << 20
+
+// maxBannerRows is the most terminal rows the banner may take up
+const maxBannerRows = 8
+
+// bannerMsg delivers a decoded server banner to the UI
+type bannerMsg struct {
+	img  image.Image
+	data []byte // Freshly downloaded image data to cache, nil when loaded from the cache
+}
+
+// bannerCachePath returns where the banner of the server at addr is cached
+func bannerCachePath(addr string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(addr))
+	return filepath.Join(dir, "mobius-hotline-client", "banners", hex.EncodeToString(sum[:])), nil
+}
+
+// loadBanner shows the cached banner of the connected server, downloading it
+// again when there is none or it has expired. Sessions that already have a
+// banner, such as after a reconnect, keep it.
+func (m *Model) loadBanner() tea.Cmd {
+	if m.banner != nil || m.activeConnection == nil {
+		return nil
+	}
+	s := m.Session
+	addr := m.activeConnection.addr
+
+	return func() tea.Msg {
+		var img image.Image
+		if path, err := bannerCachePath(addr); err == nil {
+			if fi, err := os.Stat(path); err == nil {
+				if img, err = readBanner(path); err != nil {
+					m.logger.Debug("Ignoring cached banner", "path", path, "err", err)
+				}
+				if img != nil && time.Since(fi.ModTime()) < bannerCacheTTL {
+					return bannerMsg{img: img}
+				}
+			}
+		}
+
+		if err := s.sendTransaction(hotline.NewTransaction(hotline.TranDownloadBanner, [2]byte{})); err != nil {
+			m.logger.Error("Error requesting server banner", "err", err)
+		}
+
+		// Show an expired banner until the download replaces it
+		if img != nil {
+			return bannerMsg{img: img}
+		}
+		return nil
+	}
+}
+
+// readBanner decodes a cached banner image
+func readBanner(path string) (image.Image, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	img, _, err := image.Decode(fh)
+	return img, err
+}
+
+// HandleDownloadBanner fetches the banner over the file transfer connection once
+// the server has assigned the transfer a reference number
+func (m *Model) HandleDownloadBanner(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
+	s := m.sessionFor(c)
+
+	// Servers without a banner may refuse; that isn't worth interrupting the user for
+	if t.ErrorCode != [4]byte{} {
+		m.logger.Debug("Server banner unavailable", "err", s.decodeText(t.GetField(hotline.FieldError).Data))
+		return res, err
+	}
+
+	if s == nil {
+		return res, err
+	}
+
+	var refNum [4]byte
+	copy(refNum[:], t.GetField(hotline.FieldRefNum).Data)
+	size := t.GetField(hotline.FieldTransferSize).Data
+	if len(size) != 4 || binary.BigEndian.Uint32(size) == 0 {
+		return res, err
+	}
+
+	go m.downloadBanner(s, refNum, binary.BigEndian.Uint32(size))
+
+	return res, err
+}
+
+// downloadBanner reads the banner from the transfer port and sends it to the session
+func (m *Model) downloadBanner(s *Session, refNum [4]byte, size uint32) {
+	data, err := m.fetchBanner(s, refNum, size)
+	if err != nil {
+		m.logger.Error("Banner download failed", "err", err)
+		return
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		m.logger.Error("Unable to decode server banner", "err", err)
+		return
+	}
+	m.logger.Info("Downloaded server banner", "format", format, "bounds", img.Bounds())
+
+	m.sendTo(s, bannerMsg{img: img, data: data})
+}
+
+// fetchBanner performs the HTXF handshake for a banner download and returns the
+// raw image data
+func (m *Model) fetchBanner(s *Session, refNum [4]byte, size uint32) ([]byte, error) {
+	if size > maxBannerSize {
+		return nil, fmt.Errorf("banner is too large (%d bytes)", size)
+	}
+
+	conn, err := m.dialTransfer(s, s.transferAddr)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	handshake := make([]byte, 16)
+	copy(handshake[0:4], "HTXF")
+	copy(handshake[4:8], refNum[:])
+	if _, err := conn.Write(handshake); err != nil {
+		return nil, fmt.Errorf("handshake failed: %w", err)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(conn, data); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("banner truncated: %w", err)
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (m *Model) handleBannerMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	banner := msg.(bannerMsg)
+	m.banner = banner.img
+	if m.serverInfoScreen != nil {
+		m.serverInfoScreen.SetSize(m.width, m.height)
+	}
+
+	if banner.data == nil || m.activeConnection == nil {
+		return m, nil
+	}
+	addr := m.activeConnection.addr
+	return m, func() tea.Msg {
+		path, err := bannerCachePath(addr)
+		if err == nil {
+			if err = os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+				err = os.WriteFile(path, banner.data, 0600)
+			}
+		}
+		if err != nil {
+			m.logger.Error("Unable to cache server banner", "err", err)
+		}
+		return nil
+	}
+}
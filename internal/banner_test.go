@@ -0,0 +1,188 @@
+package internal
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"testing"
+)
+
+// solidImage returns a w by h image filled with c
+func solidImage(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestBannerCells(t *testing.T) {
+	tests := []struct {
+		w, h, maxCols, maxRows int
+		cols, rows             int
+	}{
+		{w: 468, h: 60, maxCols: 80, maxRows: 8, cols: 80, rows: 5},   // Classic banner, width bound
+		{w: 468, h: 60, maxCols: 200, maxRows: 8, cols: 124, rows: 8}, // Height bound
+		{w: 40, h: 10, maxCols: 80, maxRows: 8, cols: 40, rows: 5},    // Never enlarged past a cell per pixel
+		{w: 10, h: 400, maxCols: 80, maxRows: 8, cols: 1, rows: 8},
+		{w: 468, h: 60, maxCols: 0, maxRows: 8},
+		{w: 0, h: 60, maxCols: 80, maxRows: 8},
+	}
+	for _, tt := range tests {
+		cols, rows := bannerCells(image.NewRGBA(image.Rect(0, 0, tt.w, tt.h)), tt.maxCols, tt.maxRows)
+		if cols != tt.cols || rows != tt.rows {
+			t.Errorf("bannerCells(%dx%d in %dx%d) = %dx%d, want %dx%d", tt.w, tt.h, tt.maxCols, tt.maxRows, cols, rows, tt.cols, tt.rows)
+		}
+	}
+}
+
+func TestScaleImage(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.SetRGBA(0, 0, color.RGBA{R: 200, A: 0xff})
+	src.SetRGBA(1, 0, color.RGBA{R: 100, A: 0xff})
+	src.SetRGBA(0, 1, color.RGBA{B: 40, A: 0xff})
+	src.SetRGBA(1, 1, color.RGBA{B: 40, A: 0xff})
+
+	got := scaleImage(src, 1, 1).RGBAAt(0, 0)
+	if want := (color.RGBA{R: 75, B: 20, A: 0xff}); got != want {
+		t.Errorf("scaled pixel = %v, want the average %v", got, want)
+	}
+}
+
+func TestRenderBanner(t *testing.T) {
+	img := solidImage(40, 20, color.RGBA{R: 255, A: 0xff})
+
+	tests := []struct {
+		graphics       string
+		prefix, suffix string
+	}{
+		{graphics: bannerGraphicsHalfBlock, prefix: "\x1b[38;2;255;0;0m\x1b[48;2;255;0;0m▀"},
+		{graphics: bannerGraphicsSixel, prefix: "\x1bPq"},
+		{graphics: bannerGraphicsKitty, prefix: "\x1b_Ga=T,f=100"},
+	}
+	for _, tt := range tests {
+		out, rows := renderBanner(img, tt.graphics, 80, 8)
+		if rows != 8 || !strings.HasPrefix(out, tt.prefix) {
+			t.Errorf("%s: %d rows, starting %q; want 8 rows starting %q", tt.graphics, rows, out[:min(len(out), 24)], tt.prefix)
+		}
+		// The image takes up its rows, so the layout below it lines up
+		if lines := strings.Count(out, "\n") + 1; lines != rows {
+			t.Errorf("%s: %d lines for %d rows", tt.graphics, lines, rows)
+		}
+	}
+
+	if out, rows := renderBanner(img, bannerGraphicsOff, 80, 8); out != "" || rows != 0 {
+		t.Errorf("off rendered %d rows", rows)
+	}
+}
+
+func TestWriteSixelRun(t *testing.T) {
+	tests := []struct {
+		row, want string
+	}{
+		{row: "??", want: "??"},
+		{row: "???", want: "???"},
+		{row: "????", want: "!4?"},
+		{row: "@@@@@A?", want: "!5@A?"},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		writeSixelRun(&out, []byte(tt.row))
+		if out.String() != tt.want {
+			t.Errorf("writeSixelRun(%q) = %q, want %q", tt.row, out.String(), tt.want)
+		}
+	}
+}
+
+func TestEncodeKittyChunks(t *testing.T) {
+	// Noise doesn't compress, so the PNG needs several chunks
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	_, _ = rand.New(rand.NewSource(1)).Read(img.Pix)
+
+	out := encodeKitty(img, 10, 5)
+	chunks := strings.Split(strings.TrimSuffix(out, "\x1b\\"), "\x1b\\")
+	if len(chunks) < 2 {
+		t.Fatalf("sent %d chunks, want several", len(chunks))
+	}
+	if !strings.Contains(chunks[0], "c=10,r=5,m=1;") {
+		t.Errorf("first chunk header = %q", chunks[0][:min(len(chunks[0]), 60)])
+	}
+	for _, chunk := range chunks[1 : len(chunks)-1] {
+		if !strings.HasPrefix(chunk, "\x1b_Gm=1;") {
+			t.Errorf("middle chunk starts %q", chunk[:min(len(chunk), 10)])
+		}
+	}
+	if last := chunks[len(chunks)-1]; !strings.HasPrefix(last, "\x1b_Gm=0;") {
+		t.Errorf("last chunk starts %q", last[:min(len(last), 10)])
+	}
+}
+
+func TestBannerGraphicsSetting(t *testing.T) {
+	t.Setenv("KITTY_WINDOW_ID", "")
+	t.Setenv("TERM_PROGRAM", "")
+	t.Setenv("TERM", "xterm-256color")
+
+	tests := []struct {
+		setting, want string
+	}{
+		{setting: "Sixel", want: bannerGraphicsSixel},
+		{setting: "off", want: bannerGraphicsOff},
+		{setting: "", want: bannerGraphicsHalfBlock},
+		{setting: "auto", want: bannerGraphicsHalfBlock},
+	}
+	for _, tt := range tests {
+		if got := (&Settings{BannerGraphics: tt.setting}).bannerGraphics(); got != tt.want {
+			t.Errorf("bannerGraphics(%q) = %q, want %q", tt.setting, got, tt.want)
+		}
+	}
+
+	t.Setenv("TERM", "xterm-kitty")
+	if got := detectBannerGraphics(); got != bannerGraphicsKitty {
+		t.Errorf("detected %q in kitty", got)
+	}
+	t.Setenv("TERM", "foot")
+	if got := detectBannerGraphics(); got != bannerGraphicsSixel {
+		t.Errorf("detected %q in foot", got)
+	}
+}
+
+func TestFetchBanner(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	data := []byte("GIF89a banner bytes")
+	refNum := [4]byte{1, 2, 3, 4}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		handshake := make([]byte, 16)
+		if _, err := io.ReadFull(conn, handshake); err != nil || string(handshake[:4]) != "HTXF" || [4]byte(handshake[4:8]) != refNum {
+			return
+		}
+		_, _ = conn.Write(data)
+	}()
+
+	m, s := newTestModel(t, &Settings{})
+	s.transferAddr = ln.Addr().String()
+
+	got, err := m.fetchBanner(s, refNum, uint32(len(data)))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("fetchBanner = %q, %v; want the banner data", got, err)
+	}
+
+	if _, err := m.fetchBanner(s, refNum, maxBannerSize+1); err == nil {
+		t.Error("fetchBanner accepted an oversized banner")
+	}
+}
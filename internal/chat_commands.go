@@ -0,0 +1,344 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jhalter/mobius-hotline-client/internal/style"
+	"github.com/jhalter/mobius/hotline"
+)
+
+// chatOptionEmote marks a chat message as an emote, shown as "*** name text"
+var chatOptionEmote = []byte{0x00, 0x01}
+
+var chatErrorStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("9"))
+
+// errChatCommandUsage means a command's arguments were missing or malformed
+var errChatCommandUsage = errors.New("usage")
+
+// chatCommand is a slash command typed into the chat input
+type chatCommand struct {
+	name  string
+	usage string // Arguments, e.g. "<user> <text>"
+	help  string
+	run   func(args string) error // args is the rest of the line, trimmed
+}
+
+// commandOutputMsg shows the result of a command in the chat pane
+type commandOutputMsg struct {
+	text string
+	err  bool
+}
+
+// userInfoMsg carries the reply to /info
+type userInfoMsg struct {
+	name string
+	text string
+}
+
+// registerChatCommand makes a command available in the chat input
+func (m *Model) registerChatCommand(cmd chatCommand) {
+	m.chatCommands[cmd.name] = cmd
+}
+
+func (m *Model) registerChatCommands() {
+	m.registerChatCommand(chatCommand{name: "help", usage: "[command]", help: "List commands, or show help for one", run: m.chatCmdHelp})
+	m.registerChatCommand(chatCommand{name: "me", usage: "<action>", help: "Describe what you are doing, as *** name action", run: m.chatCmdMe})
+	m.registerChatCommand(chatCommand{name: "nick", usage: "<name>", help: "Change your name on every server and save it as your default", run: m.chatCmdNick})
+	m.registerChatCommand(chatCommand{name: "icon", usage: "<id>", help: "Change your icon", run: m.chatCmdIcon})
+	m.registerChatCommand(chatCommand{name: "msg", usage: "<user> <text>", help: "Send a private message", run: m.chatCmdMsg})
+	m.registerChatCommand(chatCommand{name: "away", usage: "[message]", help: "Go away, or come back if away", run: m.chatCmdAway})
+	m.registerChatCommand(chatCommand{name: "info", usage: "<user>", help: "Show a user's connection details", run: m.chatCmdInfo})
+	m.registerChatCommand(chatCommand{name: "kick", usage: "<user>", help: "Disconnect a user from the server", run: m.chatCmdKick})
+	m.registerChatCommand(chatCommand{name: "clear", help: "Clear the chat pane", run: m.chatCmdClear})
+	m.registerChatCommand(chatCommand{name: "files", usage: "[path]", help: "Open the file browser, optionally at a folder", run: m.chatCmdFiles})
+	m.registerChatCommand(chatCommand{name: "news", help: "Open the news", run: m.chatCmdNews})
+	m.registerChatCommand(chatCommand{name: "ignore", usage: "[-global|-bookmark] <user|pattern>", help: "Ignore a user, or list who is ignored", run: m.chatCmdIgnore})
+	m.registerChatCommand(chatCommand{name: "unignore", usage: "<user|pattern>", help: "Stop ignoring a user or pattern", run: m.chatCmdUnignore})
+	m.registerChatCommand(chatCommand{name: "export", usage: "[-md|-html] [HH:MM[-HH:MM]] [file]", help: "Save the chat, or part of it, to Markdown or HTML", run: m.chatCmdExport})
+}
+
+// runChatCommand runs a line starting with "/". Problems are shown in the chat
+// pane; nothing typed here is ever sent to the server as chat.
+func (m *Model) runChatCommand(line string) {
+	name, args, _ := strings.Cut(strings.TrimPrefix(line, "/"), " ")
+	cmd, ok := m.chatCommands[strings.ToLower(name)]
+	if !ok {
+		m.chatError(fmt.Sprintf("Unknown command /%s. Type /help for a list of commands.", name))
+		return
+	}
+
+	err := cmd.run(strings.TrimSpace(args))
+	switch {
+	case errors.Is(err, errChatCommandUsage):
+		m.chatError(fmt.Sprintf("Usage: %s", cmd.synopsis()))
+	case err != nil:
+		m.chatError(fmt.Sprintf("/%s: %v", cmd.name, err))
+	}
+}
+
+// synopsis returns the command with its arguments, e.g. "/msg <user> <text>"
+func (cmd chatCommand) synopsis() string {
+	if cmd.usage == "" {
+		return "/" + cmd.name
+	}
+	return "/" + cmd.name + " " + cmd.usage
+}
+
+// chatNotice shows a line from the client in the chat pane
+func (m *Model) chatNotice(text string) {
+	if m.serverScreen != nil {
+		m.serverScreen.AddChatMessage(style.NoticeStyle.Render(text))
+	}
+}
+
+// chatError shows an error in the chat pane
+func (m *Model) chatError(text string) {
+	if m.serverScreen != nil {
+		m.serverScreen.AddChatMessage(chatErrorStyle.Render(text))
+	}
+}
+
+// findUser returns the user on the server with the given name, ignoring case
+func (m *Model) findUser(name string) (hotline.User, bool) {
+	for _, u := range m.userList {
+		if strings.EqualFold(u.Name, name) {
+			return u, true
+		}
+	}
+	return hotline.User{}, false
+}
+
+// cutUserArg splits args into the user named at the start and the rest. Names
+// with spaces can be quoted, and otherwise match the longest user name on the
+// server that args starts with.
+func (m *Model) cutUserArg(args string) (hotline.User, string, error) {
+	var name, rest string
+	if quoted, ok := strings.CutPrefix(args, `"`); ok {
+		var closed bool
+		if name, rest, closed = strings.Cut(quoted, `"`); !closed {
+			return hotline.User{}, "", errChatCommandUsage
+		}
+	} else {
+		name, rest, _ = strings.Cut(args, " ")
+		for _, u := range m.userList {
+			n := len(u.Name)
+			if n > len(name) && len(args) >= n && strings.EqualFold(args[:n], u.Name) && (len(args) == n || args[n] == ' ') {
+				name, rest = u.Name, args[n:]
+			}
+		}
+	}
+	if name == "" {
+		return hotline.User{}, "", errChatCommandUsage
+	}
+
+	u, ok := m.findUser(name)
+	if !ok {
+		return hotline.User{}, "", fmt.Errorf("no user named %q is online", name)
+	}
+	return u, strings.TrimSpace(rest), nil
+}
+
+func (m *Model) chatCmdHelp(args string) error {
+	if args != "" {
+		cmd, ok := m.chatCommands[strings.ToLower(strings.TrimPrefix(args, "/"))]
+		if !ok {
+			return fmt.Errorf("unknown command /%s", strings.TrimPrefix(args, "/"))
+		}
+		m.chatNotice(fmt.Sprintf("%s  %s", cmd.synopsis(), cmd.help))
+		return nil
+	}
+
+	m.chatNotice("Commands (start a line with // to send a slash):")
+	for _, name := range slices.Sorted(maps.Keys(m.chatCommands)) {
+		cmd := m.chatCommands[name]
+		m.chatNotice(fmt.Sprintf("  %-22s %s", cmd.synopsis(), cmd.help))
+	}
+	return nil
+}
+
+func (m *Model) chatCmdMe(args string) error {
+	if args == "" {
+		return errChatCommandUsage
+	}
+	t := hotline.NewTransaction(hotline.TranChatSend, [2]byte{},
+		hotline.NewField(hotline.FieldData, m.encodeText(args)),
+		hotline.NewField(hotline.FieldChatOptions, chatOptionEmote),
+	)
+	return m.sendTransaction(t)
+}
+
+// maxUserNameLen is the longest name, in the server's encoding, that Hotline
+// clients and servers accept
+const maxUserNameLen = 31
+
+// chatCmdNick changes the name used on every server and saves it, like the
+// Settings screen's Your Name
+func (m *Model) chatCmdNick(args string) error {
+	if args == "" {
+		return errChatCommandUsage
+	}
+	if len(m.encodeText(args)) > maxUserNameLen {
+		return fmt.Errorf("names can be at most %d characters", maxUserNameLen)
+	}
+	m.prefs.Username = args
+	m.updateHighlights()
+	m.updateTriggers()
+	m.updateUserInfo()
+	m.chatNotice(fmt.Sprintf("You are now known as %s", args))
+	return nil
+}
+
+func (m *Model) chatCmdIcon(args string) error {
+	id, err := strconv.ParseUint(args, 10, 16)
+	if err != nil {
+		return errChatCommandUsage
+	}
+	m.prefs.IconID = int(id)
+	m.updateUserInfo()
+	m.chatNotice(fmt.Sprintf("Your icon is now %d", id))
+	return nil
+}
+
+// updateUserInfo saves a changed name or icon and sends it to every server
+func (m *Model) updateUserInfo() {
+	if err := m.savePreferences(); err != nil {
+		m.logger.Error("Failed to save preferences", "err", err)
+	}
+	for _, s := range m.sessions {
+		if s.activeConnection != nil {
+			m.sendUserInfo(s)
+		}
+	}
+}
+
+func (m *Model) chatCmdMsg(args string) error {
+	u, text, err := m.cutUserArg(args)
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		return errChatCommandUsage
+	}
+	if err := m.sendPrivateMessage(u.ID, text, ""); err != nil {
+		return err
+	}
+	m.chatNotice(fmt.Sprintf("[private message to %s] %s", u.Name, text))
+	return nil
+}
+
+func (m *Model) chatCmdAway(args string) error {
+	m.toggleAway(args)
+	return nil
+}
+
+func (m *Model) chatCmdInfo(args string) error {
+	if !m.userAccess.IsSet(hotline.AccessGetClientInfo) {
+		return errors.New("you are not allowed to get user info")
+	}
+	u, rest, err := m.cutUserArg(args)
+	if err != nil {
+		return err
+	}
+	if rest != "" {
+		return errChatCommandUsage
+	}
+	return m.sendTransaction(hotline.NewTransaction(hotline.TranGetClientInfoText, [2]byte{},
+		hotline.NewField(hotline.FieldUserID, u.ID[:]),
+	))
+}
+
+func (m *Model) chatCmdKick(args string) error {
+	if !m.userAccess.IsSet(hotline.AccessDisconUser) {
+		return errors.New("you are not allowed to disconnect users")
+	}
+	u, rest, err := m.cutUserArg(args)
+	if err != nil {
+		return err
+	}
+	if rest != "" {
+		return errChatCommandUsage
+	}
+	return m.sendTransaction(hotline.NewTransaction(hotline.TranDisconnectUser, [2]byte{},
+		hotline.NewField(hotline.FieldUserID, u.ID[:]),
+	))
+}
+
+func (m *Model) chatCmdClear(args string) error {
+	if args != "" {
+		return errChatCommandUsage
+	}
+	if m.serverScreen != nil {
+		m.serverScreen.ClearChat()
+	}
+	return nil
+}
+
+func (m *Model) chatCmdFiles(args string) error {
+	var path []string
+	for _, p := range strings.Split(args, "/") {
+		if p != "" {
+			path = append(path, p)
+		}
+	}
+	m.filesScreen = NewFilesScreen(m)
+	m.handleFilesNavigateMsg(FilesNavigateMsg{Path: path})
+	return nil
+}
+
+func (m *Model) chatCmdNews(args string) error {
+	if args != "" {
+		return errChatCommandUsage
+	}
+	if !m.userAccess.IsSet(hotline.AccessNewsReadArt) {
+		return errors.New("you are not allowed to read news")
+	}
+	m.handleServerOpenNewsMsg()
+	return nil
+}
+
+func (m *Model) handleCommandOutputMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	out := msg.(commandOutputMsg)
+	if out.err {
+		m.chatError(out.text)
+	} else {
+		m.chatNotice(out.text)
+	}
+	return m, nil
+}
+
+func (m *Model) handleUserInfoMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	info := msg.(userInfoMsg)
+	m.modalScreen = NewModalScreen(ModalTypeGeneric, fmt.Sprintf("User Info: %s", info.name), info.text, []string{"Close"}, m)
+	m.PushScreen(ScreenModal)
+	return m, m.modalScreen.Init()
+}
+
+// HandleGetClientInfoText handles the reply to /info
+func (m *Model) HandleGetClientInfoText(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
+	if t.ErrorCode != [4]byte{} {
+		m.reply(c, t, commandOutputMsg{text: "/info: " + m.sessionFor(c).decodeText(t.GetField(hotline.FieldError).Data), err: true})
+		return res, err
+	}
+
+	s := m.sessionFor(c)
+	text := strings.ReplaceAll(s.decodeText(t.GetField(hotline.FieldData).Data), "\r", "\n")
+	m.reply(c, t, userInfoMsg{name: s.decodeText(t.GetField(hotline.FieldUserName).Data), text: text})
+	return res, err
+}
+
+// HandleDisconnectUser handles the reply to /kick. The user's departure shows up
+// in chat on its own, so only failures are reported.
+func (m *Model) HandleDisconnectUser(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
+	if t.ErrorCode != [4]byte{} {
+		m.reply(c, t, commandOutputMsg{text: "/kick: " + m.sessionFor(c).decodeText(t.GetField(hotline.FieldError).Data), err: true})
+	}
+	return res, err
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jhalter/mobius/hotline"
+)
+
+// newTestChatModel returns a model with a server screen to show command output
+// and a "test" command that records its arguments
+func newTestChatModel(t *testing.T, ran *[]string) *Model {
+	t.Helper()
+	m, _ := newTestModel(t, &Settings{Username: "tester"})
+	m.chatCommands = make(map[string]chatCommand)
+	m.serverScreen = NewServerScreen(m)
+	m.registerChatCommand(chatCommand{name: "test", usage: "<arg>", run: func(args string) error {
+		switch args {
+		case "":
+			return errChatCommandUsage
+		case "fail":
+			return errors.New("it failed")
+		}
+		*ran = append(*ran, args)
+		return nil
+	}})
+	return m
+}
+
+// chatLines returns the chat pane's messages without styling
+func chatLines(m *Model) []string {
+	var lines []string
+	for _, text := range m.serverScreen.chat.texts() {
+		lines = append(lines, ansi.Strip(text))
+	}
+	return lines
+}
+
+func TestRunChatCommand(t *testing.T) {
+	tests := []struct {
+		line   string
+		args   string // Arguments the command ran with, empty if it didn't run
+		output string // Error shown in the chat pane
+	}{
+		{line: "/test hello", args: "hello"},
+		{line: "/TEST hello", args: "hello"},
+		{line: "/test   two  words  ", args: "two  words"},
+		{line: "/test", output: "Usage: /test <arg>"},
+		{line: "/test fail", output: "/test: it failed"},
+		{line: "/nope x", output: "Unknown command /nope. Type /help for a list of commands."},
+		{line: "/", output: "Unknown command /."},
+	}
+	for _, tt := range tests {
+		var ran []string
+		m := newTestChatModel(t, &ran)
+		m.runChatCommand(tt.line)
+
+		if tt.args != "" && (len(ran) != 1 || ran[0] != tt.args) {
+			t.Errorf("%q ran with %q, want %q", tt.line, ran, tt.args)
+		}
+		if tt.args == "" && len(ran) > 0 {
+			t.Errorf("%q ran with %q", tt.line, ran)
+		}
+
+		lines := chatLines(m)
+		switch {
+		case tt.output == "" && len(lines) > 0:
+			t.Errorf("%q showed %q", tt.line, lines)
+		case tt.output != "" && (len(lines) != 1 || !strings.HasPrefix(lines[0], tt.output)):
+			t.Errorf("%q showed %q, want %q", tt.line, lines, tt.output)
+		}
+	}
+}
+
+func TestCutUserArg(t *testing.T) {
+	m, _ := newTestModel(t, &Settings{})
+	m.userList = []hotline.User{{Name: "bob"}, {Name: "Bob Smith"}, {Name: "ann"}}
+
+	tests := []struct {
+		args, user, rest string
+		err              string // Substring of the error, empty for none
+	}{
+		{args: "ann hi there", user: "ann", rest: "hi there"},
+		{args: "ANN", user: "ann"},
+		{args: "bob hi", user: "bob", rest: "hi"},
+		{args: "bob smith hi", user: "Bob Smith", rest: "hi"},
+		{args: "bobsmith hi", err: `no user named "bobsmith"`},
+		{args: `"bob" smith`, user: "bob", rest: "smith"},
+		{args: `"Bob Smith"`, user: "Bob Smith"},
+		{args: `"bob`, err: "usage"},
+		{args: "", err: "usage"},
+		{args: "carol hi", err: "is online"},
+	}
+	for _, tt := range tests {
+		u, rest, err := m.cutUserArg(tt.args)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("cutUserArg(%q) error = %v, want %q", tt.args, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || u.Name != tt.user || rest != tt.rest {
+			t.Errorf("cutUserArg(%q) = %q, %q, %v; want %q, %q", tt.args, u.Name, rest, err, tt.user, tt.rest)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jhalter/mobius/hotline"
+)
+
+// nickCompletion cycles through the user names that complete the word before
+// the cursor in the chat input
+type nickCompletion struct {
+	head    string // Input before the word being completed
+	tail    string // Input after the cursor
+	matches []string
+	index   int    // Match last inserted, -1 before the first
+	result  string // Input after the last completion, to notice edits
+}
+
+// newNickCompletion finds the users whose names start with the word ending at
+// pos, ignoring case. It returns nil when nothing matches.
+func newNickCompletion(value string, pos int, users []hotline.User) *nickCompletion {
+	runes := []rune(value)
+	pos = min(pos, len(runes))
+	head, tail := string(runes[:pos]), string(runes[pos:])
+
+	start := strings.LastIndex(head, " ") + 1
+	word := strings.ToLower(head[start:])
+	if word == "" {
+		return nil
+	}
+
+	var matches []string
+	for _, u := range users {
+		if strings.HasPrefix(strings.ToLower(u.Name), word) && !slices.Contains(matches, u.Name) {
+			matches = append(matches, u.Name)
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	slices.SortFunc(matches, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+
+	return &nickCompletion{head: head[:start], tail: tail, matches: matches, index: -1}
+}
+
+// next returns the input with the next match inserted and the cursor position
+// after it. Names at the start of the line are followed by ": ".
+func (c *nickCompletion) next() (string, int) {
+	c.index = (c.index + 1) % len(c.matches)
+
+	suffix := " "
+	if c.head == "" {
+		suffix = ": "
+	}
+	if strings.HasPrefix(c.tail, " ") {
+		suffix = strings.TrimSuffix(suffix, " ")
+	}
+
+	completed := c.head + c.matches[c.index] + suffix
+	c.result = completed + c.tail
+	return c.result, utf8.RuneCountInString(completed)
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/jhalter/mobius/hotline"
+)
+
+func TestNickCompletion(t *testing.T) {
+	users := []hotline.User{{Name: "bob"}, {Name: "Bobby"}, {Name: "ann"}, {Name: "bob"}, {Name: "Ærlig"}}
+
+	tests := []struct {
+		value string
+		pos   int
+		want  []string // Inputs after each press, wrapping around
+		cur   int      // Cursor after the first press
+	}{
+		{value: "bo", pos: 2, want: []string{"bob: ", "Bobby: ", "bob: "}, cur: 5},
+		{value: "hi AN", pos: 5, want: []string{"hi ann "}, cur: 7},
+		{value: "hi an there", pos: 5, want: []string{"hi ann there"}, cur: 6},
+		{value: "æ", pos: 1, want: []string{"Ærlig: "}, cur: 7},
+		{value: "hi ", pos: 3},
+		{value: "carol", pos: 5},
+	}
+	for _, tt := range tests {
+		c := newNickCompletion(tt.value, tt.pos, users)
+		if tt.want == nil {
+			if c != nil {
+				t.Errorf("completing %q found %q", tt.value, c.matches)
+			}
+			continue
+		}
+		if c == nil {
+			t.Errorf("completing %q found nothing", tt.value)
+			continue
+		}
+		for i, want := range tt.want {
+			got, cur := c.next()
+			if got != want {
+				t.Errorf("completing %q, press %d = %q, want %q", tt.value, i+1, got, want)
+			}
+			if i == 0 && cur != tt.cur {
+				t.Errorf("completing %q, cursor = %d, want %d", tt.value, cur, tt.cur)
+			}
+		}
+	}
+}
+
+func TestServerScreenCompleteNick(t *testing.T) {
+	m, _ := newTestModel(t, &Settings{})
+	s := NewServerScreen(m)
+	s.SetUserList([]hotline.User{{Name: "bob"}, {Name: "bobby"}})
+
+	s.chatInput.SetValue("bo")
+	s.completeNick()
+	s.completeNick()
+	if got := s.chatInput.Value(); got != "bobby: " {
+		t.Errorf("after two presses, input = %q", got)
+	}
+
+	// Editing the input starts a new completion
+	s.chatInput.SetValue("hi b")
+	if !s.completeNick() || s.chatInput.Value() != "hi bob " {
+		t.Errorf("after editing, input = %q", s.chatInput.Value())
+	}
+
+	s.chatInput.SetValue("hi ")
+	if s.completeNick() {
+		t.Error("completed an empty word")
+	}
+}
@@ -0,0 +1,540 @@
+package internal
+
+import (
+	"encoding/binary"
+	"fmt"
+	"html"
+	"image/color"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jhalter/mobius-hotline-client/internal/style"
+	"github.com/jhalter/mobius/hotline"
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// Transcript formats, picked with /export's -md and -html flags or the file's extension
+const (
+	exportMarkdown = "md"
+	exportHTML     = "html"
+)
+
+// exportTimePattern matches /export's time range, e.g. "14:00-15:30"
+var exportTimePattern = regexp.MustCompile(`^(\d{1,2}:\d{2})(?:-(\d{1,2}:\d{2}))?$`)
+
+// ansiPattern matches the escape sequences in chat lines: SGR styling, OSC 8
+// hyperlinks, and anything else, which is dropped
+var ansiPattern = regexp.MustCompile("\x1b\\[([0-9;:]*)m|\x1b\\]8;[^;\x07\x1b]*;([^\x07\x1b]*)(?:\x07|\x1b\\\\)|\x1b\\][^\x07\x1b]*(?:\x07|\x1b\\\\)|\x1b\\[[0-9;?]*[ -/]*[@-~]|\x1b[@-_]")
+
+// transcriptLine is one chat message to export
+type transcriptLine struct {
+	when string // Time of day, from when it was added or its chat log stamp
+	text string // As shown, with ANSI styling
+}
+
+// transcript is a stretch of public chat on a server
+type transcript struct {
+	server   string
+	addr     string
+	exported time.Time
+	users    []hotline.User
+	lines    []transcriptLine
+}
+
+// exportOptions are the parsed arguments of /export
+type exportOptions struct {
+	format   string
+	from, to time.Time // Zero for the whole scrollback
+	path     string
+}
+
+// parseExportArgs reads "[-md|-html] [from[-to]] [file]". Times are today's,
+// or yesterday's if still to come.
+func parseExportArgs(args string, now time.Time) (exportOptions, error) {
+	var opts exportOptions
+	for _, arg := range strings.Fields(args) {
+		switch {
+		case arg == "-md" || arg == "-markdown":
+			opts.format = exportMarkdown
+		case arg == "-html":
+			opts.format = exportHTML
+		case strings.HasPrefix(arg, "-"):
+			return opts, errChatCommandUsage
+		case exportTimePattern.MatchString(arg) && opts.from.IsZero():
+			times := exportTimePattern.FindStringSubmatch(arg)
+			from, err := clockTime(times[1], now)
+			if err != nil {
+				return opts, err
+			}
+			if from.After(now) {
+				from = from.AddDate(0, 0, -1)
+			}
+			to := now
+			if times[2] != "" {
+				if to, err = clockTime(times[2], from); err != nil {
+					return opts, err
+				}
+				if to.Before(from) {
+					to = to.AddDate(0, 0, 1)
+				}
+				to = to.Add(time.Minute - time.Nanosecond) // Include the last minute
+			}
+			opts.from, opts.to = from, to
+		case opts.path == "":
+			opts.path = arg
+		default:
+			return opts, errChatCommandUsage
+		}
+	}
+
+	if opts.format == "" {
+		opts.format = exportMarkdown
+		if ext := strings.ToLower(filepath.Ext(opts.path)); ext == ".html" || ext == ".htm" {
+			opts.format = exportHTML
+		}
+	}
+	return opts, nil
+}
+
+// clockTime returns the time of day "15:04" on day's date
+func clockTime(s string, day time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+}
+
+// transcript collects the public chat in the scrollback between from and to
+func (m *Model) transcript(from, to time.Time) transcript {
+	t := transcript{exported: time.Now(), users: m.userList, server: m.serverName}
+	if m.activeConnection != nil {
+		t.addr = m.activeConnection.addr
+	}
+
+	m.serverScreen.chat.each(func(text string, when time.Time) {
+		if !from.IsZero() && (when.Before(from) || when.After(to)) {
+			return
+		}
+		// Lines loaded back from the chat log start with their stamp, which
+		// the transcript shows on its own
+		stamp := "[" + when.Format(chatLogTimeFormat) + "] "
+		if plain := ansi.Strip(text); strings.HasPrefix(plain, stamp) {
+			text = strings.TrimPrefix(plain, stamp)
+		}
+		t.lines = append(t.lines, transcriptLine{when: when.Format(time.TimeOnly), text: text})
+	})
+	return t
+}
+
+// exportPath resolves /export's file argument: a leading "~" is the home
+// directory, and relative paths are in the download directory
+func exportPath(path, downloadDir string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(downloadDir, path)
+	}
+	return path, nil
+}
+
+func (m *Model) chatCmdExport(args string) error {
+	opts, err := parseExportArgs(args, time.Now())
+	if err != nil {
+		return err
+	}
+	t := m.transcript(opts.from, opts.to)
+	if len(t.lines) == 0 {
+		return fmt.Errorf("no chat to export")
+	}
+
+	path := opts.path
+	if path == "" {
+		path = fmt.Sprintf("%s %s.%s", chatLogServerDir(t.addr), t.exported.Format("2006-01-02 150405"), opts.format)
+	}
+	path, err = exportPath(path, m.downloadDir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	path = uniquePath(path)
+
+	content := t.markdown()
+	if opts.format == exportHTML {
+		content = t.html()
+	}
+	// Never overwrite a file that appeared since picking the name
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	m.chatNotice(fmt.Sprintf("Exported %d lines to %s", len(t.lines), path))
+	return nil
+}
+
+// userFlags reports whether a user is an admin and whether they are away
+func userFlags(u hotline.User) (admin, away bool) {
+	if len(u.Flags) < 2 {
+		return false, false
+	}
+	flags := binary.BigEndian.Uint16(u.Flags)
+	return flags&(1<<hotline.UserFlagAdmin) != 0, flags&(1<<hotline.UserFlagAway) != 0
+}
+
+// markdownEscaper escapes characters Markdown would treat as formatting
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "~", `\~`,
+)
+
+// markdown renders the transcript as a Markdown list, one line per message
+func (t transcript) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Chat on %s\n\n", markdownEscaper.Replace(t.server))
+	fmt.Fprintf(&b, "Exported %s from %s\n\n", t.exported.Format("2006-01-02 15:04"), markdownEscaper.Replace(t.addr))
+
+	if len(t.users) > 0 {
+		var users []string
+		for _, u := range t.users {
+			name := markdownEscaper.Replace(u.Name)
+			admin, away := userFlags(u)
+			if admin {
+				name = "**" + name + "**"
+			}
+			if away {
+				name += " (away)"
+			}
+			users = append(users, name)
+		}
+		fmt.Fprintf(&b, "Users online: %s\n\n", strings.Join(users, ", "))
+	}
+
+	for _, line := range t.lines {
+		b.WriteString("- ")
+		if line.when != "" {
+			fmt.Fprintf(&b, "`%s` ", line.when)
+		}
+
+		text := strings.TrimSpace(ansi.Strip(line.text))
+		if speaker, body, ok := strings.Cut(text, ":  "); ok {
+			fmt.Fprintf(&b, "**%s:** %s", markdownEscaper.Replace(strings.TrimSpace(speaker)), markdownEscaper.Replace(body))
+		} else {
+			// Emotes and join, leave and rename notices
+			fmt.Fprintf(&b, "*%s*", markdownEscaper.Replace(text))
+		}
+		b.WriteString("\n")
+	}
+	return strings.ReplaceAll(b.String(), "\r", "")
+}
+
+// html renders the transcript as a self-contained page, converting the chat
+// lines' ANSI styling to CSS
+func (t transcript) html() string {
+	var b strings.Builder
+	title := html.EscapeString("Chat on " + t.server)
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n", title)
+	b.WriteString("body { background: #1c1c1c; color: #d0d0d0; font-family: ui-monospace, Menlo, Consolas, monospace; margin: 2em; }\n")
+	b.WriteString("h1 { font-size: 1.3em; }\n")
+	b.WriteString(".chat { white-space: pre-wrap; line-height: 1.4; }\n")
+	b.WriteString("a { color: inherit; }\n")
+	fmt.Fprintf(&b, ".time { color: %s; }\n", lipglossCSS(style.ColorDarkGrey))
+	fmt.Fprintf(&b, ".admin { %s }\n", styleCSS(style.AdminUserStyle))
+	fmt.Fprintf(&b, ".away { %s }\n", styleCSS(style.AwayUserStyle))
+	fmt.Fprintf(&b, ".away-admin { %s }\n", styleCSS(style.AwayAdminUserStyle))
+	fmt.Fprintf(&b, ".join-leave { %s }\n", styleCSS(style.NoticeStyle))
+	b.WriteString("</style>\n</head>\n<body>\n")
+
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", title)
+	fmt.Fprintf(&b, "<p>Exported %s from %s</p>\n", t.exported.Format("2006-01-02 15:04"), html.EscapeString(t.addr))
+
+	if len(t.users) > 0 {
+		var users []string
+		for _, u := range t.users {
+			users = append(users, userSpan(u, u.Name))
+		}
+		fmt.Fprintf(&b, "<p>Users online: %s</p>\n", strings.Join(users, ", "))
+	}
+
+	b.WriteString("<div class=\"chat\">\n")
+	for _, line := range t.lines {
+		if line.when != "" {
+			fmt.Fprintf(&b, "<span class=\"time\">%s</span> ", line.when)
+		}
+		b.WriteString(t.lineHTML(strings.ReplaceAll(line.text, "\r", "")))
+		b.WriteString("\n")
+	}
+	b.WriteString("</div>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// joinLeavePattern matches the notices shown when users join or leave
+var joinLeavePattern = regexp.MustCompile(`^(→ .* joined|← .* left)$`)
+
+// userSpan wraps text in the class for a user's admin and away flags
+func userSpan(u hotline.User, text string) string {
+	text = html.EscapeString(text)
+	switch admin, away := userFlags(u); {
+	case admin && away:
+		return `<span class="away-admin">` + text + "</span>"
+	case admin:
+		return `<span class="admin">` + text + "</span>"
+	case away:
+		return `<span class="away">` + text + "</span>"
+	}
+	return text
+}
+
+// lineHTML renders a chat line, styling join and leave notices and the names
+// of admins and away users as the user list does
+func (t transcript) lineHTML(text string) string {
+	if plain := strings.TrimSpace(ansi.Strip(text)); joinLeavePattern.MatchString(plain) {
+		return `<span class="join-leave">` + html.EscapeString(plain) + "</span>"
+	}
+
+	if speaker, body, ok := strings.Cut(text, ":  "); ok && !strings.Contains(speaker, "\x1b") {
+		for _, u := range t.users {
+			if u.Name == strings.TrimSpace(speaker) {
+				lead := speaker[:strings.Index(speaker, u.Name)]
+				return html.EscapeString(lead) + userSpan(u, u.Name) + ":  " + ansiToHTML(body)
+			}
+		}
+	}
+	return ansiToHTML(text)
+}
+
+// lipglossCSS converts a lipgloss color, an ANSI color number or hex code, to
+// CSS without going through the terminal's color profile
+func lipglossCSS(c lipgloss.Color) string {
+	if n, err := strconv.Atoi(string(c)); err == nil {
+		return cssColor(ansi.IndexedColor(n))
+	}
+	if cf, err := colorful.Hex(string(c)); err == nil {
+		return cf.Hex()
+	}
+	return "inherit"
+}
+
+// cssColor converts a terminal color to a CSS hex color
+func cssColor(c color.Color) string {
+	cf, ok := colorful.MakeColor(c)
+	if !ok {
+		return "inherit"
+	}
+	return cf.Hex()
+}
+
+// styleCSS converts the text attributes of a lipgloss style to CSS
+func styleCSS(s lipgloss.Style) string {
+	var css []string
+	if fg, ok := s.GetForeground().(lipgloss.Color); ok {
+		css = append(css, "color: "+lipglossCSS(fg)+";")
+	}
+	if s.GetBold() {
+		css = append(css, "font-weight: bold;")
+	}
+	if s.GetFaint() {
+		css = append(css, "opacity: 0.6;")
+	}
+	if s.GetStrikethrough() {
+		css = append(css, "text-decoration: line-through;")
+	}
+	return strings.Join(css, " ")
+}
+
+// sgrState is the styling set by SGR sequences so far
+type sgrState struct {
+	bold, faint, italic, underline, strike, reverse bool
+	fg, bg                                          string // CSS colors, empty for the default
+}
+
+// css returns the state as an inline style
+func (st sgrState) css() string {
+	fg, bg := st.fg, st.bg
+	if st.reverse {
+		fg, bg = bg, fg
+		if fg == "" {
+			fg = "#1c1c1c"
+		}
+		if bg == "" {
+			bg = "#d0d0d0"
+		}
+	}
+
+	var css []string
+	if fg != "" {
+		css = append(css, "color: "+fg)
+	}
+	if bg != "" {
+		css = append(css, "background: "+bg)
+	}
+	if st.bold {
+		css = append(css, "font-weight: bold")
+	}
+	if st.faint {
+		css = append(css, "opacity: 0.6")
+	}
+	if st.italic {
+		css = append(css, "font-style: italic")
+	}
+	switch {
+	case st.underline && st.strike:
+		css = append(css, "text-decoration: underline line-through")
+	case st.underline:
+		css = append(css, "text-decoration: underline")
+	case st.strike:
+		css = append(css, "text-decoration: line-through")
+	}
+	return strings.Join(css, "; ")
+}
+
+// apply updates the state with the parameters of an SGR sequence
+func (st *sgrState) apply(params string) {
+	if params == "" {
+		*st = sgrState{}
+		return
+	}
+	ps := strings.FieldsFunc(params, func(r rune) bool { return r == ';' || r == ':' })
+	for i := 0; i < len(ps); i++ {
+		p, _ := strconv.Atoi(ps[i])
+		switch {
+		case p == 0:
+			*st = sgrState{}
+		case p == 1:
+			st.bold = true
+		case p == 2:
+			st.faint = true
+		case p == 3:
+			st.italic = true
+		case p == 4:
+			st.underline = true
+		case p == 7:
+			st.reverse = true
+		case p == 9:
+			st.strike = true
+		case p == 22:
+			st.bold, st.faint = false, false
+		case p == 23:
+			st.italic = false
+		case p == 24:
+			st.underline = false
+		case p == 27:
+			st.reverse = false
+		case p == 29:
+			st.strike = false
+		case p >= 30 && p <= 37:
+			st.fg = cssColor(ansi.BasicColor(p - 30))
+		case p >= 90 && p <= 97:
+			st.fg = cssColor(ansi.BasicColor(p - 90 + 8))
+		case p == 39:
+			st.fg = ""
+		case p >= 40 && p <= 47:
+			st.bg = cssColor(ansi.BasicColor(p - 40))
+		case p >= 100 && p <= 107:
+			st.bg = cssColor(ansi.BasicColor(p - 100 + 8))
+		case p == 49:
+			st.bg = ""
+		case p == 38 || p == 48:
+			var c string
+			c, i = extendedColor(ps, i)
+			if p == 38 {
+				st.fg = c
+			} else {
+				st.bg = c
+			}
+		}
+	}
+}
+
+// extendedColor reads a 256 color or true color after the 38 or 48 at ps[i],
+// returning it and the index of its last parameter
+func extendedColor(ps []string, i int) (string, int) {
+	arg := func(n int) int {
+		if i+n >= len(ps) {
+			return 0
+		}
+		v, _ := strconv.Atoi(ps[i+n])
+		return v
+	}
+	switch arg(1) {
+	case 5:
+		return cssColor(ansi.IndexedColor(arg(2))), i + 2
+	case 2:
+		return fmt.Sprintf("#%02x%02x%02x", arg(2), arg(3), arg(4)), i + 4
+	}
+	return "", i + 1
+}
+
+// ansiToHTML converts text with SGR styling and OSC 8 hyperlinks to escaped
+// HTML with inline styles. Other escape sequences are dropped.
+func ansiToHTML(text string) string {
+	var b strings.Builder
+	var st sgrState
+	var span, link bool
+
+	write := func(s string) {
+		if s == "" {
+			return
+		}
+		if css := st.css(); css != "" && !span {
+			fmt.Fprintf(&b, `<span style="%s">`, css)
+			span = true
+		}
+		b.WriteString(html.EscapeString(s))
+	}
+	closeSpan := func() {
+		if span {
+			b.WriteString("</span>")
+			span = false
+		}
+	}
+
+	last := 0
+	for _, loc := range ansiPattern.FindAllStringSubmatchIndex(text, -1) {
+		write(text[last:loc[0]])
+		last = loc[1]
+
+		seq := text[loc[0]:loc[1]]
+		switch {
+		case strings.HasSuffix(seq, "m") && strings.HasPrefix(seq, "\x1b["):
+			closeSpan()
+			st.apply(text[loc[2]:loc[3]])
+		case strings.HasPrefix(seq, "\x1b]8;"):
+			closeSpan()
+			if link {
+				b.WriteString("</a>")
+				link = false
+			}
+			if url := text[loc[4]:loc[5]]; url != "" {
+				fmt.Fprintf(&b, `<a href="%s">`, html.EscapeString(url))
+				link = true
+			}
+		}
+	}
+	write(text[last:])
+	closeSpan()
+	if link {
+		b.WriteString("</a>")
+	}
+	return b.String()
+}
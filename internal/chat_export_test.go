@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhalter/mobius/hotline"
+)
+
+func TestParseExportArgs(t *testing.T) {
+	now := time.Date(2026, 1, 31, 10, 0, 0, 0, time.Local)
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2026, 1, day, hour, min, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		args     string
+		format   string
+		from, to time.Time
+		path     string
+	}{
+		{args: "", format: exportMarkdown},
+		{args: "-html", format: exportHTML},
+		{args: "chat.HTM", format: exportHTML, path: "chat.HTM"},
+		{args: "-md chat.html", format: exportMarkdown, path: "chat.html"},
+		{args: "9:30", format: exportMarkdown, from: at(31, 9, 30), to: now},
+		{args: "09:00-9:45 -html", format: exportHTML, from: at(31, 9, 0), to: at(31, 9, 46).Add(-time.Nanosecond)},
+		// Times still to come today are yesterday's
+		{args: "23:00", format: exportMarkdown, from: at(30, 23, 0), to: now},
+		// A range that passes midnight ends the next day
+		{args: "23:00-01:00 log.md", format: exportMarkdown, from: at(30, 23, 0), to: at(31, 1, 1).Add(-time.Nanosecond), path: "log.md"},
+	}
+	for _, tt := range tests {
+		opts, err := parseExportArgs(tt.args, now)
+		if err != nil {
+			t.Errorf("parseExportArgs(%q) error: %v", tt.args, err)
+			continue
+		}
+		if opts.format != tt.format || !opts.from.Equal(tt.from) || !opts.to.Equal(tt.to) || opts.path != tt.path {
+			t.Errorf("parseExportArgs(%q) = %+v, want format %s from %v to %v path %q", tt.args, opts, tt.format, tt.from, tt.to, tt.path)
+		}
+	}
+}
+
+func TestExportPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	downloads := filepath.Join(home, "Downloads")
+	abs := filepath.Join(t.TempDir(), "chat.md")
+
+	tests := []struct {
+		path, want string
+	}{
+		{path: "chat.md", want: filepath.Join(downloads, "chat.md")},
+		{path: "logs/chat.md", want: filepath.Join(downloads, "logs", "chat.md")},
+		{path: "~/chat.txt", want: filepath.Join(home, "chat.txt")},
+		{path: "~other/chat.txt", want: filepath.Join(downloads, "~other", "chat.txt")},
+		{path: abs, want: abs},
+	}
+	for _, tt := range tests {
+		got, err := exportPath(tt.path, downloads)
+		if err != nil {
+			t.Errorf("exportPath(%q) error: %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("exportPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestUniquePath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "chat.md")
+	if got := uniquePath(path); got != path {
+		t.Errorf("uniquePath of a new file = %q, want %q", got, path)
+	}
+
+	for _, name := range []string{"chat.md", "chat (1).md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := uniquePath(path), filepath.Join(dir, "chat (2).md"); got != want {
+		t.Errorf("uniquePath of an existing file = %q, want %q", got, want)
+	}
+}
+
+func TestParseExportArgsErrors(t *testing.T) {
+	now := time.Date(2026, 1, 31, 10, 0, 0, 0, time.Local)
+	for _, args := range []string{"-pdf", "a.md b.md", "25:00"} {
+		if _, err := parseExportArgs(args, now); err == nil {
+			t.Errorf("parseExportArgs(%q) accepted bad arguments", args)
+		}
+	}
+	if _, err := parseExportArgs("-x", now); !errors.Is(err, errChatCommandUsage) {
+		t.Errorf("unknown flag error = %v, want usage", err)
+	}
+}
+
+func TestAnsiToHTML(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{text: "a < b & c", want: "a &lt; b &amp; c"},
+		{text: "\x1b[1mbold\x1b[0m plain", want: `<span style="font-weight: bold">bold</span> plain`},
+		{text: "\x1b[31;4mred\x1b[24m still red\x1b[m", want: `<span style="color: #800000; text-decoration: underline">red</span><span style="color: #800000"> still red</span>`},
+		{text: "\x1b[38;5;196mx\x1b[39m", want: `<span style="color: #ff0000">x</span>`},
+		{text: "\x1b[48;2;1;2;3;97mx", want: `<span style="color: #ffffff; background: #010203">x</span>`},
+		{text: "\x1b[2;3;9mx", want: `<span style="opacity: 0.6; font-style: italic; text-decoration: line-through">x</span>`},
+		{text: "\x1b[7mx", want: `<span style="color: #1c1c1c; background: #d0d0d0">x</span>`},
+		{
+			text: "see \x1b]8;;https://example.com/?a=1&b=2\x07link\x1b]8;;\x07.",
+			want: `see <a href="https://example.com/?a=1&amp;b=2">link</a>.`,
+		},
+		// Unclosed links are closed, and other escapes dropped
+		{text: "\x1b]8;id=1;https://example.com\x1b\\x\x1b[2Ky\x1b]0;title\x07", want: `<a href="https://example.com">xy</a>`},
+	}
+	for _, tt := range tests {
+		if got := ansiToHTML(tt.text); got != tt.want {
+			t.Errorf("ansiToHTML(%q)\n got %s\nwant %s", tt.text, got, tt.want)
+		}
+	}
+}
+
+func testTranscript() transcript {
+	admin := []byte{0, 1 << hotline.UserFlagAdmin}
+	away := []byte{0, 1 << hotline.UserFlagAway}
+	return transcript{
+		server:   "Test *Server*",
+		addr:     "hotline.example.com:5500",
+		exported: time.Date(2026, 1, 31, 10, 0, 0, 0, time.Local),
+		users: []hotline.User{
+			{Name: "alice", Flags: admin},
+			{Name: "bob", Flags: away},
+			{Name: "carol", Flags: []byte{0, 0}},
+		},
+		lines: []transcriptLine{
+			{when: "09:00:00", text: "\r alice:  hi <there> *all*"},
+			{when: "09:00:01", text: "\x1b[1;38;5;241m→ dave joined\x1b[0m"},
+			{when: "09:00:02", text: "*** bob waves"},
+		},
+	}
+}
+
+func TestTranscriptMarkdown(t *testing.T) {
+	got := testTranscript().markdown()
+	for _, want := range []string{
+		"# Chat on Test \\*Server\\*\n",
+		"Users online: **alice**, bob (away), carol\n",
+		"- `09:00:00` **alice:** hi \\<there\\> \\*all\\*\n",
+		"- `09:00:01` *→ dave joined*\n",
+		"- `09:00:02` *\\*\\*\\* bob waves*\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.ContainsAny(got, "\r\x1b") {
+		t.Errorf("markdown has control characters:\n%q", got)
+	}
+}
+
+func TestTranscriptHTML(t *testing.T) {
+	got := testTranscript().html()
+	for _, want := range []string{
+		"<title>Chat on Test *Server*</title>",
+		".admin { color: #ff0000; font-weight: bold; }",
+		".join-leave { color: #626262; font-weight: bold; }",
+		`Users online: <span class="admin">alice</span>, <span class="away">bob</span>, carol`,
+		`<span class="time">09:00:00</span>  <span class="admin">alice</span>:  hi &lt;there&gt; *all*`,
+		`<span class="time">09:00:01</span> <span class="join-leave">→ dave joined</span>`,
+		`<span class="time">09:00:02</span> *** bob waves`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTML is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.ContainsAny(got, "\r\x1b") {
+		t.Errorf("HTML has control characters:\n%q", got)
+	}
+}
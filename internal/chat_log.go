@@ -0,0 +1,381 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Chat log files are named by day, one directory per server
+const (
+	chatLogDateFormat = time.DateOnly
+	chatLogTimeFormat = time.TimeOnly
+	chatLogExt        = ".log"
+)
+
+// maxChatLogResults limits how many matches a search returns
+const maxChatLogResults = 500
+
+// defaultChatLogDir returns the chat log directory used when ChatLogDir isn't set
+func defaultChatLogDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "mobius-hotline-client", "logs")
+}
+
+// chatLogger appends chat lines to per-server, per-day plain text files
+type chatLogger struct {
+	mu    sync.Mutex
+	dir   string
+	files map[string]*os.File // Server directory -> today's open log file
+	paths map[string]string   // Server directory -> path of the open file
+}
+
+func newChatLogger(dir string) *chatLogger {
+	return &chatLogger{
+		dir:   dir,
+		files: make(map[string]*os.File),
+		paths: make(map[string]string),
+	}
+}
+
+// setDir moves future logging to dir, closing the files open in the old one
+func (l *chatLogger) setDir(dir string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if dir == l.dir {
+		return
+	}
+	for server, f := range l.files {
+		_ = f.Close()
+		delete(l.files, server)
+		delete(l.paths, server)
+	}
+	l.dir = dir
+}
+
+// Dir returns the directory logs are written to
+func (l *chatLogger) Dir() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dir
+}
+
+// write appends a line for server, stripped of terminal styling and prefixed
+// with the time. Multi-line text gets one log line per line, whether it uses
+// Hotline's \r or \n line endings.
+func (l *chatLogger) write(server string, when time.Time, text string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	dir := chatLogServerDir(server)
+	path := filepath.Join(l.dir, dir, when.Format(chatLogDateFormat)+chatLogExt)
+
+	f := l.files[dir]
+	if l.paths[dir] != path {
+		// First line of the day, or of the session
+		if f != nil {
+			_ = f.Close()
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+		var err error
+		if f, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600); err != nil {
+			delete(l.files, dir)
+			delete(l.paths, dir)
+			return err
+		}
+		l.files[dir] = f
+		l.paths[dir] = path
+	}
+
+	var b strings.Builder
+	stamp := when.Format(chatLogTimeFormat)
+	for _, line := range chatLogLines(text) {
+		fmt.Fprintf(&b, "[%s] %s\n", stamp, line)
+	}
+	_, err := f.WriteString(b.String())
+	return err
+}
+
+// chatLogLines splits a message into the lines written to the log, without
+// styling or blank lines
+func chatLogLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(ansi.Strip(text), "\r", "\n"), "\n") {
+		if line = strings.TrimRight(line, " \r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// chatLogServerDir turns a server address into a directory name
+func chatLogServerDir(addr string) string {
+	r := strings.NewReplacer("[", "", "]", "", ":", "_", "/", "_", "\\", "_", "%", "_")
+	return r.Replace(addr)
+}
+
+// logChat records a line in the active session's chat log
+func (m *Model) logChat(text string) {
+	params := m.activeConnection
+	if params == nil {
+		params = m.pendingConnection
+	}
+	if params == nil || m.chatLog == nil {
+		return
+	}
+	if err := m.chatLog.write(params.addr, time.Now(), text); err != nil {
+		m.logger.Error("Unable to write chat log", "err", err)
+	}
+}
+
+// userName returns the name of a user on the server, or "user N" if they've left
+func (m *Model) userName(id [2]byte) string {
+	for _, u := range m.userList {
+		if u.ID == id {
+			return u.Name
+		}
+	}
+	return fmt.Sprintf("user %d", uint16(id[0])<<8|uint16(id[1]))
+}
+
+// chatLogQuery filters a chat log search. Zero values match everything.
+type chatLogQuery struct {
+	keyword string
+	user    string
+	from    time.Time // First day to search
+	to      time.Time // Last day to search
+}
+
+// chatLogMatch is one line found by a search
+type chatLogMatch struct {
+	server string // Server directory name
+	date   string // YYYY-MM-DD of the log file
+	path   string
+	line   int // Zero-based line number in the file
+	text   string
+}
+
+// searchChatLogs scans the log directory for lines matching q, newest first
+func searchChatLogs(dir string, q chatLogQuery) ([]chatLogMatch, error) {
+	servers, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	type logFile struct {
+		server, date, path string
+	}
+	var files []logFile
+	for _, server := range servers {
+		if !server.IsDir() {
+			continue
+		}
+		days, err := os.ReadDir(filepath.Join(dir, server.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, day := range days {
+			date, ok := strings.CutSuffix(day.Name(), chatLogExt)
+			if !ok {
+				continue
+			}
+			d, err := time.Parse(chatLogDateFormat, date)
+			if err != nil || (!q.from.IsZero() && d.Before(q.from)) || (!q.to.IsZero() && d.After(q.to)) {
+				continue
+			}
+			files = append(files, logFile{server.Name(), date, filepath.Join(dir, server.Name(), day.Name())})
+		}
+	}
+
+	// Newest days first; lines within a day are reversed below
+	slices.SortFunc(files, func(a, b logFile) int {
+		if c := strings.Compare(b.date, a.date); c != 0 {
+			return c
+		}
+		return strings.Compare(a.server, b.server)
+	})
+
+	keyword := strings.ToLower(q.keyword)
+	var matches []chatLogMatch
+	for _, f := range files {
+		lines, err := readChatLog(f.path)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(lines) - 1; i >= 0; i-- {
+			text := lines[i]
+			if keyword != "" && !strings.Contains(strings.ToLower(text), keyword) {
+				continue
+			}
+			if q.user != "" && !strings.EqualFold(chatLogSpeaker(text), q.user) {
+				continue
+			}
+			matches = append(matches, chatLogMatch{server: f.server, date: f.date, path: f.path, line: i, text: text})
+			if len(matches) >= maxChatLogResults {
+				return matches, nil
+			}
+		}
+	}
+	return matches, nil
+}
+
+// readChatLog returns the lines of a log file
+func readChatLog(path string) ([]string, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	var lines []string
+	scanner := bufio.NewScanner(fh)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// chatLogSpeaker returns the user a log line is from, or "" for notices
+func chatLogSpeaker(line string) string {
+	// Drop the "[15:04:05] " timestamp
+	if strings.HasPrefix(line, "[") {
+		if i := strings.Index(line, "] "); i >= 0 {
+			line = line[i+2:]
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(line, "[private message from "):
+		name, _, _ := strings.Cut(strings.TrimPrefix(line, "[private message from "), "] ")
+		return name
+	case strings.HasPrefix(line, "[private message to "):
+		return ""
+	case strings.HasPrefix(line, "[private chat "):
+		if i := strings.Index(line, "] "); i >= 0 {
+			line = line[i+2:]
+		}
+	}
+
+	line = strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(line, "→ "):
+		name, _ := strings.CutSuffix(strings.TrimPrefix(line, "→ "), " joined")
+		return name
+	case strings.HasPrefix(line, "← "):
+		name, _ := strings.CutSuffix(strings.TrimPrefix(line, "← "), " left")
+		return name
+	case strings.HasPrefix(line, "*** "):
+		name, _, _ := strings.Cut(strings.TrimPrefix(line, "*** "), " ")
+		return name
+	}
+
+	// Chat lines are "name:  message"
+	if name, _, ok := strings.Cut(line, ":  "); ok {
+		return strings.TrimSpace(name)
+	}
+	return ""
+}
+
+// chatLogCursor is a place in a server's chat logs. Lines before it are older.
+type chatLogCursor struct {
+	date string // YYYY-MM-DD of the log file
+	line int    // Zero-based line number in the file
+}
+
+// chatLogLine is a line read back from a chat log
+type chatLogLine struct {
+	text string    // As logged, starting with the "[15:04:05]" stamp
+	when time.Time // From the file's date and the stamp
+}
+
+// chatLogCursorAt returns the place after the lines logged up to and including
+// t's second, less the last skip of them, which are already shown
+func chatLogCursorAt(dir, server string, t time.Time, skip int) (chatLogCursor, error) {
+	c := chatLogCursor{date: t.Format(chatLogDateFormat)}
+	lines, err := readChatLog(filepath.Join(dir, chatLogServerDir(server), c.date+chatLogExt))
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+
+	stamp := "[" + t.Format(chatLogTimeFormat) + "]"
+	for c.line < len(lines) && lines[c.line][:min(len(stamp), len(lines[c.line]))] <= stamp {
+		c.line++
+	}
+	for ; skip > 0 && c.line > 0 && strings.HasPrefix(lines[c.line-1], stamp); skip-- {
+		c.line--
+	}
+	return c, nil
+}
+
+// readChatLogBefore returns up to n lines logged for server before c, oldest
+// first, and the place of the first one
+func readChatLogBefore(dir, server string, c chatLogCursor, n int) ([]chatLogLine, chatLogCursor, error) {
+	serverDir := filepath.Join(dir, chatLogServerDir(server))
+	days, err := os.ReadDir(serverDir)
+	if os.IsNotExist(err) {
+		return nil, c, nil
+	}
+	if err != nil {
+		return nil, c, err
+	}
+
+	var dates []string
+	for _, day := range days {
+		if date, ok := strings.CutSuffix(day.Name(), chatLogExt); ok && date <= c.date {
+			dates = append(dates, date)
+		}
+	}
+	slices.Sort(dates)
+
+	var older []chatLogLine
+	for i := len(dates) - 1; i >= 0 && len(older) < n; i-- {
+		lines, err := readChatLog(filepath.Join(serverDir, dates[i]+chatLogExt))
+		if err != nil {
+			return nil, c, err
+		}
+		if dates[i] == c.date {
+			lines = lines[:min(c.line, len(lines))]
+		}
+		start := max(len(lines)-(n-len(older)), 0)
+		older = append(chatLogLinesOn(dates[i], lines[start:]), older...)
+		c = chatLogCursor{date: dates[i], line: start}
+	}
+	return older, c, nil
+}
+
+// chatLogLinesOn dates lines from the log file for date. A line without a
+// readable stamp gets the time of the one before it.
+func chatLogLinesOn(date string, lines []string) []chatLogLine {
+	when, _ := time.ParseInLocation(chatLogDateFormat, date, time.Local)
+	dated := make([]chatLogLine, len(lines))
+	for i, line := range lines {
+		if stamp, _, ok := strings.Cut(strings.TrimPrefix(line, "["), "] "); ok {
+			if t, err := time.ParseInLocation(chatLogDateFormat+" "+chatLogTimeFormat, date+" "+stamp, time.Local); err == nil {
+				when = t
+			}
+		}
+		dated[i] = chatLogLine{text: line, when: when}
+	}
+	return dated
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+const testServer = "hotline.example.com:5500"
+
+// writeTestLog logs texts for testServer at the given times
+func writeTestLog(t *testing.T, dir string, lines map[time.Time][]string) {
+	t.Helper()
+	l := newChatLogger(dir)
+	t.Cleanup(func() { l.setDir("") })
+
+	times := make([]time.Time, 0, len(lines))
+	for when := range lines {
+		times = append(times, when)
+	}
+	slices.SortFunc(times, time.Time.Compare)
+	for _, when := range times {
+		for _, text := range lines[when] {
+			if err := l.write(testServer, when, text); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func logTexts(lines []chatLogLine) []string {
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.text
+	}
+	return texts
+}
+
+func TestReadChatLogBefore(t *testing.T) {
+	dir := t.TempDir()
+	day1 := time.Date(2026, 1, 30, 23, 59, 0, 0, time.Local)
+	day2 := time.Date(2026, 1, 31, 9, 0, 0, 0, time.Local)
+	writeTestLog(t, dir, map[time.Time][]string{
+		day1:                  {"alice:  one", "bob:  two"},
+		day2:                  {"alice:  three\rand more"},
+		day2.Add(time.Minute): {"bob:  four"},
+	})
+
+	// Start before "four", and page back across the day boundary
+	c := chatLogCursor{date: "2026-01-31", line: 2}
+	lines, c, err := readChatLogBefore(dir, testServer, c, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := logTexts(lines), []string{"[09:00:00] alice:  three", "[09:00:00] and more"}; !slices.Equal(got, want) {
+		t.Errorf("first page = %q, want %q", got, want)
+	}
+	if !lines[0].when.Equal(day2) {
+		t.Errorf("first page dated %v, want %v", lines[0].when, day2)
+	}
+
+	lines, c, err = readChatLogBefore(dir, testServer, c, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := logTexts(lines), []string{"[23:59:00] alice:  one", "[23:59:00] bob:  two"}; !slices.Equal(got, want) {
+		t.Errorf("second page = %q, want %q", got, want)
+	}
+	if !lines[1].when.Equal(day1) {
+		t.Errorf("second page dated %v, want %v", lines[1].when, day1)
+	}
+
+	lines, _, err = readChatLogBefore(dir, testServer, c, 2)
+	if err != nil || len(lines) != 0 {
+		t.Errorf("past the first line got %q, %v", logTexts(lines), err)
+	}
+}
+
+func TestReadChatLogBeforeMissing(t *testing.T) {
+	lines, _, err := readChatLogBefore(t.TempDir(), testServer, chatLogCursor{date: "2026-01-31"}, 10)
+	if err != nil || len(lines) != 0 {
+		t.Errorf("without logs got %q, %v", logTexts(lines), err)
+	}
+}
+
+func TestChatLogCursorAt(t *testing.T) {
+	dir := t.TempDir()
+	at := time.Date(2026, 1, 31, 9, 0, 0, 0, time.Local)
+	writeTestLog(t, dir, map[time.Time][]string{
+		at:                  {"alice:  one"},
+		at.Add(time.Second): {"bob:  two", "carol:  three", "dave:  four"},
+		at.Add(time.Minute): {"erin:  five"},
+	})
+
+	tests := []struct {
+		when time.Time
+		skip int
+		want int
+	}{
+		// Every line from the boundary second is before the cursor...
+		{when: at.Add(time.Second + 500*time.Millisecond), want: 4},
+		// ...less the ones already shown
+		{when: at.Add(time.Second), skip: 1, want: 3},
+		{when: at.Add(time.Second), skip: 3, want: 1},
+		// Skipping never reaches back into earlier seconds
+		{when: at.Add(time.Second), skip: 10, want: 1},
+		{when: at.Add(-time.Hour), want: 0},
+		{when: at.Add(time.Hour), want: 5},
+	}
+	for _, tt := range tests {
+		c, err := chatLogCursorAt(dir, testServer, tt.when, tt.skip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.date != "2026-01-31" || c.line != tt.want {
+			t.Errorf("chatLogCursorAt(%s, skip %d) = %+v, want line %d", tt.when.Format(time.TimeOnly), tt.skip, c, tt.want)
+		}
+	}
+
+	// A day without a log starts at its beginning
+	c, err := chatLogCursorAt(dir, testServer, at.AddDate(0, 0, 1), 0)
+	if err != nil || c.date != "2026-02-01" || c.line != 0 {
+		t.Errorf("chatLogCursorAt on a day without a log = %+v, %v", c, err)
+	}
+}
+
+func TestChatLogLines(t *testing.T) {
+	got := chatLogLines("\x1b[1malice:  one\x1b[0m\r\rtwo  \nthree\r\n")
+	if want := []string{"alice:  one", "two", "three"}; !slices.Equal(got, want) {
+		t.Errorf("chatLogLines = %q, want %q", got, want)
+	}
+}
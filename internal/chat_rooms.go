@@ -0,0 +1,502 @@
+package internal
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jhalter/mobius-hotline-client/internal/style"
+	"github.com/jhalter/mobius/hotline"
+)
+
+// chatRoom is a private chat we have joined
+type chatRoom struct {
+	id       [4]byte
+	subject  string
+	members  []hotline.User
+	messages []string // Formatted lines, kept for re-wrapping
+	unread   int      // Lines received while the room wasn't on screen
+}
+
+// chatInvite is a pending invitation to a private chat
+type chatInvite struct {
+	chatID [4]byte
+	from   string
+	userID [2]byte
+}
+
+// Messages from private chat transaction handlers
+
+// chatInviteMsg reports an invitation to join a private chat
+type chatInviteMsg struct {
+	invite chatInvite
+}
+
+// chatCreatedMsg reports the private chat the server opened for our invitation
+type chatCreatedMsg struct {
+	chatID [4]byte
+	self   hotline.User
+}
+
+// chatJoinedMsg is the reply to joining a private chat. The reply doesn't name
+// the chat, so it is matched up by transaction ID.
+type chatJoinedMsg struct {
+	tranID  [4]byte
+	subject string
+	members []hotline.User
+}
+
+// chatMemberMsg reports a user joining a private chat or changing their details
+type chatMemberMsg struct {
+	chatID [4]byte
+	user   hotline.User
+}
+
+// chatMemberLeftMsg reports a user leaving a private chat
+type chatMemberLeftMsg struct {
+	chatID [4]byte
+	userID [2]byte
+}
+
+// chatSubjectMsg reports a private chat's new subject
+type chatSubjectMsg struct {
+	chatID  [4]byte
+	subject string
+}
+
+// chatRoom returns the joined private chat with the given ID, or nil
+func (s *Session) chatRoom(id [4]byte) *chatRoom {
+	for _, room := range s.chatRooms {
+		if room.id == id {
+			return room
+		}
+	}
+	return nil
+}
+
+// clearChatRooms forgets every private chat; the server drops us from them when
+// the connection closes
+func (s *Session) clearChatRooms() {
+	s.chatRooms = nil
+	s.chatInvites = nil
+	s.pendingChatJoins = nil
+}
+
+// chatID reads the chat ID field of t. Public chat has none, or all zeros.
+func chatID(t *hotline.Transaction) (id [4]byte) {
+	copy(id[:], t.GetField(hotline.FieldChatID).Data)
+	return id
+}
+
+// readUsers decodes the user records in t's FieldUsernameWithInfo fields
+func (s *Session) readUsers(t *hotline.Transaction) ([]hotline.User, error) {
+	var users []hotline.User
+	for _, field := range t.Fields {
+		if field.Type == hotline.FieldUsernameWithInfo {
+			var user hotline.User
+			if _, err := user.Write(field.Data); err != nil {
+				return nil, fmt.Errorf("unable to read user data: %w", err)
+			}
+			user.Name = s.decodeText([]byte(user.Name))
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// Transaction handlers
+
+func (m *Model) HandleInviteNewChat(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
+	if m.checkTransactionError(c, t) {
+		return nil, nil
+	}
+
+	self := hotline.User{
+		Name:  m.sessionFor(c).decodeText(t.GetField(hotline.FieldUserName).Data),
+		Icon:  t.GetField(hotline.FieldUserIconID).Data,
+		Flags: t.GetField(hotline.FieldUserFlags).Data,
+	}
+	copy(self.ID[:], t.GetField(hotline.FieldUserID).Data)
+	m.send(c, chatCreatedMsg{chatID: chatID(t), self: self})
+
+	return res, err
+}
+
+// HandleInviteToChat handles both invitations from other users and the reply to
+// our own invitation to an existing chat, which needs no action
+func (m *Model) HandleInviteToChat(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
+	if t.IsReply == 1 {
+		m.checkTransactionError(c, t)
+		return res, err
+	}
+
+	var invite chatInvite
+	invite.chatID = chatID(t)
+	invite.from = m.sessionFor(c).decodeText(t.GetField(hotline.FieldUserName).Data)
+	copy(invite.userID[:], t.GetField(hotline.FieldUserID).Data)
+
+	// Invitations from ignored users are left unanswered
+	if s := m.sessionFor(c); s != nil && s.ignores.ignores(invite.userID, invite.from) {
+		return res, err
+	}
+	m.send(c, chatInviteMsg{invite: invite})
+
+	return res, err
+}
+
+func (m *Model) HandleJoinChat(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
+	if m.checkTransactionError(c, t) {
+		return nil, nil
+	}
+
+	s := m.sessionFor(c)
+	members, err := s.readUsers(t)
+	if err != nil {
+		return res, err
+	}
+	m.send(c, chatJoinedMsg{
+		tranID:  t.ID,
+		subject: s.decodeText(t.GetField(hotline.FieldChatSubject).Data),
+		members: members,
+	})
+
+	return res, err
+}
+
+func (m *Model) HandleNotifyChatChangeUser(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
+	user := hotline.User{
+		Name:  m.sessionFor(c).decodeText(t.GetField(hotline.FieldUserName).Data),
+		Icon:  t.GetField(hotline.FieldUserIconID).Data,
+		Flags: t.GetField(hotline.FieldUserFlags).Data,
+	}
+	copy(user.ID[:], t.GetField(hotline.FieldUserID).Data)
+	m.send(c, chatMemberMsg{chatID: chatID(t), user: user})
+
+	return res, err
+}
+
+func (m *Model) HandleNotifyChatDeleteUser(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
+	var userID [2]byte
+	copy(userID[:], t.GetField(hotline.FieldUserID).Data)
+	m.send(c, chatMemberLeftMsg{chatID: chatID(t), userID: userID})
+
+	return res, err
+}
+
+func (m *Model) HandleNotifyChatSubject(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
+	m.send(c, chatSubjectMsg{
+		chatID:  chatID(t),
+		subject: m.sessionFor(c).decodeText(t.GetField(hotline.FieldChatSubject).Data),
+	})
+
+	return res, err
+}
+
+// Message handlers
+
+// handleServerInviteToChatMsg invites a user to a new private chat
+func (m *Model) handleServerInviteToChatMsg(msg ServerInviteToChatMsg) {
+	t := hotline.NewTransaction(hotline.TranInviteNewChat, [2]byte{},
+		hotline.NewField(hotline.FieldUserID, msg.TargetUserID[:]),
+	)
+	if err := m.sendTransaction(t); err != nil {
+		m.logger.Error("Error inviting user to chat", "err", err)
+	}
+}
+
+func (m *Model) handleChatCreatedMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	created := msg.(chatCreatedMsg)
+	room := &chatRoom{id: created.chatID, members: []hotline.User{created.self}}
+	m.chatRooms = append(m.chatRooms, room)
+	m.openChatRoom(room)
+	return m, nil
+}
+
+func (m *Model) handleChatInviteMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	invite := msg.(chatInviteMsg).invite
+	if m.chatRoom(invite.chatID) != nil {
+		return m, nil
+	}
+
+	showing := m.isShowingChatInviteModal()
+	m.chatInvites = append(m.chatInvites, invite)
+	m.soundPlayer.PlayAsync(SoundServerMsg)
+	m.updateChatInviteModal()
+	if !showing {
+		m.PushScreen(ScreenModal)
+	}
+	return m, m.modalScreen.Init()
+}
+
+// updateChatInviteModal shows the most recent pending chat invitation
+func (m *Model) updateChatInviteModal() {
+	if len(m.chatInvites) == 0 {
+		return
+	}
+
+	current := m.chatInvites[len(m.chatInvites)-1]
+	title := "Private Chat Invitation"
+	if len(m.chatInvites) > 1 {
+		title += fmt.Sprintf(" (1 of %d)", len(m.chatInvites))
+	}
+	content := fmt.Sprintf("%s invites you to a private chat.", current.from)
+
+	m.modalScreen = NewModalScreen(ModalTypeChatInvite, title, content, []string{"Decline", "Join"}, m)
+}
+
+// isShowingChatInviteModal returns true if the current screen is a chat invitation modal
+func (m *Model) isShowingChatInviteModal() bool {
+	return m.CurrentScreen() == ScreenModal &&
+		m.modalScreen != nil &&
+		m.modalScreen.modalType == ModalTypeChatInvite
+}
+
+// answerChatInvite joins or declines the invitation on screen, then shows the next
+// one or returns to the previous screen
+func (m *Model) answerChatInvite(join bool) tea.Cmd {
+	if len(m.chatInvites) == 0 {
+		m.PopScreen()
+		return nil
+	}
+	invite := m.chatInvites[len(m.chatInvites)-1]
+	m.chatInvites = m.chatInvites[:len(m.chatInvites)-1]
+
+	if join {
+		t := hotline.NewTransaction(hotline.TranJoinChat, [2]byte{},
+			hotline.NewField(hotline.FieldChatID, invite.chatID[:]),
+		)
+		if m.pendingChatJoins == nil {
+			m.pendingChatJoins = make(map[[4]byte][4]byte)
+		}
+		m.pendingChatJoins[t.ID] = invite.chatID
+		if err := m.sendTransaction(t); err != nil {
+			delete(m.pendingChatJoins, t.ID)
+			m.logger.Error("Error joining chat", "err", err)
+		}
+	} else {
+		t := hotline.NewTransaction(hotline.TranRejectChatInvite, [2]byte{},
+			hotline.NewField(hotline.FieldChatID, invite.chatID[:]),
+		)
+		if err := m.sendTransaction(t); err != nil {
+			m.logger.Error("Error declining chat invitation", "err", err)
+		}
+	}
+
+	if len(m.chatInvites) > 0 {
+		m.updateChatInviteModal()
+		return m.modalScreen.Init()
+	}
+	m.PopScreen()
+	return nil
+}
+
+func (m *Model) handleChatJoinedMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	joined := msg.(chatJoinedMsg)
+	id, ok := m.pendingChatJoins[joined.tranID]
+	if !ok {
+		return m, nil
+	}
+	delete(m.pendingChatJoins, joined.tranID)
+
+	room := &chatRoom{id: id, subject: joined.subject, members: joined.members}
+	m.chatRooms = append(m.chatRooms, room)
+
+	// Don't take the user away from a modal they are reading
+	if m.CurrentScreen() == ScreenModal {
+		room.unread = 1
+		return m, nil
+	}
+	m.openChatRoom(room)
+	return m, nil
+}
+
+func (m *Model) handleChatMemberMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	member := msg.(chatMemberMsg)
+	room := m.chatRoom(member.chatID)
+	if room == nil {
+		return m, nil
+	}
+
+	for i, u := range room.members {
+		if u.ID == member.user.ID {
+			if u.Name != member.user.Name {
+				m.addChatRoomLine(room, style.NoticeStyle.Render(fmt.Sprintf(" <<< %s is now known as %s >>>", u.Name, member.user.Name)))
+			}
+			room.members[i] = member.user
+			m.refreshChatRoom(room)
+			return m, nil
+		}
+	}
+
+	room.members = append(room.members, member.user)
+	if !m.ignores.ignores(member.user.ID, member.user.Name) {
+		m.addChatRoomLine(room, style.NoticeStyle.Render(fmt.Sprintf("→ %s joined", member.user.Name)))
+	}
+	return m, nil
+}
+
+func (m *Model) handleChatMemberLeftMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	left := msg.(chatMemberLeftMsg)
+	room := m.chatRoom(left.chatID)
+	if room == nil {
+		return m, nil
+	}
+
+	for i, u := range room.members {
+		if u.ID == left.userID {
+			room.members = append(room.members[:i], room.members[i+1:]...)
+			if !m.ignores.ignores(u.ID, u.Name) {
+				m.addChatRoomLine(room, style.NoticeStyle.Render(fmt.Sprintf("← %s left", u.Name)))
+			}
+			break
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) handleChatSubjectMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	subject := msg.(chatSubjectMsg)
+	room := m.chatRoom(subject.chatID)
+	if room == nil {
+		return m, nil
+	}
+
+	room.subject = subject.subject
+	m.addChatRoomLine(room, style.NoticeStyle.Render(fmt.Sprintf("Subject changed to: %s", subject.subject)))
+	return m, nil
+}
+
+// addChatRoomLine appends a line to a private chat, counting it as unread unless
+// the room is on screen
+func (m *Model) addChatRoomLine(room *chatRoom, line string) {
+	room.messages = append(room.messages, line)
+	m.logChat(fmt.Sprintf("[private chat %d] %s", binary.BigEndian.Uint32(room.id[:]), line))
+	if !m.isShowingChatRoom(room) {
+		room.unread++
+	}
+	m.refreshChatRoom(room)
+}
+
+// isShowingChatRoom reports whether room is the private chat on screen
+func (m *Model) isShowingChatRoom(room *chatRoom) bool {
+	return m.CurrentScreen() == ScreenPrivateChat && m.privateChatScreen != nil && m.privateChatScreen.room == room
+}
+
+// refreshChatRoom redraws the private chat screen if it is showing room
+func (m *Model) refreshChatRoom(room *chatRoom) {
+	if m.privateChatScreen != nil && m.privateChatScreen.room == room {
+		m.privateChatScreen.Refresh()
+	}
+}
+
+// openChatRoom shows a private chat, on top of the server screen
+func (m *Model) openChatRoom(room *chatRoom) {
+	room.unread = 0
+	m.privateChatScreen = NewPrivateChatScreen(room, m)
+	if m.CurrentScreen() == ScreenPrivateChat {
+		m.ReplaceScreen(ScreenPrivateChat)
+		return
+	}
+	m.PushScreen(ScreenPrivateChat)
+}
+
+// nextChatRoom returns the private chat after current, preferring rooms with
+// unread lines. A nil current starts from the beginning.
+func (m *Model) nextChatRoom(current *chatRoom) *chatRoom {
+	if len(m.chatRooms) == 0 {
+		return nil
+	}
+	for _, room := range m.chatRooms {
+		if room != current && room.unread > 0 {
+			return room
+		}
+	}
+	for i, room := range m.chatRooms {
+		if room == current {
+			return m.chatRooms[(i+1)%len(m.chatRooms)]
+		}
+	}
+	return m.chatRooms[0]
+}
+
+// handleServerOpenChatMsg opens the private chat with the most unread lines, or the first one
+func (m *Model) handleServerOpenChatMsg() {
+	if room := m.nextChatRoom(nil); room != nil {
+		m.openChatRoom(room)
+	}
+}
+
+// PrivateChatScreen message handlers
+
+func (m *Model) handlePrivateChatSendMsg(msg PrivateChatSendMsg) {
+	t := hotline.NewTransaction(hotline.TranChatSend, [2]byte{},
+		hotline.NewField(hotline.FieldData, m.encodeText(msg.Text)),
+		hotline.NewField(hotline.FieldChatID, msg.ChatID[:]),
+	)
+	if err := m.sendTransaction(t); err != nil {
+		m.logger.Error("Error sending private chat message", "err", err)
+	}
+}
+
+func (m *Model) handlePrivateChatSetSubjectMsg(msg PrivateChatSetSubjectMsg) {
+	t := hotline.NewTransaction(hotline.TranSetChatSubject, [2]byte{},
+		hotline.NewField(hotline.FieldChatID, msg.ChatID[:]),
+		hotline.NewField(hotline.FieldChatSubject, m.encodeText(msg.Subject)),
+	)
+	if err := m.sendTransaction(t); err != nil {
+		m.logger.Error("Error setting chat subject", "err", err)
+	}
+}
+
+func (m *Model) handlePrivateChatInviteMsg(msg PrivateChatInviteMsg) {
+	t := hotline.NewTransaction(hotline.TranInviteToChat, [2]byte{},
+		hotline.NewField(hotline.FieldUserID, msg.TargetUserID[:]),
+		hotline.NewField(hotline.FieldChatID, msg.ChatID[:]),
+	)
+	if err := m.sendTransaction(t); err != nil {
+		m.logger.Error("Error inviting user to chat", "err", err)
+		return
+	}
+
+	if room := m.chatRoom(msg.ChatID); room != nil {
+		for _, u := range m.userList {
+			if u.ID == msg.TargetUserID {
+				m.addChatRoomLine(room, style.NoticeStyle.Render(fmt.Sprintf("Invited %s", u.Name)))
+				break
+			}
+		}
+	}
+}
+
+func (m *Model) handlePrivateChatLeaveMsg(msg PrivateChatLeaveMsg) {
+	t := hotline.NewTransaction(hotline.TranLeaveChat, [2]byte{},
+		hotline.NewField(hotline.FieldChatID, msg.ChatID[:]),
+	)
+	if err := m.sendTransaction(t); err != nil {
+		m.logger.Error("Error leaving chat", "err", err)
+	}
+
+	for i, room := range m.chatRooms {
+		if room.id == msg.ChatID {
+			m.chatRooms = append(m.chatRooms[:i], m.chatRooms[i+1:]...)
+			break
+		}
+	}
+	m.PopScreen()
+}
+
+// isMember reports whether the user is in the room
+func (room *chatRoom) isMember(id [2]byte) bool {
+	for _, u := range room.members {
+		if u.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrivateChat reports whether a chat ID names a private chat rather than public chat
+func isPrivateChat(id [4]byte) bool {
+	return id != [4]byte{}
+}
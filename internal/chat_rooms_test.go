@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jhalter/mobius/hotline"
+)
+
+// roomLines returns a private chat's lines without styling
+func roomLines(room *chatRoom) []string {
+	var lines []string
+	for _, line := range room.messages {
+		lines = append(lines, ansi.Strip(line))
+	}
+	return lines
+}
+
+func TestChatRoomMembers(t *testing.T) {
+	m, _ := newTestModel(t, &Settings{Username: "tester"})
+	m.width, m.height = 120, 40
+	id := [4]byte{0, 0, 0, 7}
+	me := hotline.User{ID: [2]byte{0, 1}, Name: "tester"}
+	m.handleChatCreatedMsg(chatCreatedMsg{chatID: id, self: me})
+
+	room := m.chatRoom(id)
+	if room == nil || m.CurrentScreen() != ScreenPrivateChat || !m.isShowingChatRoom(room) {
+		t.Fatal("created chat wasn't opened")
+	}
+
+	bob := hotline.User{ID: [2]byte{0, 2}, Name: "bob"}
+	spammer := hotline.User{ID: [2]byte{0, 3}, Name: "spambot"}
+	m.ignores.setPatterns([]string{"spam*"})
+
+	m.handleChatMemberMsg(chatMemberMsg{chatID: id, user: bob})
+	m.handleChatMemberMsg(chatMemberMsg{chatID: id, user: spammer})
+	m.handleChatMemberMsg(chatMemberMsg{chatID: id, user: hotline.User{ID: bob.ID, Name: "robert"}})
+	m.handleChatSubjectMsg(chatSubjectMsg{chatID: id, subject: "plans"})
+	m.handleChatMemberLeftMsg(chatMemberLeftMsg{chatID: id, userID: spammer.ID})
+	m.handleChatMemberLeftMsg(chatMemberLeftMsg{chatID: id, userID: bob.ID})
+
+	// Notices for other chats are ignored
+	m.handleChatMemberMsg(chatMemberMsg{chatID: [4]byte{0, 0, 0, 8}, user: bob})
+
+	want := []string{
+		"→ bob joined",
+		" <<< bob is now known as robert >>>",
+		"Subject changed to: plans",
+		"← robert left",
+	}
+	if got := roomLines(room); !slices.Equal(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+	if len(room.members) != 1 || room.members[0].ID != me.ID || room.subject != "plans" {
+		t.Errorf("members = %v, subject %q; want only us and the new subject", room.members, room.subject)
+	}
+	if room.unread != 0 {
+		t.Errorf("unread = %d while the room was on screen", room.unread)
+	}
+
+	// Lines arriving while the room isn't on screen are unread
+	m.PopScreen()
+	m.handleChatMemberMsg(chatMemberMsg{chatID: id, user: bob})
+	if room.unread != 1 {
+		t.Errorf("unread = %d, want 1", room.unread)
+	}
+}
+
+func TestAnswerChatInvite(t *testing.T) {
+	m, s := newTestModel(t, &Settings{Username: "tester"})
+	m.width, m.height = 120, 40
+	conn := recordSent(s)
+	first, second := [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2}
+	m.chatInvites = []chatInvite{{chatID: first, from: "ann"}, {chatID: second, from: "bob"}}
+	m.PushScreen(ScreenModal)
+
+	// The newest invitation is answered first
+	m.answerChatInvite(false)
+	m.answerChatInvite(true)
+	if len(m.chatInvites) != 0 || m.CurrentScreen() == ScreenModal {
+		t.Errorf("%d invitations left on screen %v, want none and the modal closed", len(m.chatInvites), m.CurrentScreen())
+	}
+
+	sent := conn.sent(t)
+	if len(sent) != 2 {
+		t.Fatalf("sent %d transactions, want 2", len(sent))
+	}
+	if sent[0].Type != hotline.TranRejectChatInvite || chatID(&sent[0]) != second {
+		t.Errorf("first answer = %v for chat %v, want a rejection of chat 2", sent[0].Type, chatID(&sent[0]))
+	}
+	join := sent[1]
+	if join.Type != hotline.TranJoinChat || chatID(&join) != first {
+		t.Errorf("second answer = %v for chat %v, want to join chat 1", join.Type, chatID(&join))
+	}
+
+	// The join reply doesn't name the chat; it's matched by transaction ID
+	members := []hotline.User{{ID: [2]byte{0, 5}, Name: "ann"}}
+	m.handleChatJoinedMsg(chatJoinedMsg{tranID: [4]byte{9, 9, 9, 9}, subject: "stray"})
+	m.handleChatJoinedMsg(chatJoinedMsg{tranID: join.ID, subject: "hello", members: members})
+	if len(m.chatRooms) != 1 {
+		t.Fatalf("joined %d chats, want 1", len(m.chatRooms))
+	}
+	room := m.chatRooms[0]
+	if room.id != first || room.subject != "hello" || len(room.members) != 1 || !room.isMember(members[0].ID) {
+		t.Errorf("room = %+v, want chat 1 with ann", room)
+	}
+	if !m.isShowingChatRoom(room) || len(m.pendingChatJoins) != 0 {
+		t.Error("joined chat wasn't opened and forgotten as pending")
+	}
+}
+
+func TestNextChatRoom(t *testing.T) {
+	m, _ := newTestModel(t, &Settings{})
+	if m.nextChatRoom(nil) != nil {
+		t.Fatal("found a room with none joined")
+	}
+
+	a, b, c := &chatRoom{id: [4]byte{1}}, &chatRoom{id: [4]byte{2}}, &chatRoom{id: [4]byte{3}}
+	m.chatRooms = []*chatRoom{a, b, c}
+
+	tests := []struct {
+		current, want *chatRoom
+		unread        *chatRoom
+	}{
+		{current: nil, want: a},
+		{current: a, want: b},
+		{current: c, want: a},
+		{current: a, unread: c, want: c},
+		{current: c, unread: c, want: a}, // The current room's own unread lines don't count
+	}
+	for _, tt := range tests {
+		for _, room := range m.chatRooms {
+			room.unread = 0
+		}
+		if tt.unread != nil {
+			tt.unread.unread = 3
+		}
+		if got := m.nextChatRoom(tt.current); got != tt.want {
+			t.Errorf("nextChatRoom(%v) with %v unread = %v, want %v", tt.current, tt.unread, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultConnectTimeout bounds a connection attempt when Settings.ConnectTimeout is unset
+const defaultConnectTimeout = 15 * time.Second
+
+// connectPhase names a step of establishing a server connection
+type connectPhase string
+
+const (
+	phaseDNS       connectPhase = "DNS lookup"
+	phaseTCP       connectPhase = "TCP connect"
+	phaseProxy     connectPhase = "Proxy negotiation"
+	phaseTLS       connectPhase = "TLS handshake"
+	phaseHandshake connectPhase = "Hotline handshake"
+	phaseLogin     connectPhase = "Login"
+)
+
+// status returns the loading screen text shown while the phase is in progress
+func (p connectPhase) status() string {
+	switch p {
+	case phaseDNS:
+		return "Resolving server address..."
+	case phaseTCP:
+		return "Connecting to server..."
+	case phaseProxy:
+		return "Connecting through proxy..."
+	case phaseTLS:
+		return "Negotiating TLS..."
+	case phaseHandshake:
+		return "Performing handshake..."
+	case phaseLogin:
+		return "Logging in..."
+	}
+	return "Connecting to server..."
+}
+
+// connectError records which phase of a connection attempt failed
+type connectError struct {
+	phase connectPhase
+	err   error
+}
+
+func (e *connectError) Error() string {
+	switch {
+	case errors.Is(e.err, context.DeadlineExceeded):
+		return fmt.Sprintf("%s timed out", e.phase)
+	case errors.Is(e.err, context.Canceled):
+		return fmt.Sprintf("%s cancelled", e.phase)
+	}
+	return fmt.Sprintf("%s failed: %v", e.phase, e.err)
+}
+
+func (e *connectError) Unwrap() error {
+	return e.err
+}
+
+// phaseError wraps err with the phase it happened in. When ctx is done the
+// underlying error is usually a closed connection, so ctx's error is used instead.
+func phaseError(ctx context.Context, phase connectPhase, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+	return &connectError{phase: phase, err: err}
+}
+
+// connectPhaseMsg reports progress of a connection attempt
+type connectPhaseMsg struct {
+	phase connectPhase
+}
+
+func (m *Model) handleConnectPhaseMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	phaseMsg := msg.(connectPhaseMsg)
+
+	// Reconnects keep their countdown text
+	if m.reconnect == nil && m.loadingScreen != nil && m.CurrentScreen() == ScreenLoading {
+		m.loadingScreen.SetMessage(phaseMsg.phase.status())
+	}
+	return m, nil
+}
+
+// connectTimeout returns the configured connection attempt timeout
+func (cp *Settings) connectTimeout() time.Duration {
+	if cp.ConnectTimeout <= 0 {
+		return defaultConnectTimeout
+	}
+	return time.Duration(cp.ConnectTimeout) * time.Second
+}
+
+// beginConnection replaces the session's connection context. It is called on the
+// UI goroutine before the attempt starts so that cancelling the loading screen
+// can abort the dial and handshake.
+func (s *Session) beginConnection() context.Context {
+	if s.connectionCtxCancel != nil {
+		s.connectionCtxCancel()
+	}
+	s.connectionCtx, s.connectionCtxCancel = context.WithCancel(context.Background())
+	return s.connectionCtx
+}
@@ -0,0 +1,216 @@
+package internal
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jhalter/mobius/hotline"
+)
+
+// newTestModel returns a model with one active session whose UI messages are
+// discarded
+func newTestModel(t *testing.T, prefs *Settings) (*Model, *Session) {
+	t.Helper()
+	stopped, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	m := &Model{
+		prefs:   prefs,
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		program: tea.NewProgram(nil, tea.WithContext(stopped)),
+	}
+	s := m.newSession()
+	m.sessions = []*Session{s}
+	m.Session = s
+	return m, s
+}
+
+// fakeLoginServer accepts one connection, completes the Hotline handshake and
+// hands the login transaction's ID to answer. It returns the server's address
+// and a channel that is closed once the client has hung up.
+func fakeLoginServer(t *testing.T, answer func(conn net.Conn, loginID [4]byte)) (string, <-chan struct{}) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	hungUp := make(chan struct{})
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		if err := expect(conn, hotline.ClientHandshake); err != nil {
+			return
+		}
+		if _, err := conn.Write(hotline.ServerHandshake); err != nil {
+			return
+		}
+
+		header := make([]byte, 20)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		if _, err := io.CopyN(io.Discard, conn, int64(binary.BigEndian.Uint32(header[16:20]))); err != nil {
+			return
+		}
+		answer(conn, [4]byte(header[4:8]))
+
+		// Wait for the client to hang up
+		_, _ = io.Copy(io.Discard, conn)
+		close(hungUp)
+	}()
+	return ln.Addr().String(), hungUp
+}
+
+// waitHungUp fails the test unless the client closes the connection soon
+func waitHungUp(t *testing.T, hungUp <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-hungUp:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client left the connection open")
+	}
+}
+
+func TestJoinServerLoginTimeout(t *testing.T) {
+	addr, hungUp := fakeLoginServer(t, func(net.Conn, [4]byte) {})
+	m, s := newTestModel(t, &Settings{Username: "tester", ConnectTimeout: 1})
+
+	start := time.Now()
+	err := m.joinServer(s.beginConnection(), s, connectionParams{addr: addr})
+
+	var connErr *connectError
+	if !errors.As(err, &connErr) || connErr.phase != phaseLogin || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("joinServer error = %v, want the login to time out", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("joinServer took %v with a 1s timeout", elapsed)
+	}
+	waitHungUp(t, hungUp)
+}
+
+func TestJoinServerCancelDuringLogin(t *testing.T) {
+	answered := make(chan struct{})
+	addr, hungUp := fakeLoginServer(t, func(net.Conn, [4]byte) { close(answered) })
+	m, s := newTestModel(t, &Settings{Username: "tester", ConnectTimeout: 30})
+
+	ctx := s.beginConnection()
+	go func() {
+		<-answered
+		s.connectionCtxCancel()
+	}()
+	err := m.joinServer(ctx, s, connectionParams{addr: addr})
+
+	var connErr *connectError
+	if !errors.As(err, &connErr) || connErr.phase != phaseLogin || !errors.Is(err, context.Canceled) {
+		t.Fatalf("joinServer error = %v, want the login to be cancelled", err)
+	}
+	waitHungUp(t, hungUp)
+}
+
+func TestJoinServerLoginReply(t *testing.T) {
+	addr, hungUp := fakeLoginServer(t, func(conn net.Conn, loginID [4]byte) {
+		reply := hotline.Transaction{IsReply: 1, ID: loginID}
+		_, _ = io.Copy(conn, &reply)
+	})
+	m, s := newTestModel(t, &Settings{Username: "tester", ConnectTimeout: 30})
+
+	if err := m.joinServer(s.beginConnection(), s, connectionParams{addr: addr}); err != nil {
+		t.Fatalf("joinServer: %v", err)
+	}
+
+	s.connectionCtxCancel()
+	_ = s.hlClient.Disconnect()
+	waitHungUp(t, hungUp)
+}
+
+func TestConnectError(t *testing.T) {
+	refused := errors.New("connection refused")
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: &connectError{phase: phaseTCP, err: refused}, want: "TCP connect failed: connection refused"},
+		{err: &connectError{phase: phaseTLS, err: context.DeadlineExceeded}, want: "TLS handshake timed out"},
+		{err: phaseError(context.Background(), phaseLogin, io.EOF), want: "Login failed: EOF"},
+		{err: phaseError(done, phaseHandshake, io.EOF), want: "Hotline handshake cancelled"}, // The EOF came from hanging up
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+	if err := (&connectError{phase: phaseTCP, err: refused}); !errors.Is(err, refused) {
+		t.Error("connectError doesn't unwrap to its cause")
+	}
+}
+
+func TestConnectTimeoutSetting(t *testing.T) {
+	if got := (&Settings{}).connectTimeout(); got != defaultConnectTimeout {
+		t.Errorf("unset timeout = %v, want %v", got, defaultConnectTimeout)
+	}
+	if got := (&Settings{ConnectTimeout: 3}).connectTimeout(); got != 3*time.Second {
+		t.Errorf("timeout = %v, want 3s", got)
+	}
+}
+
+func TestJoinServerHandshakeTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	// Accept the connection but never answer the handshake
+	hungUp := make(chan struct{})
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, _ = io.Copy(io.Discard, conn)
+		close(hungUp)
+	}()
+
+	m, s := newTestModel(t, &Settings{Username: "tester", ConnectTimeout: 1})
+	err = m.joinServer(s.beginConnection(), s, connectionParams{addr: ln.Addr().String()})
+
+	var connErr *connectError
+	if !errors.As(err, &connErr) || connErr.phase != phaseHandshake || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("joinServer error = %v, want the handshake to time out", err)
+	}
+	waitHungUp(t, hungUp)
+}
+
+func TestJoinServerRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	m, s := newTestModel(t, &Settings{Username: "tester", ConnectTimeout: 5})
+	err = m.joinServer(s.beginConnection(), s, connectionParams{addr: addr})
+
+	var connErr *connectError
+	if !errors.As(err, &connErr) || connErr.phase != phaseTCP || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("joinServer error = %v, want the TCP connect to fail", err)
+	}
+}
@@ -1,15 +1,14 @@
 package internal
 
 import (
-	"crypto/tls"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"os"
 	"path/filepath"
 	"slices"
-	"strconv"
 	"strings"
 	"time"
 
@@ -17,38 +16,44 @@ import (
 )
 
 // dialTransfer connects to the server's transfer port using TLS when the
-// control connection is TLS, otherwise falls back to plain TCP.
-func (m *Model) dialTransfer(addr string) (net.Conn, error) {
-	if m.connectionUsesTLS {
-		dialer := &net.Dialer{Timeout: 10 * time.Second}
-		return tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{InsecureSkipVerify: true})
+// control connection is TLS, otherwise falls back to plain TCP. The transfer
+// certificate must match the one the control connection was verified with,
+// and the connection goes through the same proxy.
+func (m *Model) dialTransfer(s *Session, addr string) (net.Conn, error) {
+	d := newDialer(nil, 10*time.Second)
+	if s.dialer != nil {
+		d = newDialer(s.dialer.proxy, 10*time.Second)
+	}
+
+	if s.connectionUsesTLS {
+		if s.certVerifier == nil {
+			return nil, errors.New("no verified TLS certificate for this connection")
+		}
+		return d.DialTLS(addr, s.certVerifier.forTransfer().tlsConfig())
 	}
 
-	return net.DialTimeout("tcp", addr, 10*time.Second)
+	return d.Dial(addr)
 }
 
-func (m *Model) performFileTransfer(task *Task, refNum [4]byte, transferSize uint32) {
+func (m *Model) performFileTransfer(s *Session, task *Task, refNum [4]byte, transferSize uint32) {
 	defer func() {
 		if task.Status == TaskActive {
 			task.Status = TaskCompleted
 			task.EndTime = time.Now()
 		}
-		m.program.Send(taskStatusMsg{
+		m.sendTo(s, taskStatusMsg{
 			taskID: task.ID,
 			status: task.Status,
 			err:    task.Error,
 		})
 	}()
 
-	// Get server address from hlClient
-	serverAddr := m.hlClient.Connection.RemoteAddr().String()
-	host, port, _ := net.SplitHostPort(serverAddr)
-	portInt, _ := strconv.Atoi(port)
-	ftAddr := net.JoinHostPort(host, strconv.Itoa(portInt+1))
+	// Connect to the file transfer port (server port + 1 unless the bookmark overrides it)
+	ftAddr := s.transferAddr
 
-	m.logger.Info("Connecting to file transfer server", "addr", ftAddr, "refNum", refNum, "tls", m.connectionUsesTLS)
+	m.logger.Info("Connecting to file transfer server", "addr", ftAddr, "refNum", refNum, "tls", s.connectionUsesTLS)
 
-	conn, err := m.dialTransfer(ftAddr)
+	conn, err := m.dialTransfer(s, ftAddr)
 	if err != nil {
 		task.Status = TaskFailed
 		task.Error = fmt.Errorf("connection failed: %w", err)
@@ -146,7 +151,7 @@ func (m *Model) performFileTransfer(task *Task, refNum [4]byte, transferSize uin
 	m.logger.Info("Data fork", "size", dataForkSize)
 
 	// Stream data fork to file with progress tracking
-	if err := m.copyWithProgress(file, conn, int64(dataForkSize), task); err != nil {
+	if err := m.copyWithProgress(s, file, conn, int64(dataForkSize), task); err != nil {
 		task.Status = TaskFailed
 		task.Error = fmt.Errorf("data transfer failed: %w", err)
 		m.logger.Error("Data transfer failed", "err", err)
@@ -197,7 +202,7 @@ func (m *Model) performFileTransfer(task *Task, refNum [4]byte, transferSize uin
 	m.logger.Info("File download completed", "path", localPath)
 }
 
-func (m *Model) copyWithProgress(dst io.Writer, src io.Reader, size int64, task *Task) error {
+func (m *Model) copyWithProgress(s *Session, dst io.Writer, src io.Reader, size int64, task *Task) error {
 	buf := make([]byte, 32*1024) // 32KB buffer
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
@@ -223,7 +228,7 @@ func (m *Model) copyWithProgress(dst io.Writer, src io.Reader, size int64, task
 			// Send progress update
 			select {
 			case <-ticker.C:
-				m.program.Send(taskProgressMsg{
+				m.sendTo(s, taskProgressMsg{
 					taskID: task.ID,
 					bytes:  written,
 				})
@@ -239,7 +244,7 @@ func (m *Model) copyWithProgress(dst io.Writer, src io.Reader, size int64, task
 	}
 
 	// Send final progress update
-	m.program.Send(taskProgressMsg{
+	m.sendTo(s, taskProgressMsg{
 		taskID: task.ID,
 		bytes:  written,
 	})
@@ -248,22 +253,23 @@ func (m *Model) copyWithProgress(dst io.Writer, src io.Reader, size int64, task
 }
 
 func (m *Model) resolveDownloadPath(fileName string) string {
-	basePath := filepath.Join(m.downloadDir, fileName)
+	return uniquePath(filepath.Join(m.downloadDir, fileName))
+}
 
-	// Check for conflicts and auto-rename
-	if _, err := os.Stat(basePath); err == nil {
-		ext := filepath.Ext(fileName)
-		nameWithoutExt := strings.TrimSuffix(fileName, ext)
+// uniquePath returns path, or if it exists the first free "name (n).ext" beside it
+func uniquePath(path string) string {
+	if _, err := os.Stat(path); err != nil {
+		return path
+	}
 
-		for i := 1; ; i++ {
-			newPath := filepath.Join(m.downloadDir, fmt.Sprintf("%s (%d)%s", nameWithoutExt, i, ext))
-			if _, err := os.Stat(newPath); os.IsNotExist(err) {
-				return newPath
-			}
+	ext := filepath.Ext(path)
+	pathWithoutExt := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		newPath := fmt.Sprintf("%s (%d)%s", pathWithoutExt, i, ext)
+		if _, err := os.Stat(newPath); os.IsNotExist(err) {
+			return newPath
 		}
 	}
-
-	return basePath
 }
 
 // writeAppleDoubleHeader writes a minimal AppleDouble header for resource fork storage
@@ -310,13 +316,13 @@ func (m *Model) writeAppleDoubleHeader(w io.Writer, resourceForkSize uint32) err
 }
 
 // performFileUpload handles the entire file upload process
-func (m *Model) performFileUpload(task *Task, refNum [4]byte) {
+func (m *Model) performFileUpload(s *Session, task *Task, refNum [4]byte) {
 	defer func() {
 		if task.Status == TaskActive {
 			task.Status = TaskCompleted
 			task.EndTime = time.Now()
 		}
-		m.program.Send(taskStatusMsg{
+		m.sendTo(s, taskStatusMsg{
 			taskID: task.ID,
 			status: task.Status,
 			err:    task.Error,
@@ -367,15 +373,12 @@ func (m *Model) performFileUpload(task *Task, refNum [4]byte) {
 		totalSize += uint32(16 + int(resForkSize))
 	}
 
-	// Connect to file transfer port (server port + 1)
-	serverAddr := m.hlClient.Connection.RemoteAddr().String()
-	host, port, _ := net.SplitHostPort(serverAddr)
-	portInt, _ := strconv.Atoi(port)
-	ftAddr := net.JoinHostPort(host, strconv.Itoa(portInt+1))
+	// Connect to the file transfer port (server port + 1 unless the bookmark overrides it)
+	ftAddr := s.transferAddr
 
-	m.logger.Info("Connecting to file transfer server", "addr", ftAddr, "refNum", refNum, "tls", m.connectionUsesTLS)
+	m.logger.Info("Connecting to file transfer server", "addr", ftAddr, "refNum", refNum, "tls", s.connectionUsesTLS)
 
-	conn, err := m.dialTransfer(ftAddr)
+	conn, err := m.dialTransfer(s, ftAddr)
 	if err != nil {
 		task.Status = TaskFailed
 		task.Error = fmt.Errorf("connection failed: %w", err)
@@ -402,7 +405,7 @@ func (m *Model) performFileUpload(task *Task, refNum [4]byte) {
 	}
 
 	// Send FlattenedFileObject
-	if err := m.sendFlattenedFileObject(conn, file, fileInfo, task); err != nil {
+	if err := m.sendFlattenedFileObject(s, conn, file, fileInfo, task); err != nil {
 		task.Status = TaskFailed
 		task.Error = err
 		m.logger.Error("Upload failed", "err", err)
@@ -413,7 +416,7 @@ func (m *Model) performFileUpload(task *Task, refNum [4]byte) {
 }
 
 // sendFlattenedFileObject sends the FFO structure with file data
-func (m *Model) sendFlattenedFileObject(conn net.Conn, file *os.File, fileInfo os.FileInfo, task *Task) error {
+func (m *Model) sendFlattenedFileObject(s *Session, conn net.Conn, file *os.File, fileInfo os.FileInfo, task *Task) error {
 	// Check for AppleDouble resource fork
 	resPath := filepath.Join(filepath.Dir(task.LocalPath), "._"+filepath.Base(task.LocalPath))
 	var resFile *os.File
@@ -471,7 +474,7 @@ func (m *Model) sendFlattenedFileObject(conn net.Conn, file *os.File, fileInfo o
 	}
 
 	// Stream file data with progress tracking
-	if err := m.copyWithProgressUpload(conn, file, fileInfo.Size(), task); err != nil {
+	if err := m.copyWithProgressUpload(s, conn, file, fileInfo.Size(), task); err != nil {
 		return fmt.Errorf("data transfer: %w", err)
 	}
 
@@ -541,7 +544,7 @@ func (m *Model) createInfoFork(fileInfo os.FileInfo) []byte {
 }
 
 // copyWithProgressUpload streams data with progress tracking for uploads
-func (m *Model) copyWithProgressUpload(dst io.Writer, src io.Reader, size int64, task *Task) error {
+func (m *Model) copyWithProgressUpload(s *Session, dst io.Writer, src io.Reader, size int64, task *Task) error {
 	buf := make([]byte, 32*1024) // 32KB buffer
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
@@ -567,7 +570,7 @@ func (m *Model) copyWithProgressUpload(dst io.Writer, src io.Reader, size int64,
 			// Send progress update
 			select {
 			case <-ticker.C:
-				m.program.Send(taskProgressMsg{
+				m.sendTo(s, taskProgressMsg{
 					taskID: task.ID,
 					bytes:  written,
 				})
@@ -583,7 +586,7 @@ func (m *Model) copyWithProgressUpload(dst io.Writer, src io.Reader, size int64,
 	}
 
 	// Send final progress update
-	m.program.Send(taskProgressMsg{
+	m.sendTo(s, taskProgressMsg{
 		taskID: task.ID,
 		bytes:  written,
 	})
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -27,74 +28,27 @@ func (m *Model) registerHandler(msgType tea.Msg, handler msgHandler) {
 func (m *Model) handleWindowResize(msg tea.Msg) (tea.Model, tea.Cmd) {
 	windowMsg := msg.(tea.WindowSizeMsg)
 	m.width = windowMsg.Width
-	m.height = windowMsg.Height
-	m.resizeAllScreens(windowMsg.Width, windowMsg.Height)
+	m.termHeight = windowMsg.Height
+	m.relayout()
 	return m, nil
 }
 
 func (m *Model) resizeAllScreens(w, h int) {
-	if m.homeScreen != nil {
-		m.homeScreen.SetSize(w, h)
-	}
-	if m.joinServerScreen != nil {
-		m.joinServerScreen.SetSize(w, h)
-	}
-	if m.bookmarkScreen != nil {
-		m.bookmarkScreen.SetSize(w, h)
-	}
-	if m.trackerScreen != nil {
-		m.trackerScreen.SetSize(w, h)
-	}
-	if m.settingsScreen != nil {
-		m.settingsScreen.SetSize(w, h)
-	}
-	if m.serverScreen != nil {
-		m.serverScreen.SetSize(w, h)
-	}
-	if m.newsScreen != nil {
-		m.newsScreen.SetSize(w, h)
-	}
-	if m.newsArticlePostScreen != nil {
-		m.newsArticlePostScreen.SetSize(w, h)
-	}
-	if m.newsBundleFormScreen != nil {
-		m.newsBundleFormScreen.SetSize(w, h)
-	}
-	if m.newsCategoryFormScreen != nil {
-		m.newsCategoryFormScreen.SetSize(w, h)
-	}
-	if m.legacyNewsPostScreen != nil {
-		m.legacyNewsPostScreen.SetSize(w, h)
-	}
-	if m.accountsScreen != nil {
-		m.accountsScreen.SetSize(w, h)
-	}
-	if m.filesScreen != nil {
-		m.filesScreen.SetSize(w, h)
-	}
-	if m.tasksScreen != nil {
-		m.tasksScreen.SetSize(w, h)
-	}
-	if m.logsScreen != nil {
-		m.logsScreen.SetSize(w, h)
-	}
-	if m.messageBoardScreen != nil {
-		m.messageBoardScreen.SetSize(w, h)
-	}
-	if m.filePickerScreen != nil {
-		m.filePickerScreen.SetSize(w, h)
-	}
-	if m.composeMessageScreen != nil {
-		m.composeMessageScreen.SetSize(w, h)
-	}
-	if m.modalScreen != nil {
-		m.modalScreen.SetSize(w, h)
+	for _, s := range m.sessions {
+		s.resizeScreens(w, h)
 	}
 }
 
 func (m *Model) handleChatMsgfunc(msg tea.Msg) (tea.Model, tea.Cmd) {
 	chatMessage := msg.(chatMsg)
 
+	if chatMessage.ignored {
+		if m.serverScreen != nil {
+			m.serverScreen.AddIgnoredLine()
+		}
+		return m, nil
+	}
+
 	// Use regex to extract username (everything up to and including first colon)
 	re := regexp.MustCompile(`^[^:]*:`)
 	match := re.FindString(chatMessage.text)
@@ -103,11 +57,27 @@ func (m *Model) handleChatMsgfunc(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// Apply bold styling to username
 	message := strings.TrimPrefix(chatMessage.text, match)
+	if chatMessage.mention {
+		message = mentionStyle.Render(message)
+	}
 	formattedMsg = style.UsernameStyle.Render(match) + message
 
+	if isPrivateChat(chatMessage.chatID) {
+		if room := m.chatRoom(chatMessage.chatID); room != nil {
+			m.addChatRoomLine(room, formattedMsg)
+		}
+		return m, nil
+	}
+
+	m.logChat(strings.TrimSpace(chatMessage.text))
+
 	// Add to server screen if it exists
 	if m.serverScreen != nil {
-		m.serverScreen.AddChatMessage(formattedMsg)
+		if chatMessage.mention {
+			m.serverScreen.AddMention(formattedMsg)
+		} else {
+			m.serverScreen.AddChatMessage(formattedMsg)
+		}
 	}
 
 	return m, nil
@@ -147,6 +117,8 @@ func (m *Model) handleErrorMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m *Model) handleServerMsgMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 	serverMessage := msg.(serverMsgMsg)
+	m.autoReply(serverMessage)
+	m.logChat(fmt.Sprintf("[private message from %s] %s", serverMessage.from, serverMessage.text))
 
 	// Add to private message stack
 	pm := PrivateMessage{
@@ -169,11 +141,19 @@ func (m *Model) handleServerMsgMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) handleAgreementMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	agreementMessage := msg.(agreementMsg)
+	m.serverInfo.agreement = agreementMessage.text
+
+	// The agreement was already accepted before the connection dropped
+	if m.autoAgree {
+		m.autoAgree = false
+		return m, m.agreeToServer()
+	}
+
 	// Pop loading screen if it's active
 	if m.CurrentScreen() == ScreenLoading {
 		m.PopScreen()
 	}
-	agreementMessage := msg.(agreementMsg)
 	m.modalScreen = NewModalScreen(
 		ModalTypeAgreement,
 		"Server Agreement",
@@ -191,8 +171,27 @@ func (m *Model) handleServerConnectedMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.PopScreen()
 	}
 
+	// The attempt was abandoned while the login was in flight
+	if m.pendingConnection == nil {
+		return m, nil
+	}
+	m.activeConnection = m.pendingConnection
+	m.pendingConnection = nil
+
 	serverConnected := msg.(serverConnectedMsg)
+	agreement := m.serverInfo.agreement
+	m.serverInfo = serverConnected.info
+	m.serverInfo.agreement = agreement
+	m.serverInfo.connectedAt = time.Now()
+
+	// Pick up where we left off after an automatic reconnect
+	if m.reconnect != nil && m.serverScreen != nil {
+		m.soundPlayer.PlayAsync(SoundLoggedIn)
+		return m, m.finishReconnect()
+	}
+
 	m.serverName = serverConnected.name
+	m.logChat(fmt.Sprintf("--- Connected to %s as %s ---", serverConnected.name, m.prefs.Username))
 
 	// Create and initialize ServerScreen
 	m.serverScreen = NewServerScreen(m)
@@ -204,9 +203,9 @@ func (m *Model) handleServerConnectedMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.soundPlayer.PlayAsync(SoundLoggedIn)
 
 	// Add initial join message to chat viewport
-	joinStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("241"))
-	joinMsg := joinStyle.Render(fmt.Sprintf("→ %s joined", m.prefs.Username))
+	joinMsg := style.NoticeStyle.Render(fmt.Sprintf("→ %s joined", m.prefs.Username))
 	m.serverScreen.AddChatMessage(joinMsg)
+	m.logChat(joinMsg)
 
 	return m, nil
 }
@@ -229,7 +228,7 @@ func (m *Model) handleTrackerServerSelectedMsg(msg tea.Msg) (tea.Model, tea.Cmd)
 	// Create join server screen with tracker server details
 	var cmd tea.Cmd
 	m.joinServerScreen, cmd = NewJoinServerScreenForConnect(
-		srv.Addr(),
+		serverRecordAddr(srv),
 		hotline.GuestAccount,
 		"",
 		false,
@@ -237,6 +236,7 @@ func (m *Model) handleTrackerServerSelectedMsg(msg tea.Msg) (tea.Model, tea.Cmd)
 		m,
 	)
 	m.pendingServerName = string(srv.Name)
+	m.pendingBookmark = nil
 	m.ReplaceScreen(ScreenJoinServer)
 
 	return m, cmd
@@ -255,12 +255,25 @@ func (m *Model) handleSettingsSavedMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.prefs.IconID = settingsMsg.IconID
 	m.prefs.Tracker = settingsMsg.Tracker
 	m.prefs.DownloadDir = settingsMsg.DownloadDir
+	m.prefs.ChatLogDir = settingsMsg.ChatLogDir
+	m.prefs.Ignore = settingsMsg.Ignore
 	m.prefs.EnableBell = settingsMsg.EnableBell
 	m.prefs.EnableSounds = settingsMsg.EnableSounds
 
+	m.updateHighlights()
+	m.updateTriggers()
+	m.refreshIgnores()
+
 	// Update the active download directory
 	m.downloadDir = m.prefs.DownloadDir
 
+	// Log new chat to the new directory
+	if m.prefs.ChatLogDir != "" {
+		m.chatLog.setDir(m.prefs.ChatLogDir)
+	} else {
+		m.chatLog.setDir(defaultChatLogDir())
+	}
+
 	// Update sound player enabled state
 	if m.soundPlayer != nil {
 		m.soundPlayer.SetEnabled(m.prefs.EnableSounds)
@@ -295,6 +308,7 @@ func (m *Model) handleBookmarkSelectedMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m,
 	)
 	m.pendingServerName = bm.Name
+	m.pendingBookmark = &bm
 	m.ReplaceScreen(ScreenJoinServer)
 
 	return m, cmd
@@ -342,15 +356,50 @@ func (m *Model) handleJoinServerConnectMsg(msg JoinServerConnectMsg) tea.Cmd {
 		m.pendingServerName = msg.Addr
 	}
 
+	params := connectionParams{
+		name:     m.pendingServerName,
+		addr:     msg.Addr,
+		login:    msg.Login,
+		password: msg.Password,
+		useTLS:   msg.TLS,
+	}
+	if bm := m.pendingBookmark; bm != nil {
+		params.tlsCAFile = bm.TLSCAFile
+		params.tlsStrict = bm.TLSStrict
+		params.transfer = transferEndpoint{host: bm.TransferHost, port: bm.TransferPort}
+		params.bookmark = bm.Name
+	}
+	params.proxy = m.prefs.proxyFor(m.pendingBookmark)
+	params.encoding = m.prefs.encodingFor(m.pendingBookmark)
+	m.pendingBookmark = nil
+
+	return m.connectToServer(params)
+}
+
+// connectToServer shows the loading screen and connects the active session in the background
+func (m *Model) connectToServer(params connectionParams) tea.Cmd {
+	// A deliberate connection replaces any connection we were trying to get back to
+	m.reconnect = nil
+	m.endResume()
+	m.pendingConnection = &params
+	m.serverInfo = serverInfo{}
+	m.banner = nil
+	m.ignores.setPatterns(m.ignorePatterns(&params))
+	m.ignores.clearIDs()
+	m.text.setEncoding(params.encoding)
+
 	// Show loading screen while connecting
 	var loadingCmd tea.Cmd
 	m.loadingScreen, loadingCmd = NewLoadingScreen("Connecting to server...", m)
 	m.PushScreen(ScreenLoading)
 
 	// Connect to server asynchronously
+	s := m.Session
+	pending := m.pendingConnection
+	ctx := s.beginConnection()
 	connectCmd := func() tea.Msg {
-		err := m.joinServer(msg.Addr, msg.Login, msg.Password, msg.TLS)
-		return serverConnectionAttemptMsg{err: err}
+		err := m.joinServer(ctx, s, params)
+		return serverConnectionAttemptMsg{params: pending, err: err}
 	}
 
 	return tea.Batch(loadingCmd, connectCmd)
@@ -358,11 +407,31 @@ func (m *Model) handleJoinServerConnectMsg(msg JoinServerConnectMsg) tea.Cmd {
 
 func (m *Model) handleServerConnectionAttemptMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 	attemptMsg := msg.(serverConnectionAttemptMsg)
+	if attemptMsg.err != nil && attemptMsg.params != m.pendingConnection {
+		// The attempt was cancelled or superseded by a newer one
+		return m, nil
+	}
 	if attemptMsg.err != nil {
 		// Connection failed - pop loading screen and show error
 		if m.CurrentScreen() == ScreenLoading {
 			m.PopScreen()
 		}
+		params := m.pendingConnection
+		m.pendingConnection = nil
+
+		// First connection to a TLS server: ask whether to trust its certificate
+		var unknown *unknownCertError
+		if errors.As(attemptMsg.err, &unknown) && params != nil {
+			m.pendingTrust = &pendingTrust{cert: unknown, params: *params}
+			content := fmt.Sprintf(
+				"%s presented a certificate that is not in your known hosts.\n\n%s\n\nTrust this certificate and connect?",
+				unknown.hostKey, formatFingerprint(unknown.fingerprint),
+			)
+			m.modalScreen = NewModalScreen(ModalTypeTrustCertificate, "Unknown Server Certificate", content, []string{"Reject", "Trust"}, m)
+			m.PushScreen(ScreenModal)
+			return m, m.modalScreen.Init()
+		}
+
 		m.modalScreen = NewModalScreen(ModalTypeError, "Connection Error", attemptMsg.err.Error(), []string{"OK"}, m)
 		m.PushScreen(ScreenModal)
 		return m, m.modalScreen.Init()
@@ -379,6 +448,11 @@ func (m *Model) handleJoinServerBookmarkSavedMsg(msg JoinServerBookmarkSavedMsg)
 		m.prefs.Bookmarks[msg.Index].Login = msg.Login
 		m.prefs.Bookmarks[msg.Index].Password = msg.Password
 		m.prefs.Bookmarks[msg.Index].TLS = msg.TLS
+		m.prefs.Bookmarks[msg.Index].TLSCAFile = msg.CAFile
+		m.prefs.Bookmarks[msg.Index].TLSStrict = msg.Strict
+		m.prefs.Bookmarks[msg.Index].TransferHost = msg.Transfer.host
+		m.prefs.Bookmarks[msg.Index].TransferPort = msg.Transfer.port
+		m.prefs.Bookmarks[msg.Index].Encoding = msg.Encoding
 		_ = m.savePreferences()
 	}
 	m.bookmarkScreen = NewBookmarkScreen(m.prefs.Bookmarks, m)
@@ -387,6 +461,12 @@ func (m *Model) handleJoinServerBookmarkSavedMsg(msg JoinServerBookmarkSavedMsg)
 
 func (m *Model) handleJoinServerBookmarkCreatedMsg(msg JoinServerBookmarkCreatedMsg) {
 	m.prefs.AddBookmark(msg.Name, msg.Addr, msg.Login, msg.Password, msg.TLS)
+	bm := &m.prefs.Bookmarks[len(m.prefs.Bookmarks)-1]
+	bm.TLSCAFile = msg.CAFile
+	bm.TLSStrict = msg.Strict
+	bm.TransferHost = msg.Transfer.host
+	bm.TransferPort = msg.Transfer.port
+	bm.Encoding = msg.Encoding
 	_ = m.savePreferences()
 	m.bookmarkScreen = NewBookmarkScreen(m.prefs.Bookmarks, m)
 	m.PopScreen()
@@ -437,6 +517,12 @@ func (m *Model) handleFilesMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.CurrentScreen() != ScreenFiles {
 		m.PushScreen(ScreenFiles)
 	}
+
+	// Finish opening a hotline:// URL that named a file or folder
+	if name := m.pendingFileTarget; name != "" {
+		m.pendingFileTarget = ""
+		return m, m.openFileTarget(name, filesMessage.files)
+	}
 	return m, nil
 }
 
@@ -473,12 +559,12 @@ func (m *Model) handleNewsArticleDataMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) handleNewsNavigateToCategoryMsg(msg NewsNavigateToCategoryMsg) {
-	pathBytes := encodeNewsPath(msg.Path)
-	if err := m.hlClient.Send(hotline.NewTransaction(
+	pathBytes := m.encodeNewsPath(msg.Path)
+	if err := m.request(hotline.NewTransaction(
 		hotline.TranGetNewsArtNameList,
 		[2]byte{},
 		hotline.NewField(hotline.FieldNewsPath, pathBytes),
-	)); err != nil {
+	), "news article list"); err != nil {
 		m.logger.Error("Error requesting news articles", "err", err)
 	}
 }
@@ -486,26 +572,26 @@ func (m *Model) handleNewsNavigateToCategoryMsg(msg NewsNavigateToCategoryMsg) {
 func (m *Model) handleNewsNavigateToBundleMsg(msg NewsNavigateToBundleMsg) {
 	var fields []hotline.Field
 	if len(msg.Path) > 0 {
-		pathBytes := encodeNewsPath(msg.Path)
+		pathBytes := m.encodeNewsPath(msg.Path)
 		fields = append(fields, hotline.NewField(hotline.FieldNewsPath, pathBytes))
 	}
-	if err := m.hlClient.Send(hotline.NewTransaction(hotline.TranGetNewsCatNameList, [2]byte{}, fields...)); err != nil {
+	if err := m.request(hotline.NewTransaction(hotline.TranGetNewsCatNameList, [2]byte{}, fields...), "news category list"); err != nil {
 		m.logger.Error("Error requesting news categories", "err", err)
 	}
 }
 
 func (m *Model) handleNewsRequestArticleMsg(msg NewsRequestArticleMsg) {
-	pathBytes := encodeNewsPath(msg.Path)
+	pathBytes := m.encodeNewsPath(msg.Path)
 
 	articleIDBytes := make([]byte, 4)
 	binary.BigEndian.PutUint32(articleIDBytes, msg.ArticleID)
 
-	if err := m.hlClient.Send(hotline.NewTransaction(
+	if err := m.request(hotline.NewTransaction(
 		hotline.TranGetNewsArtData,
 		[2]byte{},
 		hotline.NewField(hotline.FieldNewsPath, pathBytes),
 		hotline.NewField(hotline.FieldNewsArtID, articleIDBytes),
-	)); err != nil {
+	), "news article"); err != nil {
 		m.logger.Error("Error requesting article data", "err", err)
 	}
 }
@@ -635,29 +721,20 @@ func (m *Model) handleTaskStatusMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m *Model) handleDownloadReplyMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 	downloadReply := msg.(downloadReplyMsg)
-	taskID := m.pendingDownloads[downloadReply.txID]
-	delete(m.pendingDownloads, downloadReply.txID)
-
-	task := m.taskManager.Get(taskID)
+	task := m.taskManager.Get(downloadReply.taskID)
 	if task != nil {
 		task.TotalBytes = int64(downloadReply.transferSize)
 		task.Status = TaskActive
 
 		// Launch file transfer in background
-		go m.performFileTransfer(task, downloadReply.refNum, downloadReply.transferSize)
+		go m.performFileTransfer(m.Session, task, downloadReply.refNum, downloadReply.transferSize)
 	}
 	return m, nil
 }
 
 func (m *Model) handleUploadReplyMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 	uploadReply := msg.(uploadReplyMsg)
-	taskID, ok := m.pendingUploads[uploadReply.txID]
-	if !ok {
-		return m, nil
-	}
-	delete(m.pendingUploads, uploadReply.txID)
-
-	task := m.taskManager.Get(taskID)
+	task := m.taskManager.Get(uploadReply.taskID)
 	if task == nil {
 		return m, nil
 	}
@@ -665,7 +742,7 @@ func (m *Model) handleUploadReplyMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 	task.Status = TaskActive
 
 	// Start file transfer in goroutine
-	go m.performFileUpload(task, uploadReply.refNum)
+	go m.performFileUpload(m.Session, task, uploadReply.refNum)
 
 	return m, nil
 }
@@ -674,7 +751,7 @@ func (m *Model) handleUploadReplyMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m *Model) handleNewsArticlePostedMsg(msg NewsArticlePostedMsg) {
 	// Create transaction with all required fields
-	pathBytes := encodeNewsPath(msg.Path)
+	pathBytes := m.encodeNewsPath(msg.Path)
 
 	// Parent article ID: 0 for new post, or stored ID for replies
 	parentIDBytes := make([]byte, 4)
@@ -685,79 +762,79 @@ func (m *Model) handleNewsArticlePostedMsg(msg NewsArticlePostedMsg) {
 		[2]byte{},
 		hotline.NewField(hotline.FieldNewsPath, pathBytes),
 		hotline.NewField(hotline.FieldNewsArtID, parentIDBytes),
-		hotline.NewField(hotline.FieldNewsArtTitle, []byte(msg.Subject)),
-		hotline.NewField(hotline.FieldNewsArtData, []byte(msg.Body)),
+		hotline.NewField(hotline.FieldNewsArtTitle, m.encodeText(msg.Subject)),
+		hotline.NewField(hotline.FieldNewsArtData, m.encodeText(msg.Body)),
 	)
 
-	if err := m.hlClient.Send(t); err != nil {
+	if err := m.sendTransaction(t); err != nil {
 		m.logger.Error("Error posting news article", "err", err)
 	}
 
 	m.PopScreen()
 
 	// Refetch the article list to show the new post
-	refetchPathBytes := encodeNewsPath(m.newsScreen.GetPath())
-	if err := m.hlClient.Send(hotline.NewTransaction(
+	refetchPathBytes := m.encodeNewsPath(m.newsScreen.GetPath())
+	if err := m.request(hotline.NewTransaction(
 		hotline.TranGetNewsArtNameList,
 		[2]byte{},
 		hotline.NewField(hotline.FieldNewsPath, refetchPathBytes),
-	)); err != nil {
+	), "news article list"); err != nil {
 		m.logger.Error("Error refetching articles", "err", err)
 	}
 }
 
 func (m *Model) handleNewsBundleCreatedMsg(msg NewsBundleCreatedMsg) {
 	// Create bundle at current location
-	pathBytes := encodeNewsPath(msg.Path)
+	pathBytes := m.encodeNewsPath(msg.Path)
 
 	t := hotline.NewTransaction(
 		hotline.TranNewNewsFldr,
 		[2]byte{},
 		hotline.NewField(hotline.FieldNewsPath, pathBytes),
-		hotline.NewField(hotline.FieldFileName, []byte(msg.Name)),
+		hotline.NewField(hotline.FieldFileName, m.encodeText(msg.Name)),
 	)
 
-	if err := m.hlClient.Send(t); err != nil {
+	if err := m.sendTransaction(t); err != nil {
 		m.logger.Error("Error creating news bundle", "err", err)
 	}
 
 	m.PopScreen()
 
 	// Refetch current location
-	refetchPathBytes := encodeNewsPath(m.newsScreen.GetPath())
-	if err := m.hlClient.Send(hotline.NewTransaction(
+	refetchPathBytes := m.encodeNewsPath(m.newsScreen.GetPath())
+	if err := m.request(hotline.NewTransaction(
 		hotline.TranGetNewsCatNameList,
 		[2]byte{},
 		hotline.NewField(hotline.FieldNewsPath, refetchPathBytes),
-	)); err != nil {
+	), "news category list"); err != nil {
 		m.logger.Error("Error refetching categories", "err", err)
 	}
 }
 
 func (m *Model) handleNewsCategoryCreatedMsg(msg NewsCategoryCreatedMsg) {
 	// Create category at current location
-	pathBytes := encodeNewsPath(msg.Path)
+	pathBytes := m.encodeNewsPath(msg.Path)
 
 	t := hotline.NewTransaction(
 		hotline.TranNewNewsCat,
 		[2]byte{},
 		hotline.NewField(hotline.FieldNewsPath, pathBytes),
-		hotline.NewField(hotline.FieldNewsCatName, []byte(msg.Name)),
+		hotline.NewField(hotline.FieldNewsCatName, m.encodeText(msg.Name)),
 	)
 
-	if err := m.hlClient.Send(t); err != nil {
+	if err := m.sendTransaction(t); err != nil {
 		m.logger.Error("Error creating news category", "err", err)
 	}
 
 	m.PopScreen()
 
 	// Refetch current location
-	refetchPathBytes := encodeNewsPath(m.newsScreen.GetPath())
-	if err := m.hlClient.Send(hotline.NewTransaction(
+	refetchPathBytes := m.encodeNewsPath(m.newsScreen.GetPath())
+	if err := m.request(hotline.NewTransaction(
 		hotline.TranGetNewsCatNameList,
 		[2]byte{},
 		hotline.NewField(hotline.FieldNewsPath, refetchPathBytes),
-	)); err != nil {
+	), "news category list"); err != nil {
 		m.logger.Error("Error refetching categories", "err", err)
 	}
 }
@@ -767,19 +844,19 @@ func (m *Model) handleLegacyNewsPostedMsg(msg LegacyNewsPostedMsg) {
 	t := hotline.NewTransaction(
 		hotline.TranOldPostNews,
 		[2]byte{},
-		hotline.NewField(hotline.FieldData, []byte(msg.Content)),
+		hotline.NewField(hotline.FieldData, m.encodeText(msg.Content)),
 	)
 
-	if err := m.hlClient.Send(t); err != nil {
+	if err := m.sendTransaction(t); err != nil {
 		m.logger.Error("Error posting news", "err", err)
 	}
 
+	m.PopScreen()
+
 	// Refresh the messageboard content
-	if err := m.hlClient.Send(hotline.NewTransaction(hotline.TranGetMsgs, [2]byte{})); err != nil {
+	if err := m.request(hotline.NewTransaction(hotline.TranGetMsgs, [2]byte{}), "message board"); err != nil {
 		m.logger.Error("Error refreshing messageboard", "err", err)
 	}
-
-	m.PopScreen()
 }
 
 // ServerScreen message handlers
@@ -791,23 +868,58 @@ func (m *Model) handleServerDisconnectRequestedMsg() tea.Cmd {
 }
 
 func (m *Model) handleServerSendChatMsg(msg ServerSendChatMsg) {
+	if m.activeConnection != nil {
+		if err := m.history.Add(m.activeConnection.addr, msg.Text); err != nil {
+			m.logger.Error("Failed to save input history", "err", err)
+		}
+	}
+
+	text := msg.Text
+	if strings.HasPrefix(text, "//") {
+		// A doubled slash sends chat that starts with a slash
+		text = text[1:]
+	} else if strings.HasPrefix(text, "/") {
+		m.runChatCommand(text)
+		return
+	}
+
 	t := hotline.NewTransaction(hotline.TranChatSend, [2]byte{},
-		hotline.NewField(hotline.FieldData, []byte(msg.Text)),
+		hotline.NewField(hotline.FieldData, m.encodeText(text)),
 	)
-	_ = m.hlClient.Send(t)
+	_ = m.sendTransaction(t)
+}
+
+func (m *Model) handleServerOpenInfoMsg() {
+	m.serverInfoScreen = NewServerInfoScreen(m)
+	m.PushScreen(ScreenServerInfo)
+}
+
+func (m *Model) handleServerBannerMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	banner := msg.(serverBannerMsg)
+	m.serverInfo.bannerType = banner.bannerType
+	m.serverInfo.bannerURL = banner.url
+	return m, nil
+}
+
+// ServerInfoScreen message handlers
+
+func (m *Model) handleServerInfoShowAgreementMsg() tea.Cmd {
+	m.modalScreen = NewModalScreen(ModalTypeGeneric, "Server Agreement", m.serverInfo.agreement, []string{"Close"}, m)
+	m.PushScreen(ScreenModal)
+	return m.modalScreen.Init()
 }
 
 func (m *Model) handleServerOpenNewsMsg() {
 	// Request threaded news - create fresh screen (handler will initialize when response arrives)
 	m.newsScreen = NewNewsScreen(m)
-	if err := m.hlClient.Send(hotline.NewTransaction(hotline.TranGetNewsCatNameList, [2]byte{})); err != nil {
+	if err := m.request(hotline.NewTransaction(hotline.TranGetNewsCatNameList, [2]byte{}), "news category list"); err != nil {
 		m.logger.Error("Error requesting news categories", "err", err)
 	}
 }
 
 func (m *Model) handleServerOpenMessageBoardMsg() {
 	// Request messageboard
-	if err := m.hlClient.Send(hotline.NewTransaction(hotline.TranGetMsgs, [2]byte{})); err != nil {
+	if err := m.request(hotline.NewTransaction(hotline.TranGetMsgs, [2]byte{}), "message board"); err != nil {
 		m.logger.Error("Error requesting messageboard", "err", err)
 	}
 }
@@ -816,14 +928,14 @@ func (m *Model) handleServerOpenFilesMsg() {
 	// Create fresh files screen
 	m.filesScreen = NewFilesScreen(m)
 	// Request file list (handler will push screen when response arrives)
-	if err := m.hlClient.Send(hotline.NewTransaction(hotline.TranGetFileNameList, [2]byte{})); err != nil {
+	if err := m.request(hotline.NewTransaction(hotline.TranGetFileNameList, [2]byte{}), "file list"); err != nil {
 		m.logger.Error("Error requesting files", "err", err)
 	}
 }
 
 func (m *Model) handleServerOpenAccountsMsg() {
 	// Request user accounts list
-	if err := m.hlClient.Send(hotline.NewTransaction(hotline.TranListUsers, [2]byte{})); err != nil {
+	if err := m.request(hotline.NewTransaction(hotline.TranListUsers, [2]byte{}), "account list"); err != nil {
 		m.logger.Error("Error requesting account list", "err", err)
 	}
 }
@@ -851,20 +963,27 @@ func (m *Model) handleServerComposeMessageMsg(msg ServerComposeMessageMsg) tea.C
 
 // ComposeMessageScreen message handlers
 
-func (m *Model) handleComposeMessageSentMsg(msg ComposeMessageSentMsg) tea.Cmd {
-	// Build transaction fields
+// sendPrivateMessage sends text to a user, quoting quote if it isn't empty, and
+// logs it
+func (m *Model) sendPrivateMessage(target [2]byte, text, quote string) error {
 	fields := []hotline.Field{
-		hotline.NewField(hotline.FieldData, []byte(msg.Text)),
-		hotline.NewField(hotline.FieldUserID, msg.TargetID[:]),
+		hotline.NewField(hotline.FieldData, m.encodeText(text)),
+		hotline.NewField(hotline.FieldUserID, target[:]),
 	}
-
-	// Add quoted message if replying
-	if msg.QuoteText != "" {
-		fields = append(fields, hotline.NewField(hotline.FieldQuotingMsg, []byte(msg.QuoteText)))
+	if quote != "" {
+		fields = append(fields, hotline.NewField(hotline.FieldQuotingMsg, m.encodeText(quote)))
 	}
 
 	t := hotline.NewTransaction(hotline.TranSendInstantMsg, [2]byte{}, fields...)
-	if err := m.hlClient.Send(t); err != nil {
+	if err := m.sendTransaction(t); err != nil {
+		return err
+	}
+	m.logChat(fmt.Sprintf("[private message to %s] %s", m.userName(target), text))
+	return nil
+}
+
+func (m *Model) handleComposeMessageSentMsg(msg ComposeMessageSentMsg) tea.Cmd {
+	if err := m.sendPrivateMessage(msg.TargetID, msg.Text, msg.QuoteText); err != nil {
 		m.logger.Error("Error sending private message", "err", err)
 	}
 
@@ -930,16 +1049,16 @@ func (m *Model) handleFilesGetInfoMsg(msg FilesGetInfoMsg) {
 	t := hotline.NewTransaction(
 		hotline.TranGetFileInfo,
 		[2]byte{},
-		hotline.NewField(hotline.FieldFileName, []byte(msg.FileName)),
+		hotline.NewField(hotline.FieldFileName, m.encodeText(msg.FileName)),
 	)
 
 	// Add file path if in subdirectory
 	if len(msg.FilePath) > 0 {
 		pathStr := strings.Join(msg.FilePath, "/")
-		t.Fields = append(t.Fields, hotline.NewField(hotline.FieldFilePath, hotline.EncodeFilePath(pathStr)))
+		t.Fields = append(t.Fields, hotline.NewField(hotline.FieldFilePath, m.encodeFilePath(pathStr)))
 	}
 
-	if err := m.hlClient.Send(t); err != nil {
+	if err := m.request(t, "file info"); err != nil {
 		m.logger.Error("Error sending file info request", "err", err)
 	}
 }
@@ -957,8 +1076,8 @@ func (m *Model) handleFilesNavigateMsg(msg FilesNavigateMsg) {
 		m.filesScreen.SetFilePath(msg.Path)
 	}
 	// Request new file list for this path
-	f := hotline.NewField(hotline.FieldFilePath, hotline.EncodeFilePath(strings.Join(msg.Path, "/")))
-	if err := m.hlClient.Send(hotline.NewTransaction(hotline.TranGetFileNameList, [2]byte{}, f)); err != nil {
+	f := hotline.NewField(hotline.FieldFilePath, m.encodeFilePath(strings.Join(msg.Path, "/")))
+	if err := m.request(hotline.NewTransaction(hotline.TranGetFileNameList, [2]byte{}, f), "file list"); err != nil {
 		m.logger.Error("Error requesting file list", "err", err)
 	}
 }
@@ -975,6 +1094,7 @@ func (m *Model) handleMessageBoardPostRequestedMsg() tea.Cmd {
 // HomeScreen message handlers
 
 func (m *Model) handleHomeJoinServerMsg() tea.Cmd {
+	m.pendingBookmark = nil
 	var cmd tea.Cmd
 	m.joinServerScreen, cmd = NewJoinServerScreen(m)
 	m.PushScreen(ScreenJoinServer)
@@ -991,7 +1111,7 @@ func (m *Model) handleHomeTrackerMsg() tea.Cmd {
 	var cmd tea.Cmd
 	m.loadingScreen, cmd = NewLoadingScreen("Connecting to tracker...", m)
 	m.PushScreen(ScreenLoading)
-	return tea.Batch(cmd, fetchTrackerList(m.prefs.Tracker))
+	return tea.Batch(cmd, fetchTrackerList(m.prefs.Tracker, newDialer(m.prefs.proxyFor(nil), 5*time.Second)))
 }
 
 func (m *Model) handleHomeSettingsMsg() tea.Cmd {
@@ -1001,6 +1121,12 @@ func (m *Model) handleHomeSettingsMsg() tea.Cmd {
 	return cmd
 }
 
+func (m *Model) handleHomeChatLogsMsg() tea.Cmd {
+	m.chatLogsScreen = NewChatLogsScreen(m)
+	m.PushScreen(ScreenChatLogs)
+	return m.chatLogsScreen.Init()
+}
+
 // FilePickerScreen message handlers
 
 func (m *Model) handleFilePickerFileSelectedMsg(msg FilePickerFileSelectedMsg) tea.Cmd {
@@ -9,7 +9,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/charmbracelet/lipgloss"
+	"github.com/jhalter/mobius-hotline-client/internal/style"
 	"github.com/jhalter/mobius/hotline"
 )
 
@@ -23,28 +23,52 @@ var ignoredErrorMessages = []string{
 // checkTransactionError checks if a transaction has an error response and sends
 // an error message to the UI if one exists.
 // Returns true if an error was found, false otherwise.
-func (m *Model) checkTransactionError(t *hotline.Transaction) bool {
+func (m *Model) checkTransactionError(c *hotline.Client, t *hotline.Transaction) bool {
 	if t.ErrorCode != [4]byte{0, 0, 0, 0} {
-		errorText := string(t.GetField(hotline.FieldError).Data)
+		errorText := m.sessionFor(c).decodeText(t.GetField(hotline.FieldError).Data)
 
 		// Check if this error should be ignored
 		for _, ignored := range ignoredErrorMessages {
 			if errorText == ignored {
+				m.reply(c, t, nil)
 				return true // Error exists but is ignored
 			}
 		}
 
-		m.program.Send(errorMsg{text: errorText})
+		m.reply(c, t, errorMsg{text: errorText})
 		return true
 	}
 	return false
 }
 
 func (m *Model) HandleKeepAlive(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
+	// Replies to our own keepalives measure the round-trip time
+	if t.IsReply == 1 {
+		if s := m.sessionFor(c); s != nil && s.health != nil {
+			if rtt, ok := s.health.pong(t.ID); ok {
+				m.send(c, keepAliveRTTMsg{rtt: rtt})
+			}
+		}
+	}
 	return res, err
 }
 
 func (m *Model) HandleTranServerMsg(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
+	s := m.sessionFor(c)
+	msg := strings.ReplaceAll(s.decodeText(t.GetField(hotline.FieldData).Data), "\r", "\n")
+	from := s.decodeText(t.GetField(hotline.FieldUserName).Data)
+	userIDField := t.GetField(hotline.FieldUserID)
+	var userID [2]byte
+	if len(userIDField.Data) >= 2 {
+		copy(userID[:], userIDField.Data[:2])
+	}
+
+	// Drop messages from ignored users without a sound or a modal
+	if s != nil && from != "" && s.ignores.ignores(userID, from) {
+		m.logger.Debug("Dropped private message from ignored user", "from", from)
+		return res, err
+	}
+
 	// Play sound for private message
 	if m.soundPlayer != nil {
 		m.soundPlayer.PlayAsync(SoundServerMsg)
@@ -52,25 +76,25 @@ func (m *Model) HandleTranServerMsg(ctx context.Context, c *hotline.Client, t *h
 
 	now := time.Now().Format(time.RFC850)
 
-	msg := strings.ReplaceAll(string(t.GetField(hotline.FieldData).Data), "\r", "\n")
-	from := string(t.GetField(hotline.FieldUserName).Data)
-	userIDField := t.GetField(hotline.FieldUserID)
-	var userID [2]byte
-	if len(userIDField.Data) >= 2 {
-		copy(userID[:], userIDField.Data[:2])
+	automatic := bytes.Equal(t.GetField(hotline.FieldOptions).Data, instantMsgAutoResponse)
+
+	// Automatic responses don't set off triggers, so two auto-responders can't loop
+	if !automatic {
+		m.checkTriggers(c, triggerEvent{source: triggerSourcePrivate, from: from, userID: userID, text: msg})
 	}
 
 	// Send message to Bubble Tea program to update UI
-	m.program.Send(serverMsgMsg{from: from, userID: userID, text: msg, time: now})
+	m.send(c, serverMsgMsg{from: from, userID: userID, text: msg, time: now, automatic: automatic})
 
 	return res, err
 }
 
 func (m *Model) HandleGetFileNameList(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		return nil, nil
 	}
 
+	s := m.sessionFor(c)
 	var files []hotline.FileNameWithInfo
 	for _, f := range t.Fields {
 		var fn hotline.FileNameWithInfo
@@ -78,24 +102,26 @@ func (m *Model) HandleGetFileNameList(ctx context.Context, c *hotline.Client, t
 		if err != nil {
 			continue
 		}
+		// Names are only displayed and sent back through encodeText, so keep them as UTF-8
+		fn.Name = []byte(s.decodeText(fn.Name))
 		files = append(files, fn)
 	}
 
-	m.program.Send(filesMsg{files: files})
+	m.reply(c, t, filesMsg{files: files})
 
 	return res, err
 }
 
 func (m *Model) TranGetMsgs(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		return nil, nil
 	}
 
-	messageBoardText := string(t.GetField(hotline.FieldData).Data)
+	messageBoardText := m.sessionFor(c).decodeText(t.GetField(hotline.FieldData).Data)
 	messageBoardText = strings.ReplaceAll(messageBoardText, "\r", "\n")
 
 	// Send message to Bubble Tea program to update UI
-	m.program.Send(messageBoardMsg{text: messageBoardText})
+	m.reply(c, t, messageBoardMsg{text: messageBoardText})
 
 	return res, err
 }
@@ -103,20 +129,26 @@ func (m *Model) TranGetMsgs(ctx context.Context, c *hotline.Client, t *hotline.T
 func (m *Model) HandleNotifyChangeUser(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
 	newUser := hotline.User{
 		ID:    [2]byte(t.GetField(hotline.FieldUserID).Data),
-		Name:  string(t.GetField(hotline.FieldUserName).Data),
+		Name:  m.sessionFor(c).decodeText(t.GetField(hotline.FieldUserName).Data),
 		Icon:  t.GetField(hotline.FieldUserIconID).Data,
 		Flags: t.GetField(hotline.FieldUserFlags).Data,
 	}
 
+	s := m.sessionFor(c)
+	if s == nil {
+		return res, err
+	}
+
 	var oldName string
 	var newUserList []hotline.User
 	updatedUser := false
+	ignored := s.ignores.ignores(newUser.ID, newUser.Name)
 
-	for _, u := range m.userList {
+	for _, u := range s.userList {
 		if newUser.ID == u.ID {
 			oldName = u.Name
-			if u.Name != newUser.Name {
-				m.program.Send(chatMsg{text: fmt.Sprintf(" <<< %s is now known as %s >>>", oldName, newUser.Name)})
+			if u.Name != newUser.Name && !ignored && !s.ignores.matchName(oldName) {
+				m.send(c, chatMsg{text: fmt.Sprintf(" <<< %s is now known as %s >>>", oldName, newUser.Name)})
 			}
 			u = newUser
 			updatedUser = true
@@ -126,17 +158,20 @@ func (m *Model) HandleNotifyChangeUser(ctx context.Context, c *hotline.Client, t
 
 	if !updatedUser {
 		newUserList = append(newUserList, newUser)
-		// Play sound for user joining
-		if m.soundPlayer != nil {
-			m.soundPlayer.PlayAsync(SoundUserJoin)
+
+		// Ignored users join quietly
+		if !ignored {
+			// Play sound for user joining
+			if m.soundPlayer != nil {
+				m.soundPlayer.PlayAsync(SoundUserJoin)
+			}
+			// Send join message to chat
+			m.send(c, chatMsg{text: style.NoticeStyle.Render(fmt.Sprintf("→ %s joined", newUser.Name))})
 		}
-		// Send join message to chat
-		joinStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("241"))
-		m.program.Send(chatMsg{text: joinStyle.Render(fmt.Sprintf("→ %s joined", newUser.Name))})
 	}
 
 	// Send message to Bubble Tea program to update UI
-	m.program.Send(userListMsg{users: newUserList})
+	m.send(c, userListMsg{users: newUserList})
 
 	return res, err
 }
@@ -144,17 +179,31 @@ func (m *Model) HandleNotifyChangeUser(ctx context.Context, c *hotline.Client, t
 func (m *Model) HandleNotifyDeleteUser(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
 	exitUser := t.GetField(hotline.FieldUserID).Data
 
+	s := m.sessionFor(c)
+	if s == nil {
+		return res, err
+	}
+
 	// Find the username before removing
 	var leavingUsername string
+	var leavingID [2]byte
 	var newUserList []hotline.User
-	for _, u := range m.userList {
+	for _, u := range s.userList {
 		if !bytes.Equal(exitUser, u.ID[:]) {
 			newUserList = append(newUserList, u)
 		} else {
 			leavingUsername = u.Name
+			leavingID = u.ID
 		}
 	}
 
+	// Ignored users leave quietly, and their ID may be reused by someone else
+	if leavingUsername != "" && s.ignores.ignores(leavingID, leavingUsername) {
+		s.ignores.setID(leavingID, false)
+		m.send(c, userListMsg{users: newUserList})
+		return res, err
+	}
+
 	// Play sound for user leaving
 	if m.soundPlayer != nil {
 		m.soundPlayer.PlayAsync(SoundUserLeave)
@@ -162,99 +211,128 @@ func (m *Model) HandleNotifyDeleteUser(ctx context.Context, c *hotline.Client, t
 
 	// Send leave message to chat
 	if leavingUsername != "" {
-		leaveStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("241"))
-		m.program.Send(chatMsg{text: leaveStyle.Render(fmt.Sprintf("← %s left", leavingUsername))})
+		m.send(c, chatMsg{text: style.NoticeStyle.Render(fmt.Sprintf("← %s left", leavingUsername))})
 	}
 
 	// Send message to Bubble Tea program to update UI
-	m.program.Send(userListMsg{users: newUserList})
+	m.send(c, userListMsg{users: newUserList})
 
 	return res, err
 }
 
 func (m *Model) HandleClientGetUserNameList(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		return nil, nil
 	}
 
-	var users []hotline.User
-	for _, field := range t.Fields {
-		if field.Type == hotline.FieldUsernameWithInfo {
-			var user hotline.User
-			if _, err := user.Write(field.Data); err != nil {
-				return res, fmt.Errorf("unable to read user data: %w", err)
-			}
-			users = append(users, user)
-		}
+	users, err := m.sessionFor(c).readUsers(t)
+	if err != nil {
+		return res, err
 	}
 
 	// Send message to Bubble Tea program to update UI
-	m.program.Send(userListMsg{users: users})
+	m.send(c, userListMsg{users: users})
 
 	return res, err
 }
 
 func (m *Model) HandleClientChatMsg(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	// Play sound for chat message
-	if m.soundPlayer != nil {
-		m.soundPlayer.PlayAsync(SoundChatMsg)
-	}
+	chatText := m.sessionFor(c).decodeText(t.GetField(hotline.FieldData).Data)
+	chatText = strings.ReplaceAll(chatText, "\r", "")
+	id := chatID(t)
 
-	// Terminal bell (independent of sounds)
-	if m.prefs.EnableBell {
-		fmt.Print("\a")
+	if s := m.sessionFor(c); s != nil && s.chatLineIgnored(chatText) {
+		if m.prefs.IgnoredChat == ignoredChatCollapse && !isPrivateChat(id) {
+			m.send(c, chatMsg{text: chatText, chatID: id, ignored: true})
+		}
+		return res, err
 	}
 
-	chatText := string(t.GetField(hotline.FieldData).Data)
-	chatText = strings.ReplaceAll(chatText, "\r", "")
+	// Mentions only apply to public chat
+	mention := !isPrivateChat(id) && m.highlights.match(chatText)
+	m.chatAlert(mention)
+
 	// Send message to Bubble Tea program to update UI
-	m.program.Send(chatMsg{text: chatText})
+	m.send(c, chatMsg{text: chatText, chatID: id, mention: mention})
+
+	if ev, ok := m.triggers.chatEvent(chatText, id); ok {
+		m.checkTriggers(c, ev)
+	}
 
 	return res, err
 }
 
 func (m *Model) HandleClientTranUserAccess(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		return nil, nil
 	}
 
-	copy(m.userAccess[:], t.GetField(hotline.FieldUserAccess).Data)
-	m.logger.Debug("Permissions", "bits", fmt.Sprintf("%b", m.userAccess))
+	s := m.sessionFor(c)
+	if s == nil {
+		return res, err
+	}
+
+	copy(s.userAccess[:], t.GetField(hotline.FieldUserAccess).Data)
+	m.logger.Debug("Permissions", "bits", fmt.Sprintf("%b", s.userAccess))
 
 	// Enable/disable keybinding depending on access.
-	if m.serverScreen != nil {
-		m.serverScreen.SetUserAccess(m.userAccess)
+	if s.serverScreen != nil {
+		s.serverScreen.SetUserAccess(s.userAccess)
 	}
-	if m.messageBoardScreen != nil {
-		m.messageBoardScreen.SetUserAccess(m.userAccess)
+	if s.messageBoardScreen != nil {
+		s.messageBoardScreen.SetUserAccess(s.userAccess)
 	}
 
 	return res, err
 }
 
+func (m *Model) HandleServerBanner(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
+	m.send(c, serverBannerMsg{
+		bannerType: string(t.GetField(hotline.FieldBannerType).Data),
+		url:        string(t.GetField(fieldBannerURL).Data),
+	})
+
+	return res, err
+}
+
 func (m *Model) HandleClientTranShowAgreement(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		return nil, nil
 	}
 
-	agreement := string(t.GetField(hotline.FieldData).Data)
+	agreement := m.sessionFor(c).decodeText(t.GetField(hotline.FieldData).Data)
 	agreement = strings.ReplaceAll(agreement, "\r", "\n")
 
 	// Show agreement modal with Agree/Disagree options
-	m.program.Send(agreementMsg{text: agreement})
+	m.send(c, agreementMsg{text: agreement})
 
 	return res, err
 }
 
 func (m *Model) HandleClientTranLogin(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	if m.checkTransactionError(t) {
+	m.sessionFor(c).loginReplied()
+
+	if m.checkTransactionError(c, t) {
 		return nil, errors.New("login error")
 	}
 
+	s := m.sessionFor(c)
+	if s == nil {
+		return res, err
+	}
+
+	info := serverInfo{name: m.sessionFor(c).decodeText(t.GetField(hotline.FieldServerName).Data)}
+	if v := t.GetField(hotline.FieldVersion).Data; len(v) == 2 {
+		info.version = binary.BigEndian.Uint16(v)
+	}
+	if id := t.GetField(hotline.FieldCommunityBannerID).Data; len(id) == 2 {
+		info.bannerID = binary.BigEndian.Uint16(id)
+	}
+
 	// Send server connected message with the name to display
-	m.program.Send(serverConnectedMsg{name: m.pendingServerName})
+	m.send(c, serverConnectedMsg{name: s.pendingServerName, info: info})
 
-	if err := c.Send(hotline.NewTransaction(hotline.TranGetUserNameList, [2]byte{})); err != nil {
+	if err := m.sessionFor(c).sendTransaction(hotline.NewTransaction(hotline.TranGetUserNameList, [2]byte{})); err != nil {
 		m.logger.Error("err", "err", err)
 	}
 
@@ -262,7 +340,7 @@ func (m *Model) HandleClientTranLogin(ctx context.Context, c *hotline.Client, t
 }
 
 func (m *Model) HandleDownloadFile(ctx context.Context, c *hotline.Client, t *hotline.Transaction) ([]hotline.Transaction, error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		return nil, nil
 	}
 
@@ -282,8 +360,7 @@ func (m *Model) HandleDownloadFile(ctx context.Context, c *hotline.Client, t *ho
 	var refNumBytes [4]byte
 	copy(refNumBytes[:], refNumField.Data)
 
-	m.program.Send(downloadReplyMsg{
-		txID:         t.ID,
+	m.reply(c, t, downloadReplyMsg{
 		refNum:       refNumBytes,
 		transferSize: transferSize,
 		fileSize:     fileSize,
@@ -293,7 +370,7 @@ func (m *Model) HandleDownloadFile(ctx context.Context, c *hotline.Client, t *ho
 }
 
 func (m *Model) HandleUploadFile(ctx context.Context, c *hotline.Client, t *hotline.Transaction) ([]hotline.Transaction, error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		return nil, nil
 	}
 
@@ -302,8 +379,7 @@ func (m *Model) HandleUploadFile(ctx context.Context, c *hotline.Client, t *hotl
 	var refNumBytes [4]byte
 	copy(refNumBytes[:], refNum)
 
-	m.program.Send(uploadReplyMsg{
-		txID:   t.ID,
+	m.reply(c, t, uploadReplyMsg{
 		refNum: refNumBytes,
 	})
 	m.logger.Info("Upload transaction ID", "id", t.ID)
@@ -312,13 +388,15 @@ func (m *Model) HandleUploadFile(ctx context.Context, c *hotline.Client, t *hotl
 }
 
 func (m *Model) HandleGetFileInfo(ctx context.Context, c *hotline.Client, t *hotline.Transaction) ([]hotline.Transaction, error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		return nil, nil
 	}
 
+	s := m.sessionFor(c)
+
 	// Extract file info fields from response
 	msg := fileInfoMsg{
-		fileName: string(t.GetField(hotline.FieldFileName).Data),
+		fileName: s.decodeText(t.GetField(hotline.FieldFileName).Data),
 	}
 
 	// Extract type and creator strings with nil checks
@@ -348,7 +426,7 @@ func (m *Model) HandleGetFileInfo(ctx context.Context, c *hotline.Client, t *hot
 	// Extract optional fields
 	commentField := t.GetField(hotline.FieldFileComment)
 	if commentField != nil && len(commentField.Data) > 0 {
-		msg.comment = string(commentField.Data)
+		msg.comment = s.decodeText(commentField.Data)
 	}
 
 	fileSizeField := t.GetField(hotline.FieldFileSize)
@@ -357,15 +435,16 @@ func (m *Model) HandleGetFileInfo(ctx context.Context, c *hotline.Client, t *hot
 		msg.hasFileSize = true
 	}
 
-	m.program.Send(msg)
+	m.reply(c, t, msg)
 	return nil, nil
 }
 
 func (m *Model) HandleGetNewsCatNameList(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		return nil, nil
 	}
 
+	s := m.sessionFor(c)
 	var categories []newsItem
 
 	// Parse category list data from transaction fields
@@ -383,24 +462,25 @@ func (m *Model) HandleGetNewsCatNameList(ctx context.Context, c *hotline.Client,
 			isBundle := bytes.Equal(catData.Type[:], hotline.NewsBundle[:])
 
 			categories = append(categories, newsItem{
-				name:     catData.Name,
+				name:     s.decodeText([]byte(catData.Name)),
 				isBundle: isBundle,
 			})
 		}
 	}
 
 	// Send categories to UI
-	m.program.Send(newsCategoriesMsg{categories: categories})
+	m.reply(c, t, newsCategoriesMsg{categories: categories})
 
 	return res, err
 }
 
 func (m *Model) HandleGetNewsArtNameList(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		return nil, nil
 	}
 
 	var articles []newsArticleItem
+	s := m.sessionFor(c)
 
 	// Get the NewsArtListData field
 	artListField := t.GetField(hotline.FieldNewsArtListData)
@@ -448,7 +528,7 @@ func (m *Model) HandleGetNewsArtNameList(ctx context.Context, c *hotline.Client,
 		if offset+titleLen > len(data) {
 			break
 		}
-		title := string(data[offset : offset+titleLen])
+		title := s.decodeText(data[offset : offset+titleLen])
 		offset += titleLen
 
 		// Read poster (1 byte length + data)
@@ -460,7 +540,7 @@ func (m *Model) HandleGetNewsArtNameList(ctx context.Context, c *hotline.Client,
 		if offset+posterLen > len(data) {
 			break
 		}
-		poster := string(data[offset : offset+posterLen])
+		poster := s.decodeText(data[offset : offset+posterLen])
 		offset += posterLen
 
 		// Skip flavor text (1 byte length + data) and article size (2 bytes)
@@ -483,25 +563,27 @@ func (m *Model) HandleGetNewsArtNameList(ctx context.Context, c *hotline.Client,
 			depth:       0,
 			isExpanded:  false,
 			hasChildren: false,
+			dimmed:      m.prefs.DimIgnoredNews && s != nil && s.ignores.matchName(poster),
 		})
 	}
 
 	// Send articles to UI
-	m.program.Send(newsArticlesMsg{articles: articles})
+	m.reply(c, t, newsArticlesMsg{articles: articles})
 
 	return res, err
 }
 
 func (m *Model) HandleGetNewsArtData(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		return nil, nil
 	}
 
 	// Parse article data from transaction fields
+	s := m.sessionFor(c)
 	var article hotline.NewsArtData
-	article.Title = string(t.GetField(hotline.FieldNewsArtTitle).Data)
-	article.Poster = string(t.GetField(hotline.FieldNewsArtPoster).Data)
-	article.Data = string(t.GetField(hotline.FieldNewsArtData).Data)
+	article.Title = s.decodeText(t.GetField(hotline.FieldNewsArtTitle).Data)
+	article.Poster = s.decodeText(t.GetField(hotline.FieldNewsArtPoster).Data)
+	article.Data = s.decodeText(t.GetField(hotline.FieldNewsArtData).Data)
 	article.Data = strings.ReplaceAll(article.Data, "\r", "\n")
 
 	dateField := t.GetField(hotline.FieldNewsArtDate)
@@ -510,13 +592,13 @@ func (m *Model) HandleGetNewsArtData(ctx context.Context, c *hotline.Client, t *
 	}
 
 	// Send article to UI
-	m.program.Send(newsArticleDataMsg{article: article})
+	m.reply(c, t, newsArticleDataMsg{article: article})
 
 	return res, err
 }
 
 func (m *Model) HandlePostNewsArt(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		m.logger.Error("Error posting news article")
 		return nil, nil
 	}
@@ -526,7 +608,7 @@ func (m *Model) HandlePostNewsArt(ctx context.Context, c *hotline.Client, t *hot
 }
 
 func (m *Model) HandleNewNewsFldr(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		m.logger.Error("Error creating news bundle")
 		return nil, nil
 	}
@@ -536,7 +618,7 @@ func (m *Model) HandleNewNewsFldr(ctx context.Context, c *hotline.Client, t *hot
 }
 
 func (m *Model) HandleNewNewsCat(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		m.logger.Error("Error creating news category")
 		return nil, nil
 	}
@@ -553,12 +635,12 @@ func (m *Model) HandleNewMsg(ctx context.Context, c *hotline.Client, t *hotline.
 }
 
 func (m *Model) HandleTranAgreed(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		return nil, nil
 	}
 
-	m.NavigateTo(ScreenServerUI)
-	if err := c.Send(hotline.NewTransaction(hotline.TranGetUserNameList, [2]byte{})); err != nil {
+	m.send(c, serverAgreedMsg{})
+	if err := m.sessionFor(c).sendTransaction(hotline.NewTransaction(hotline.TranGetUserNameList, [2]byte{})); err != nil {
 		m.logger.Error("err", "err", err)
 	}
 
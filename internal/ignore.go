@@ -0,0 +1,320 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jhalter/mobius/hotline"
+)
+
+// IgnoredChat values choose what happens to chat from ignored users
+const (
+	ignoredChatHide     = "hide"     // Drop the lines (default)
+	ignoredChatCollapse = "collapse" // Replace runs of lines with a count
+)
+
+// ignoredStyle marks ignored users in the user list and collapsed chat
+var ignoredStyle = lipgloss.NewStyle().Faint(true).Strikethrough(true)
+
+// ignoreList decides whose chat, private messages and notices a session hides.
+// It is read from the transaction handler goroutine and edited from the UI.
+type ignoreList struct {
+	mu       sync.RWMutex
+	patterns []string         // Name patterns from the settings and the bookmark
+	ids      map[[2]byte]bool // Users ignored for this connection only
+}
+
+func newIgnoreList() *ignoreList {
+	return &ignoreList{ids: make(map[[2]byte]bool)}
+}
+
+// setPatterns replaces the name patterns
+func (l *ignoreList) setPatterns(patterns []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.patterns = patterns
+}
+
+// clearIDs forgets the ignored user IDs, which the server reassigns on reconnect
+func (l *ignoreList) clearIDs() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ids = make(map[[2]byte]bool)
+}
+
+// setID ignores or stops ignoring a user for this connection
+func (l *ignoreList) setID(id [2]byte, ignored bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if ignored {
+		l.ids[id] = true
+	} else {
+		delete(l.ids, id)
+	}
+}
+
+// hasID reports whether a user is ignored for this connection
+func (l *ignoreList) hasID(id [2]byte) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.ids[id]
+}
+
+// matchName reports whether a name matches one of the patterns
+func (l *ignoreList) matchName(name string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, p := range l.patterns {
+		if matchIgnorePattern(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignores reports whether a user is ignored by ID or name
+func (l *ignoreList) ignores(id [2]byte, name string) bool {
+	return l.hasID(id) || l.matchName(name)
+}
+
+// matchIgnorePattern matches a name against a shell pattern such as "spam*",
+// ignoring case
+func matchIgnorePattern(pattern, name string) bool {
+	ok, err := path.Match(strings.ToLower(pattern), strings.ToLower(name))
+	return err == nil && ok
+}
+
+// validIgnorePattern checks that a pattern can be matched
+func validIgnorePattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return errors.New("empty pattern")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q", pattern)
+	}
+	return nil
+}
+
+// parseIgnorePatterns splits a comma separated list of patterns
+func parseIgnorePatterns(s string) ([]string, error) {
+	var patterns []string
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if err := validIgnorePattern(p); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// chatLineIgnored reports whether a public or private chat line was said by an
+// ignored user. Chat lines carry only the speaker's name, cut to 13 characters,
+// so it is matched against the user list to find their ID.
+func (s *Session) chatLineIgnored(line string) bool {
+	line = strings.TrimSpace(line)
+	if speaker, _, ok := strings.Cut(line, ":  "); ok {
+		speaker = strings.TrimSpace(speaker)
+		if s.ignores.matchName(speaker) {
+			return true
+		}
+		for _, u := range s.userList {
+			if chatName(u.Name) == speaker && s.ignores.ignores(u.ID, u.Name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Emotes are "*** name action"
+	if emote, ok := strings.CutPrefix(line, "*** "); ok {
+		for _, u := range s.userList {
+			if strings.HasPrefix(emote, u.Name+" ") && s.ignores.ignores(u.ID, u.Name) {
+				return true
+			}
+		}
+		name, _, _ := strings.Cut(emote, " ")
+		return s.ignores.matchName(name)
+	}
+	return false
+}
+
+// ignorePatterns returns the global patterns followed by those of the bookmark
+// used for a connection
+func (m *Model) ignorePatterns(params *connectionParams) []string {
+	patterns := slices.Clone(m.prefs.Ignore)
+	if bm := m.connectionBookmark(params); bm != nil {
+		patterns = append(patterns, bm.Ignore...)
+	}
+	return patterns
+}
+
+// connectionBookmark returns the bookmark a connection was made from, or nil
+func (m *Model) connectionBookmark(params *connectionParams) *Bookmark {
+	if params == nil || params.bookmark == "" {
+		return nil
+	}
+	for i, bm := range m.prefs.Bookmarks {
+		if bm.Name == params.bookmark && bm.Addr == params.addr {
+			return &m.prefs.Bookmarks[i]
+		}
+	}
+	return nil
+}
+
+// refreshIgnores applies edited patterns to every session
+func (m *Model) refreshIgnores() {
+	for _, s := range m.sessions {
+		params := s.activeConnection
+		if params == nil {
+			params = s.pendingConnection
+		}
+		s.ignores.setPatterns(m.ignorePatterns(params))
+	}
+}
+
+// toggleIgnoreUser ignores or stops ignoring an online user for this connection
+func (m *Model) toggleIgnoreUser(u hotline.User) {
+	ignored := !m.ignores.hasID(u.ID)
+	m.ignores.setID(u.ID, ignored)
+	if ignored {
+		m.chatNotice(fmt.Sprintf("Ignoring %s until you disconnect", u.Name))
+	} else {
+		m.chatNotice(fmt.Sprintf("No longer ignoring %s", u.Name))
+	}
+}
+
+// handleServerToggleIgnoreMsg ignores the user selected in the user list
+func (m *Model) handleServerToggleIgnoreMsg(msg ServerToggleIgnoreMsg) {
+	for _, u := range m.userList {
+		if u.ID == msg.TargetUserID {
+			m.toggleIgnoreUser(u)
+			return
+		}
+	}
+}
+
+// chatCmdIgnore ignores an online user until we disconnect, or with -global or
+// -bookmark saves a name pattern to the settings or the current bookmark
+func (m *Model) chatCmdIgnore(args string) error {
+	if args == "" {
+		m.listIgnores()
+		return nil
+	}
+
+	scope, pattern, _ := strings.Cut(args, " ")
+	pattern = strings.TrimSpace(pattern)
+	switch scope {
+	case "-global", "-bookmark":
+		if err := validIgnorePattern(pattern); err != nil {
+			return errChatCommandUsage
+		}
+		list := &m.prefs.Ignore
+		if scope == "-bookmark" {
+			bm := m.connectionBookmark(m.activeConnection)
+			if bm == nil {
+				return errors.New("not connected from a bookmark")
+			}
+			list = &bm.Ignore
+		}
+		if slices.ContainsFunc(*list, func(p string) bool { return strings.EqualFold(p, pattern) }) {
+			return fmt.Errorf("%q is already ignored", pattern)
+		}
+		*list = append(*list, pattern)
+		m.saveIgnores()
+		m.chatNotice(fmt.Sprintf("Ignoring names matching %q", pattern))
+		return nil
+	}
+
+	u, rest, err := m.cutUserArg(args)
+	if err != nil {
+		return err
+	}
+	if rest != "" {
+		return errChatCommandUsage
+	}
+	if m.ignores.ignores(u.ID, u.Name) {
+		return fmt.Errorf("%s is already ignored", u.Name)
+	}
+	m.toggleIgnoreUser(u)
+	return nil
+}
+
+// chatCmdUnignore stops ignoring an online user and removes a matching pattern
+// from the settings and the current bookmark
+func (m *Model) chatCmdUnignore(args string) error {
+	if args == "" {
+		return errChatCommandUsage
+	}
+
+	var found bool
+	if u, ok := m.findUser(args); ok && m.ignores.hasID(u.ID) {
+		m.toggleIgnoreUser(u)
+		found = true
+	}
+
+	lists := []*[]string{&m.prefs.Ignore}
+	if bm := m.connectionBookmark(m.activeConnection); bm != nil {
+		lists = append(lists, &bm.Ignore)
+	}
+	var removed bool
+	for _, list := range lists {
+		n := len(*list)
+		*list = slices.DeleteFunc(*list, func(p string) bool { return strings.EqualFold(p, args) })
+		removed = removed || len(*list) != n
+	}
+	if removed {
+		m.saveIgnores()
+		m.chatNotice(fmt.Sprintf("No longer ignoring names matching %q", args))
+		found = true
+	}
+
+	if !found {
+		return fmt.Errorf("%q is not ignored", args)
+	}
+	return nil
+}
+
+// listIgnores shows the ignored users and patterns in the chat pane
+func (m *Model) listIgnores() {
+	var users []string
+	for _, u := range m.userList {
+		if m.ignores.hasID(u.ID) {
+			users = append(users, u.Name)
+		}
+	}
+	var bookmark []string
+	if bm := m.connectionBookmark(m.activeConnection); bm != nil {
+		bookmark = bm.Ignore
+	}
+
+	if len(users) == 0 && len(m.prefs.Ignore) == 0 && len(bookmark) == 0 {
+		m.chatNotice("Nobody is ignored")
+		return
+	}
+	if len(users) > 0 {
+		m.chatNotice("Ignored until you disconnect: " + strings.Join(users, ", "))
+	}
+	if len(m.prefs.Ignore) > 0 {
+		m.chatNotice("Ignored everywhere: " + strings.Join(m.prefs.Ignore, ", "))
+	}
+	if len(bookmark) > 0 {
+		m.chatNotice("Ignored on this bookmark: " + strings.Join(bookmark, ", "))
+	}
+}
+
+// saveIgnores applies and saves edited ignore patterns
+func (m *Model) saveIgnores() {
+	m.refreshIgnores()
+	if err := m.savePreferences(); err != nil {
+		m.logger.Error("Failed to save preferences", "err", err)
+	}
+}
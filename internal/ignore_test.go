@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jhalter/mobius/hotline"
+)
+
+func TestMatchIgnorePattern(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{pattern: "spammer", name: "spammer", want: true},
+		{pattern: "spammer", name: "SPAMMER", want: true},
+		{pattern: "Spam*", name: "spambot 3000", want: true},
+		{pattern: "spam*", name: "a spambot"},
+		{pattern: "*bot", name: "Helper Bot", want: true},
+		{pattern: "guest?", name: "guest7", want: true},
+		{pattern: "guest?", name: "guest42"},
+		{pattern: "[ab]nn", name: "Ann", want: true},
+		{pattern: "[", name: "["},
+		{pattern: "a/*", name: "a/b", want: true},
+	}
+	for _, tt := range tests {
+		if got := matchIgnorePattern(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchIgnorePattern(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseIgnorePatterns(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+		err  bool
+	}{
+		{in: ""},
+		{in: "spam*, guest? ,,bob", want: []string{"spam*", "guest?", "bob"}},
+		{in: "ok, [", err: true},
+	}
+	for _, tt := range tests {
+		got, err := parseIgnorePatterns(tt.in)
+		if (err != nil) != tt.err || !slices.Equal(got, tt.want) {
+			t.Errorf("parseIgnorePatterns(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestChatLineIgnored(t *testing.T) {
+	s := &Session{ignores: newIgnoreList()}
+	s.ignores.setPatterns([]string{"spam*"})
+	s.userList = []hotline.User{
+		{ID: [2]byte{0, 1}, Name: "bob"},
+		{ID: [2]byte{0, 2}, Name: "Mallory the Magnificent"},
+		{ID: [2]byte{0, 3}, Name: "Bob Smith"},
+	}
+	s.ignores.setID([2]byte{0, 2}, true)
+
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{line: "\r          bob:  hello", want: false},
+		{line: "\r      spambot:  buy now", want: true},
+		{line: "\rMallory the M:  hi", want: true}, // Name cut to 13 characters
+		{line: "\r *** Mallory the Magnificent waves", want: true},
+		{line: "\r *** spambot waves", want: true},
+		{line: "\r *** Bob Smith waves", want: false},
+		{line: "\r *** bob waves", want: false},
+		{line: "a notice without a speaker", want: false},
+	}
+	for _, tt := range tests {
+		if got := s.chatLineIgnored(tt.line); got != tt.want {
+			t.Errorf("chatLineIgnored(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+
+	// IDs are per connection; patterns outlast them
+	s.ignores.clearIDs()
+	if s.chatLineIgnored("\rMallory the M:  hi") {
+		t.Error("ignored by ID after the IDs were cleared")
+	}
+	if !s.chatLineIgnored("\r      spambot:  buy now") {
+		t.Error("pattern dropped with the IDs")
+	}
+}
+
+func TestIgnorePatternsForBookmark(t *testing.T) {
+	m, _ := newTestModel(t, &Settings{
+		Ignore: []string{"spam*"},
+		Bookmarks: []Bookmark{
+			{Name: "Home", Addr: "home.example.com:5500", Ignore: []string{"troll"}},
+		},
+	})
+
+	tests := []struct {
+		params *connectionParams
+		want   []string
+	}{
+		{params: nil, want: []string{"spam*"}},
+		{params: &connectionParams{addr: "home.example.com:5500"}, want: []string{"spam*"}},
+		{params: &connectionParams{addr: "home.example.com:5500", bookmark: "Home"}, want: []string{"spam*", "troll"}},
+		{params: &connectionParams{addr: "other.example.com:5500", bookmark: "Home"}, want: []string{"spam*"}},
+	}
+	for _, tt := range tests {
+		if got := m.ignorePatterns(tt.params); !slices.Equal(got, tt.want) {
+			t.Errorf("ignorePatterns(%+v) = %q, want %q", tt.params, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxInputHistory is how many lines are kept for each server
+const maxInputHistory = 200
+
+// InputHistory stores the lines typed into the chat input, keyed by server "host:port"
+type InputHistory struct {
+	path    string
+	mu      sync.Mutex
+	servers map[string][]string // Oldest line first
+}
+
+// defaultInputHistoryPath returns the input history file stored next to the config file
+func defaultInputHistoryPath(cfgPath string) string {
+	return filepath.Join(filepath.Dir(cfgPath), "mobius-client-history.yaml")
+}
+
+// LoadInputHistory reads the input history file, treating a missing file as empty
+func LoadInputHistory(path string) (*InputHistory, error) {
+	h := &InputHistory{path: path, servers: make(map[string][]string)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	if err := yaml.Unmarshal(data, &h.servers); err != nil {
+		return h, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if h.servers == nil {
+		h.servers = make(map[string][]string)
+	}
+	return h, nil
+}
+
+// Lines returns a copy of a server's history, oldest first
+func (h *InputHistory) Lines(server string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.servers[server])
+}
+
+// Add appends a line to a server's history and saves the store. Blank lines and
+// repeats of the previous line are skipped.
+func (h *InputHistory) Add(server, line string) error {
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	lines := h.servers[server]
+	if len(lines) > 0 && lines[len(lines)-1] == line {
+		return nil
+	}
+	lines = append(lines, line)
+	if len(lines) > maxInputHistory {
+		lines = slices.Clone(lines[len(lines)-maxInputHistory:])
+	}
+	h.servers[server] = lines
+
+	out, err := yaml.Marshal(h.servers)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(h.path, out, 0600)
+}
+
+// inputHistory returns the active server's input history, oldest first
+func (m *Model) inputHistory() []string {
+	if m.activeConnection == nil || m.history == nil {
+		return nil
+	}
+	return m.history.Lines(m.activeConnection.addr)
+}
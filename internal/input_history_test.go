@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestInputHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.yaml")
+	h, err := LoadInputHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"hello", "hello", "  ", "/away lunch", "hello"} {
+		if err := h.Add("a.example.com:5500", line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Add("b.example.com:5500", "other"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Saved and reloaded, per server
+	reloaded, err := LoadInputHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := reloaded.Lines("a.example.com:5500"), []string{"hello", "/away lunch", "hello"}; !slices.Equal(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+	if got := reloaded.Lines("b.example.com:5500"); !slices.Equal(got, []string{"other"}) {
+		t.Errorf("other server's lines = %q", got)
+	}
+
+	// Only the newest lines are kept
+	for i := range maxInputHistory + 5 {
+		if err := h.Add("a.example.com:5500", fmt.Sprintf("line %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := h.Lines("a.example.com:5500")
+	if len(lines) != maxInputHistory || lines[0] != "line 5" {
+		t.Errorf("kept %d lines from %q, want %d from line 5", len(lines), lines[0], maxInputHistory)
+	}
+}
+
+func TestRecallHistory(t *testing.T) {
+	h, err := LoadInputHistory(filepath.Join(t.TempDir(), "history.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"first", "second"} {
+		if err := h.Add("a.example.com:5500", line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, _ := newTestModel(t, &Settings{})
+	m.history = h
+	m.activeConnection = &connectionParams{addr: "a.example.com:5500"}
+	s := NewServerScreen(m)
+	s.chatInput.SetValue("draft")
+
+	steps := []struct {
+		delta int
+		want  string
+	}{
+		{delta: -1, want: "second"},
+		{delta: -1, want: "first"},
+		{delta: -1, want: "first"}, // Already at the oldest
+		{delta: 1, want: "second"},
+		{delta: 1, want: "draft"}, // Past the newest, back to what was typed
+		{delta: 1, want: "draft"},
+		{delta: -1, want: "second"},
+	}
+	for i, step := range steps {
+		s.recallHistory(step.delta)
+		if got := s.chatInput.Value(); got != step.want {
+			t.Errorf("step %d: input = %q, want %q", i+1, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jhalter/mobius/hotline"
+)
+
+const (
+	defaultKeepAliveInterval  = 60 * time.Second
+	defaultKeepAliveMaxMissed = 3
+
+	// keepAliveFirstPing delays the first keepalive so it doesn't race the login
+	keepAliveFirstPing = 5 * time.Second
+)
+
+// keepAliveInterval returns the configured time between keepalives
+func (cp *Settings) keepAliveInterval() time.Duration {
+	if cp.KeepAliveInterval <= 0 {
+		return defaultKeepAliveInterval
+	}
+	return time.Duration(cp.KeepAliveInterval) * time.Second
+}
+
+// keepAliveMaxMissed returns how many unanswered keepalives mark the connection dead
+func (cp *Settings) keepAliveMaxMissed() int {
+	if cp.KeepAliveMaxMissed <= 0 {
+		return defaultKeepAliveMaxMissed
+	}
+	return cp.KeepAliveMaxMissed
+}
+
+// connHealth tracks the keepalives sent on a connection and their replies.
+// It is shared by the keepalive loop and the transaction handler goroutine.
+type connHealth struct {
+	mu           sync.Mutex
+	sent         map[[4]byte]time.Time // Unanswered keepalives by transaction ID
+	missed       int                   // Consecutive keepalives without a reply
+	answered     bool                  // The server has replied to a keepalive
+	waitForReply bool                  // Don't count misses until answered
+}
+
+func newConnHealth(waitForReply bool) *connHealth {
+	return &connHealth{sent: make(map[[4]byte]time.Time), waitForReply: waitForReply}
+}
+
+// ping records a keepalive about to be sent. It returns false once maxMissed
+// keepalives in a row have gone unanswered. With waitForReply, a server that
+// has never answered one is not counted against.
+func (h *connHealth) ping(id [4]byte, maxMissed int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.sent) > 0 && (h.answered || !h.waitForReply) {
+		h.missed++
+		if h.missed >= maxMissed {
+			return false
+		}
+	}
+	h.sent[id] = time.Now()
+	return true
+}
+
+// pong records the reply to a keepalive and returns the round-trip time
+func (h *connHealth) pong(id [4]byte) (time.Duration, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sentAt, ok := h.sent[id]
+	if !ok {
+		return 0, false
+	}
+	// Earlier keepalives are superseded by this reply
+	clear(h.sent)
+	h.missed = 0
+	h.answered = true
+	return time.Since(sentAt), true
+}
+
+// keepAliveRTTMsg reports the latest keepalive round-trip time
+type keepAliveRTTMsg struct {
+	rtt time.Duration
+}
+
+// monitorConnection sends keepalives until ctx is cancelled. If the server stops
+// answering, the connection is closed, which triggers the normal disconnect flow.
+func (m *Model) monitorConnection(ctx context.Context, s *Session, h *connHealth) {
+	interval := m.prefs.keepAliveInterval()
+	maxMissed := m.prefs.keepAliveMaxMissed()
+
+	timer := time.NewTimer(keepAliveFirstPing)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		t := hotline.NewTransaction(hotline.TranKeepAlive, [2]byte{})
+		if !h.ping(t.ID, maxMissed) {
+			m.logger.Error("Server stopped answering keepalives; closing connection", "server", s.hlClient.Connection.RemoteAddr(), "missed", maxMissed)
+			_ = s.hlClient.Disconnect()
+			return
+		}
+		if err := s.sendTransaction(t); err != nil {
+			m.logger.Error("Error sending keepalive", "err", err)
+		}
+		timer.Reset(interval)
+	}
+}
+
+func (m *Model) handleKeepAliveRTTMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	rttMsg := msg.(keepAliveRTTMsg)
+	if m.serverScreen != nil {
+		m.serverScreen.SetRTT(rttMsg.rtt)
+	}
+	return m, nil
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhalter/mobius/hotline"
+)
+
+func TestConnHealthPong(t *testing.T) {
+	h := newConnHealth(false)
+	first, second := [4]byte{0, 0, 0, 1}, [4]byte{0, 0, 0, 2}
+
+	if _, ok := h.pong(first); ok {
+		t.Error("pong accepted a keepalive that was never sent")
+	}
+	if !h.ping(first, 3) || !h.ping(second, 3) {
+		t.Fatal("ping failed before any were missed")
+	}
+	if h.missed != 1 {
+		t.Errorf("missed = %d after one unanswered keepalive, want 1", h.missed)
+	}
+
+	// A reply to the latest keepalive answers the earlier ones too
+	if rtt, ok := h.pong(second); !ok || rtt < 0 {
+		t.Fatalf("pong = %v, %v; want a round-trip time", rtt, ok)
+	}
+	if h.missed != 0 || len(h.sent) != 0 || !h.answered {
+		t.Errorf("after pong missed = %d, sent = %d, answered = %v; want 0, 0, true", h.missed, len(h.sent), h.answered)
+	}
+	if _, ok := h.pong(first); ok {
+		t.Error("pong accepted a superseded keepalive")
+	}
+}
+
+func TestConnHealthMissed(t *testing.T) {
+	h := newConnHealth(false)
+
+	// The first ping has nothing outstanding; each later one counts a miss
+	for i := range 3 {
+		if !h.ping([4]byte{0, 0, 0, byte(i)}, 3) {
+			t.Fatalf("ping %d failed with %d missed", i+1, h.missed)
+		}
+	}
+	if h.ping([4]byte{0, 0, 0, 3}, 3) {
+		t.Error("ping succeeded after 3 keepalives went unanswered")
+	}
+}
+
+func TestConnHealthWaitForReply(t *testing.T) {
+	h := newConnHealth(true)
+
+	// A server that never answers keepalives isn't counted against
+	for i := range 10 {
+		if !h.ping([4]byte{0, 0, 0, byte(i)}, 3) {
+			t.Fatalf("ping %d failed before the server ever answered", i+1)
+		}
+	}
+
+	// Once it has answered, misses count as usual
+	if _, ok := h.pong([4]byte{0, 0, 0, 9}); !ok {
+		t.Fatal("pong rejected the latest keepalive")
+	}
+	results := []bool{
+		h.ping([4]byte{0, 0, 1, 0}, 2),
+		h.ping([4]byte{0, 0, 1, 1}, 2),
+		h.ping([4]byte{0, 0, 1, 2}, 2),
+	}
+	if !results[0] || !results[1] || results[2] {
+		t.Errorf("pings after an answer = %v, want true, true, false", results)
+	}
+}
+
+func TestKeepAliveSettings(t *testing.T) {
+	tests := []struct {
+		prefs     Settings
+		interval  time.Duration
+		maxMissed int
+	}{
+		{prefs: Settings{}, interval: defaultKeepAliveInterval, maxMissed: defaultKeepAliveMaxMissed},
+		{prefs: Settings{KeepAliveInterval: -1, KeepAliveMaxMissed: -1}, interval: defaultKeepAliveInterval, maxMissed: defaultKeepAliveMaxMissed},
+		{prefs: Settings{KeepAliveInterval: 15, KeepAliveMaxMissed: 5}, interval: 15 * time.Second, maxMissed: 5},
+	}
+	for _, tt := range tests {
+		if got := tt.prefs.keepAliveInterval(); got != tt.interval {
+			t.Errorf("keepAliveInterval(%d) = %v, want %v", tt.prefs.KeepAliveInterval, got, tt.interval)
+		}
+		if got := tt.prefs.keepAliveMaxMissed(); got != tt.maxMissed {
+			t.Errorf("keepAliveMaxMissed(%d) = %d, want %d", tt.prefs.KeepAliveMaxMissed, got, tt.maxMissed)
+		}
+	}
+}
+
+func TestHandleKeepAliveReply(t *testing.T) {
+	m, s := newTestModel(t, &Settings{})
+	s.hlClient = &hotline.Client{}
+	s.health = newConnHealth(false)
+	id := [4]byte{0, 0, 0, 1}
+	s.health.ping(id, 3)
+
+	// Keepalives from the server aren't answers to ours
+	if _, err := m.HandleKeepAlive(context.Background(), s.hlClient, &hotline.Transaction{ID: id}); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.health.sent) != 1 {
+		t.Fatal("a request from the server counted as a reply")
+	}
+
+	if _, err := m.HandleKeepAlive(context.Background(), s.hlClient, &hotline.Transaction{IsReply: 1, ID: id}); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.health.sent) != 0 || !s.health.answered {
+		t.Error("the reply didn't answer the outstanding keepalive")
+	}
+}
+
+func TestServerScreenRTT(t *testing.T) {
+	m, _ := newTestModel(t, &Settings{})
+	screen := NewServerScreen(m)
+	screen.SetServerName("Example")
+	if title := screen.title(); strings.Contains(title, "(") {
+		t.Errorf("title = %q before any round trip was measured", title)
+	}
+
+	m.serverScreen = screen
+	m.handleKeepAliveRTTMsg(keepAliveRTTMsg{rtt: 42*time.Millisecond + 300*time.Microsecond})
+	if title := screen.title(); !strings.Contains(title, "Example (42ms)") {
+		t.Errorf("title = %q, want the rounded round-trip time", title)
+	}
+}
@@ -0,0 +1,233 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KnownHosts is a trust-on-first-use store of pinned TLS certificate
+// fingerprints, keyed by server "host:port"
+type KnownHosts struct {
+	path  string
+	mu    sync.Mutex
+	hosts map[string]string
+}
+
+// defaultKnownHostsPath returns the known hosts file stored next to the config file
+func defaultKnownHostsPath(cfgPath string) string {
+	return filepath.Join(filepath.Dir(cfgPath), "mobius-client-known-hosts.yaml")
+}
+
+// LoadKnownHosts reads the known hosts file, treating a missing file as empty
+func LoadKnownHosts(path string) (*KnownHosts, error) {
+	k := &KnownHosts{path: path, hosts: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return k, nil
+	}
+	if err != nil {
+		return k, err
+	}
+	if err := yaml.Unmarshal(data, &k.hosts); err != nil {
+		return k, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if k.hosts == nil {
+		k.hosts = make(map[string]string)
+	}
+	return k, nil
+}
+
+// Lookup returns the pinned fingerprint for a host
+func (k *KnownHosts) Lookup(hostKey string) (string, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	fp, ok := k.hosts[hostKey]
+	return fp, ok
+}
+
+// Pin records the fingerprint for a host and saves the store
+func (k *KnownHosts) Pin(hostKey, fingerprint string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.hosts[hostKey] = fingerprint
+
+	out, err := yaml.Marshal(k.hosts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(k.path, out, 0600)
+}
+
+// certFingerprint returns the SHA-256 fingerprint of a certificate as colon separated hex
+func certFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return "SHA256:" + strings.Join(parts, ":")
+}
+
+// unknownCertError is returned when a server presents a certificate that has not been pinned yet
+type unknownCertError struct {
+	hostKey     string
+	fingerprint string
+}
+
+func (e *unknownCertError) Error() string {
+	return fmt.Sprintf("the certificate for %s is not in known hosts", e.hostKey)
+}
+
+// pendingTrust holds a connection waiting for the user to accept an unknown certificate
+type pendingTrust struct {
+	cert   *unknownCertError
+	params connectionParams
+}
+
+// certMismatchError is returned when a server's certificate differs from the pinned one
+type certMismatchError struct {
+	hostKey  string
+	expected string
+	actual   string
+}
+
+func (e *certMismatchError) Error() string {
+	return fmt.Sprintf(
+		"the certificate for %s does not match the pinned fingerprint; refusing to connect.\n\nExpected: %s\nReceived: %s\n\nIf the server's certificate was changed on purpose, remove its entry from the known hosts file.",
+		e.hostKey, e.expected, e.actual,
+	)
+}
+
+// isCertError reports whether err was caused by certificate verification
+func isCertError(err error) bool {
+	var unknown *unknownCertError
+	var mismatch *certMismatchError
+	var invalid x509.CertificateInvalidError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	return errors.As(err, &unknown) || errors.As(err, &mismatch) ||
+		errors.As(err, &invalid) || errors.As(err, &unknownAuthority) || errors.As(err, &hostname)
+}
+
+// certVerifier checks a server certificate either by chain verification or by
+// comparing it with the fingerprint pinned in the known hosts store
+type certVerifier struct {
+	knownHosts  *KnownHosts
+	hostKey     string         // Known hosts key, the control connection's "host:port"
+	serverName  string         // Hostname used for SNI and chain verification
+	chain       bool           // Require normal chain verification instead of pinning
+	roots       *x509.CertPool // Custom CA bundle, nil for the system roots
+	pin         string         // Required fingerprint, used for transfer connections
+	fingerprint string         // Fingerprint of the last verified certificate
+}
+
+// newCertVerifier builds the verifier for a connection to addr
+func (m *Model) newCertVerifier(addr string, p connectionParams) (*certVerifier, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	v := &certVerifier{
+		knownHosts: m.knownHosts,
+		hostKey:    addr,
+		serverName: host,
+		chain:      p.tlsStrict || p.tlsCAFile != "",
+	}
+
+	if p.tlsCAFile != "" {
+		pem, err := os.ReadFile(p.tlsCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		v.roots = x509.NewCertPool()
+		if !v.roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", p.tlsCAFile)
+		}
+	}
+
+	return v, nil
+}
+
+// forTransfer returns a verifier for the transfer connection that requires the
+// same certificate the control connection was verified with
+func (v *certVerifier) forTransfer() *certVerifier {
+	return &certVerifier{
+		knownHosts: v.knownHosts,
+		hostKey:    v.hostKey,
+		serverName: v.serverName,
+		chain:      v.chain,
+		roots:      v.roots,
+		pin:        v.fingerprint,
+	}
+}
+
+// tlsConfig returns a TLS config that runs the verifier during the handshake.
+// InsecureSkipVerify only disables the built-in checks; VerifyConnection replaces them.
+func (v *certVerifier) tlsConfig() *tls.Config {
+	return &tls.Config{
+		ServerName:         v.serverName,
+		InsecureSkipVerify: true,
+		VerifyConnection:   v.verify,
+	}
+}
+
+func (v *certVerifier) verify(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("server did not present a certificate")
+	}
+	leaf := cs.PeerCertificates[0]
+	fingerprint := certFingerprint(leaf)
+
+	if v.chain {
+		intermediates := x509.NewCertPool()
+		for _, cert := range cs.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+		if _, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         v.roots,
+			DNSName:       v.serverName,
+			Intermediates: intermediates,
+		}); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case v.pin != "":
+		if fingerprint != v.pin {
+			return &certMismatchError{hostKey: v.hostKey, expected: v.pin, actual: fingerprint}
+		}
+	case !v.chain:
+		known, ok := v.knownHosts.Lookup(v.hostKey)
+		if !ok {
+			return &unknownCertError{hostKey: v.hostKey, fingerprint: fingerprint}
+		}
+		if known != fingerprint {
+			return &certMismatchError{hostKey: v.hostKey, expected: known, actual: fingerprint}
+		}
+	}
+
+	v.fingerprint = fingerprint
+	return nil
+}
+
+// formatFingerprint splits a fingerprint over two lines so it fits in a modal
+func formatFingerprint(fp string) string {
+	hex := strings.TrimPrefix(fp, "SHA256:")
+	if len(hex) < 48 {
+		return fp
+	}
+	return "SHA256:\n" + hex[:48] + "\n" + hex[48:]
+}
@@ -0,0 +1,269 @@
+package internal
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert is a certificate and key for a fake server or CA
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert creates a certificate for name signed by parent, or self-signed
+// if parent is nil. CA certificates can sign others.
+func newTestCert(t *testing.T, name string, isCA bool, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: cert, key: key}
+}
+
+// writePEM saves the certificate to a PEM file in a temporary directory
+func (c *testCert) writePEM(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// handshake runs a TLS handshake between v and a server presenting chain,
+// returning the client's error. It uses a loopback connection rather than a
+// pipe, so a client that rejects the certificate mid-flight can't deadlock.
+func handshake(t *testing.T, v *certVerifier, chain ...*testCert) error {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	cert := tls.Certificate{PrivateKey: chain[0].key, Leaf: chain[0].cert}
+	for _, c := range chain {
+		cert.Certificate = append(cert.Certificate, c.cert.Raw)
+	}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_ = tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}}).Handshake()
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return tls.Client(conn, v.tlsConfig()).Handshake()
+}
+
+func newTestKnownHosts(t *testing.T) *KnownHosts {
+	t.Helper()
+	k, err := LoadKnownHosts(filepath.Join(t.TempDir(), "known-hosts.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func newTestVerifier(t *testing.T, k *KnownHosts, p connectionParams) *certVerifier {
+	t.Helper()
+	m := &Model{knownHosts: k}
+	v, err := m.newCertVerifier("hotline.example.com:5600", p)
+	if err != nil {
+		t.Fatalf("newCertVerifier: %v", err)
+	}
+	return v
+}
+
+func TestCertVerifierUnknownCert(t *testing.T) {
+	server := newTestCert(t, "hotline.example.com", false, nil)
+	v := newTestVerifier(t, newTestKnownHosts(t), connectionParams{})
+
+	err := handshake(t, v, server)
+	var unknown *unknownCertError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("handshake error = %v, want unknownCertError", err)
+	}
+	if unknown.hostKey != "hotline.example.com:5600" || unknown.fingerprint != certFingerprint(server.cert) {
+		t.Errorf("unknownCertError = %+v, want the server's host and fingerprint", unknown)
+	}
+	if !isCertError(err) {
+		t.Error("isCertError = false for an unknown certificate")
+	}
+}
+
+func TestCertVerifierPinnedCert(t *testing.T) {
+	server := newTestCert(t, "hotline.example.com", false, nil)
+	k := newTestKnownHosts(t)
+	if err := k.Pin("hotline.example.com:5600", certFingerprint(server.cert)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pin is saved and survives a reload
+	reloaded, err := LoadKnownHosts(k.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := newTestVerifier(t, reloaded, connectionParams{})
+	if err := handshake(t, v, server); err != nil {
+		t.Fatalf("handshake with pinned certificate: %v", err)
+	}
+	if v.fingerprint != certFingerprint(server.cert) {
+		t.Errorf("fingerprint = %q, want the server's", v.fingerprint)
+	}
+}
+
+func TestCertVerifierChangedCert(t *testing.T) {
+	pinned := newTestCert(t, "hotline.example.com", false, nil)
+	server := newTestCert(t, "hotline.example.com", false, nil)
+	k := newTestKnownHosts(t)
+	if err := k.Pin("hotline.example.com:5600", certFingerprint(pinned.cert)); err != nil {
+		t.Fatal(err)
+	}
+
+	v := newTestVerifier(t, k, connectionParams{})
+	err := handshake(t, v, server)
+	var mismatch *certMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("handshake error = %v, want certMismatchError", err)
+	}
+	if mismatch.expected != certFingerprint(pinned.cert) || mismatch.actual != certFingerprint(server.cert) {
+		t.Errorf("certMismatchError = %+v, want pinned and received fingerprints", mismatch)
+	}
+	if v.fingerprint != "" {
+		t.Error("refused certificate was recorded as verified")
+	}
+}
+
+func TestCertVerifierChain(t *testing.T) {
+	ca := newTestCert(t, "Test CA", true, nil)
+	caFile := ca.writePEM(t)
+	signed := newTestCert(t, "hotline.example.com", false, ca)
+	wrongName := newTestCert(t, "other.example.com", false, ca)
+	selfSigned := newTestCert(t, "hotline.example.com", false, nil)
+
+	tests := []struct {
+		name   string
+		params connectionParams
+		chain  []*testCert
+		ok     bool
+	}{
+		{name: "signed by CA file", params: connectionParams{tlsCAFile: caFile}, chain: []*testCert{signed}, ok: true},
+		{name: "signed with intermediate sent", params: connectionParams{tlsCAFile: caFile}, chain: []*testCert{signed, ca}, ok: true},
+		{name: "wrong hostname", params: connectionParams{tlsCAFile: caFile}, chain: []*testCert{wrongName}},
+		{name: "not signed by CA file", params: connectionParams{tlsCAFile: caFile}, chain: []*testCert{selfSigned}},
+		{name: "strict with system roots", params: connectionParams{tlsStrict: true}, chain: []*testCert{signed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Chain verification ignores known hosts, even for a pinned certificate
+			k := newTestKnownHosts(t)
+			if err := k.Pin("hotline.example.com:5600", certFingerprint(tt.chain[0].cert)); err != nil {
+				t.Fatal(err)
+			}
+			v := newTestVerifier(t, k, tt.params)
+
+			err := handshake(t, v, tt.chain...)
+			if tt.ok && err != nil {
+				t.Fatalf("handshake: %v", err)
+			}
+			if !tt.ok && !isCertError(err) {
+				t.Fatalf("handshake error = %v, want a certificate error", err)
+			}
+		})
+	}
+}
+
+func TestNewCertVerifierBadCAFile(t *testing.T) {
+	m := &Model{knownHosts: newTestKnownHosts(t)}
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{empty, filepath.Join(t.TempDir(), "missing.pem")} {
+		if _, err := m.newCertVerifier("hotline.example.com:5600", connectionParams{tlsCAFile: path}); err == nil {
+			t.Errorf("newCertVerifier accepted CA file %s", filepath.Base(path))
+		}
+	}
+}
+
+func TestCertVerifierForTransfer(t *testing.T) {
+	server := newTestCert(t, "hotline.example.com", false, nil)
+	other := newTestCert(t, "hotline.example.com", false, nil)
+	k := newTestKnownHosts(t)
+	if err := k.Pin("hotline.example.com:5600", certFingerprint(server.cert)); err != nil {
+		t.Fatal(err)
+	}
+
+	control := newTestVerifier(t, k, connectionParams{})
+	if err := handshake(t, control, server); err != nil {
+		t.Fatalf("control handshake: %v", err)
+	}
+
+	if err := handshake(t, control.forTransfer(), server); err != nil {
+		t.Errorf("transfer handshake with the control certificate: %v", err)
+	}
+
+	// Re-pinning the host mid-session doesn't let a different transfer certificate through
+	if err := k.Pin("hotline.example.com:5600", certFingerprint(other.cert)); err != nil {
+		t.Fatal(err)
+	}
+	err := handshake(t, control.forTransfer(), other)
+	var mismatch *certMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("transfer handshake error = %v, want certMismatchError", err)
+	}
+	if mismatch.expected != certFingerprint(server.cert) {
+		t.Errorf("expected fingerprint = %q, want the control connection's", mismatch.expected)
+	}
+}
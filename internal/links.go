@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Hyperlink outputs, selected with Settings.Hyperlinks. Anything else, including
+// "auto", detects the terminal.
+const (
+	hyperlinksOn  = "on"
+	hyperlinksOff = "off"
+)
+
+// urlPattern finds web, FTP and Hotline links in text. Trailing punctuation is
+// trimmed off by trimURL.
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?|ftp|hotline)://[^\s<>"\x1b]+`)
+
+// hyperlinkPattern matches the OSC 8 sequences written by wrapLinks
+var hyperlinkPattern = regexp.MustCompile("\x1b\\]8;[^;\x07]*;([^\x07]*)\x07")
+
+// nonBreakingHyphen stands in for hyphens in links while wrapping, so that
+// links only break when they are wider than the line
+const nonBreakingHyphen = "‑"
+
+// hyperlinks reports whether links should be written as OSC 8 hyperlinks,
+// detecting the terminal's support when unset or "auto"
+func (cp *Settings) hyperlinks() bool {
+	switch strings.ToLower(cp.Hyperlinks) {
+	case hyperlinksOn:
+		return true
+	case hyperlinksOff:
+		return false
+	}
+	return detectHyperlinks()
+}
+
+// detectHyperlinks guesses from the environment whether the terminal supports
+// OSC 8. Most terminals that don't quietly ignore it, but some print it.
+func detectHyperlinks() bool {
+	term := os.Getenv("TERM")
+	program := os.Getenv("TERM_PROGRAM")
+
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("WT_SESSION") != "" || os.Getenv("VTE_VERSION") != "":
+		return true
+	case program == "iTerm.app" || program == "WezTerm" || program == "ghostty" || program == "vscode":
+		return true
+	case strings.Contains(term, "kitty") || strings.Contains(term, "alacritty") || term == "foot" || strings.HasPrefix(term, "foot-"):
+		return true
+	}
+	return false
+}
+
+// findLinks returns the links in text in the order they appear, without repeats
+func findLinks(text string) []string {
+	var links []string
+	for _, link := range urlPattern.FindAllString(ansi.Strip(text), -1) {
+		link = trimURL(link)
+		if !slices.Contains(links, link) {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// trimURL drops punctuation that ends the sentence around a link rather than
+// the link itself, keeping closing brackets that have a partner in the link
+func trimURL(link string) string {
+	for link != "" {
+		last := link[len(link)-1]
+		switch {
+		case strings.IndexByte(".,;:!?'", last) >= 0:
+		case last == ')' && strings.Count(link, "(") < strings.Count(link, ")"):
+		case last == ']' && strings.Count(link, "[") < strings.Count(link, "]"):
+		default:
+			return link
+		}
+		link = link[:len(link)-1]
+	}
+	return link
+}
+
+// wrapLinks wraps text to width like wordwrap.String, but moves a link to the
+// next line rather than breaking it, and only splits links wider than a line.
+// With osc8 set, every piece of a link is an OSC 8 hyperlink to the whole URL.
+func wrapLinks(text string, width int, osc8 bool) string {
+	marked := urlPattern.ReplaceAllStringFunc(text, func(match string) string {
+		link := trimURL(match)
+		return ansi.SetHyperlink(link) + strings.ReplaceAll(link, "-", nonBreakingHyphen) +
+			ansi.ResetHyperlink() + match[len(link):]
+	})
+
+	lines := strings.Split(ansi.Wrap(marked, width, ""), "\n")
+	var open string // Link carried over from the previous line
+	for i, line := range lines {
+		var b strings.Builder
+		if open != "" && osc8 {
+			b.WriteString(ansi.SetHyperlink(open))
+		}
+
+		last := 0
+		for _, loc := range hyperlinkPattern.FindAllStringSubmatchIndex(line, -1) {
+			b.WriteString(unmarkLink(line[last:loc[0]], open))
+			if osc8 {
+				b.WriteString(line[loc[0]:loc[1]])
+			}
+			open = line[loc[2]:loc[3]]
+			last = loc[1]
+		}
+		b.WriteString(unmarkLink(line[last:], open))
+
+		if open != "" && osc8 {
+			b.WriteString(ansi.ResetHyperlink())
+		}
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// unmarkLink restores the hyphens of text inside a link
+func unmarkLink(text, link string) string {
+	if link == "" {
+		return text
+	}
+	return strings.ReplaceAll(text, nonBreakingHyphen, "-")
+}
+
+// copyToClipboard puts text on the system clipboard with OSC 52, which works
+// over SSH but may be turned off in the terminal's settings
+func copyToClipboard(text string) {
+	fmt.Print(ansi.SetSystemClipboard(text))
+}
+
+// openLink runs the OpenCommand setting, or the platform's default opener, with
+// the link as its last argument
+func (m *Model) openLink(link string) error {
+	args := strings.Fields(m.prefs.OpenCommand)
+	if len(args) == 0 {
+		switch runtime.GOOS {
+		case "darwin":
+			args = []string{"open"}
+		case "windows":
+			args = []string{"rundll32", "url.dll,FileProtocolHandler"}
+		default:
+			args = []string{"xdg-open"}
+		}
+	}
+
+	cmd := exec.Command(args[0], append(args[1:], link)...)
+	if err := cmd.Start(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("%s not found; set OpenCommand in the config file", args[0])
+		}
+		return err
+	}
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			m.logger.Warn("Open command failed", "cmd", args[0], "link", link, "err", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestTrimURL(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com":                       "https://example.com",
+		"https://example.com.":                      "https://example.com",
+		"https://example.com/a?b=c,":                "https://example.com/a?b=c",
+		"https://example.com/!?":                    "https://example.com/",
+		"https://en.wikipedia.org/wiki/Foo_(bar)":   "https://en.wikipedia.org/wiki/Foo_(bar)",
+		"https://en.wikipedia.org/wiki/Foo_(bar)).": "https://en.wikipedia.org/wiki/Foo_(bar)",
+		"https://example.com/x)":                    "https://example.com/x",
+		"https://example.com/[1]":                   "https://example.com/[1]",
+		"https://example.com/1]":                    "https://example.com/1",
+		"hotline://example.com:5500/Files/':":       "hotline://example.com:5500/Files/",
+	}
+	for link, want := range tests {
+		if got := trimURL(link); got != want {
+			t.Errorf("trimURL(%q) = %q, want %q", link, got, want)
+		}
+	}
+}
+
+func TestFindLinks(t *testing.T) {
+	text := "see https://example.com/a, (ftp://files.example.com/pub) and " +
+		"\x1b[1mhotline://hotline.example.com/\x1b[0m or https://example.com/a again"
+	want := []string{"https://example.com/a", "ftp://files.example.com/pub", "hotline://hotline.example.com/"}
+	if got := findLinks(text); !slices.Equal(got, want) {
+		t.Errorf("findLinks = %q, want %q", got, want)
+	}
+	if got := findLinks("no links, just example.com"); got != nil {
+		t.Errorf("findLinks without links = %q", got)
+	}
+}
+
+func TestWrapLinks(t *testing.T) {
+	const link = "https://example.com/some-long-path"
+
+	// The link moves to the next line rather than breaking at a hyphen
+	got := wrapLinks("read this: "+link+" now", 40, false)
+	want := "read this:\n" + link + " now"
+	if got != want {
+		t.Errorf("wrapLinks = %q, want %q", got, want)
+	}
+
+	// Text without links wraps as usual
+	if got := wrapLinks("one two three", 8, false); got != "one two\nthree" {
+		t.Errorf("wrapLinks without links = %q", got)
+	}
+}
+
+func TestWrapLinksSplitsLongLinks(t *testing.T) {
+	const link = "https://example.com/a-very-long-path-that-cannot-fit"
+
+	got := wrapLinks(link, 20, false)
+	if strings.ReplaceAll(got, "\n", "") != link {
+		t.Errorf("wrapLinks split %q into %q", link, got)
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if w := ansi.StringWidth(line); w > 20 {
+			t.Errorf("line %q is %d wide, want at most 20", line, w)
+		}
+	}
+
+	// With OSC 8, every piece links to the whole URL and is closed on its own line
+	got = wrapLinks(link+".", 20, true)
+	lines := strings.Split(got, "\n")
+	if len(lines) < 2 {
+		t.Fatalf("wrapLinks with OSC 8 = %q, want several lines", got)
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, ansi.SetHyperlink(link)) || !strings.Contains(line, ansi.ResetHyperlink()) {
+			t.Errorf("line %q doesn't link to %s", line, link)
+		}
+	}
+	if plain := ansi.Strip(strings.ReplaceAll(got, "\n", "")); plain != link+"." {
+		t.Errorf("wrapLinks with OSC 8 shows %q, want %q", plain, link+".")
+	}
+}
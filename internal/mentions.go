@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// MentionConfig picks out public chat lines addressed to us
+type MentionConfig struct {
+	// Keywords are matched as whole words, ignoring case
+	Keywords []string `yaml:"Keywords,omitempty"`
+	// Patterns are regular expressions, e.g. "(?i)\\bdeploy(ed|ing)?\\b"
+	Patterns []string `yaml:"Patterns,omitempty"`
+	// IgnoreName stops our own username from counting as a mention
+	IgnoreName bool `yaml:"IgnoreName,omitempty"`
+}
+
+// mentionStyle renders the text of a chat line that mentions us
+var mentionStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("0")).Background(lipgloss.Color("220"))
+
+// maxChatNameLen is how much of a name the server puts in front of chat lines
+const maxChatNameLen = 13
+
+// chatName returns a name as the server shows it in front of chat lines
+func chatName(name string) string {
+	r := []rune(name)
+	return string(r[:min(len(r), maxChatNameLen)])
+}
+
+// highlighter matches chat lines against the mention rules. It is used from the
+// transaction handler goroutine and rebuilt from the UI.
+type highlighter struct {
+	mu       sync.RWMutex
+	name     string // Our username, as the server shows it in chat
+	patterns []*regexp.Regexp
+}
+
+// wordPattern matches s as a whole word, ignoring case
+func wordPattern(s string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(^|\W)` + regexp.QuoteMeta(s) + `($|\W)`)
+}
+
+// newHighlighter compiles the mention rules for username. Invalid patterns are
+// returned as errors and left out.
+func newHighlighter(cfg MentionConfig, username string) (*highlighter, []error) {
+	h := &highlighter{}
+	errs := h.set(cfg, username)
+	return h, errs
+}
+
+// set replaces the rules
+func (h *highlighter) set(cfg MentionConfig, username string) []error {
+	var patterns []*regexp.Regexp
+	var errs []error
+	if name := strings.TrimSpace(username); name != "" && !cfg.IgnoreName {
+		patterns = append(patterns, wordPattern(name))
+	}
+	for _, k := range cfg.Keywords {
+		if k = strings.TrimSpace(k); k != "" {
+			patterns = append(patterns, wordPattern(k))
+		}
+	}
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		patterns = append(patterns, re)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.name = chatName(username)
+	h.patterns = patterns
+	return errs
+}
+
+// match reports whether a public chat line mentions us. The speaker's name and
+// our own lines are never matched.
+func (h *highlighter) match(line string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	text := strings.TrimSpace(line)
+	if speaker, body, ok := strings.Cut(text, ":  "); ok {
+		if strings.TrimSpace(speaker) == h.name {
+			return false
+		}
+		text = body
+	} else if emote, ok := strings.CutPrefix(text, "*** "); ok {
+		// Emotes are "*** name action"
+		if h.name != "" && strings.HasPrefix(emote, h.name+" ") {
+			return false
+		}
+	} else {
+		// Join, leave and rename notices
+		return false
+	}
+
+	for _, re := range h.patterns {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateHighlights rebuilds the mention rules after the name or rules change
+func (m *Model) updateHighlights() {
+	for _, err := range m.highlights.set(m.prefs.Mentions, m.prefs.Username) {
+		m.logger.Error("Invalid mention pattern", "err", err)
+	}
+}
+
+// chatAlert plays the sound and rings the bell for a chat line. Mentions always
+// ring the bell, even when EnableBell is off.
+func (m *Model) chatAlert(mention bool) {
+	if mention {
+		if m.soundPlayer != nil {
+			m.soundPlayer.PlayAsync(SoundMention)
+		}
+		fmt.Print("\a")
+		return
+	}
+
+	if m.soundPlayer != nil {
+		m.soundPlayer.PlayAsync(SoundChatMsg)
+	}
+	if m.prefs.EnableBell {
+		fmt.Print("\a")
+	}
+}
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestHighlighterMatch(t *testing.T) {
+	h, errs := newHighlighter(MentionConfig{
+		Keywords: []string{"deploy", " c++ "},
+		Patterns: []string{`(?i)\bbuild (failed|broke)\b`},
+	}, "Tester")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{line: "\r          bob:  hey tester", want: true},
+		{line: "\r          bob:  hey TESTER!", want: true},
+		{line: "\r          bob:  testers unite", want: false}, // Whole words only
+		{line: "\r          bob:  time to deploy", want: true},
+		{line: "\r          bob:  redeploy it", want: false},
+		{line: "\r          bob:  I write C++ all day", want: true},
+		{line: "\r          bob:  the Build Failed again", want: true},
+		{line: "\r          bob:  build passed", want: false},
+		{line: "\r       Tester:  deploy now", want: false}, // Our own line
+		{line: "\r       tester:  hi", want: false},
+		{line: "\r *** bob waves at tester", want: true},
+		{line: "\r *** Tester deploys", want: false}, // Our own emote
+		{line: "→ tester joined", want: false},
+	}
+	for _, tt := range tests {
+		if got := h.match(tt.line); got != tt.want {
+			t.Errorf("match(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestHighlighterSet(t *testing.T) {
+	// Long names are cut to what the server shows in front of chat lines
+	h, _ := newHighlighter(MentionConfig{}, "Bartholomew Jones")
+	if h.match("\rBartholomew J:  Bartholomew Jones is me") {
+		t.Error("matched our own line under a shortened name")
+	}
+	if !h.match("\r          bob:  hi Bartholomew Jones") {
+		t.Error("didn't match our full name")
+	}
+
+	// Rebuilding replaces the rules; invalid patterns are left out
+	errs := h.set(MentionConfig{Patterns: []string{"(", "ping"}, IgnoreName: true}, "Bartholomew Jones")
+	if len(errs) != 1 {
+		t.Errorf("errors = %v, want one for the invalid pattern", errs)
+	}
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{line: "\r          bob:  hi Bartholomew Jones", want: false},
+		{line: "\r          bob:  ping", want: true},
+		{line: "\r          bob:  (", want: false},
+	}
+	for _, tt := range tests {
+		if got := h.match(tt.line); got != tt.want {
+			t.Errorf("after set, match(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestPreviousMention(t *testing.T) {
+	m, _ := newTestModel(t, &Settings{ScrollbackLines: 10})
+	s := NewServerScreen(m)
+	s.SetSize(100, 12) // Three chat lines
+
+	s.AddChatMessage("line")
+	s.AddMention("first mention")
+	addLines(s.chat, 0, 3)
+	s.AddMention("second mention")
+	addLines(s.chat, 3, 5)
+	if s.unseenMentions != 2 {
+		t.Errorf("unseenMentions = %d, want 2", s.unseenMentions)
+	}
+
+	// Jumping goes from the newest mention back, wrapping around
+	for _, want := range []string{"second mention", "first mention", "second mention"} {
+		s.previousMention()
+		if got := viewLines(s.chat); len(got) == 0 || got[0] != want {
+			t.Errorf("after jumping, view = %q, want %q at the top", got, want)
+		}
+	}
+	if s.unseenMentions != 0 {
+		t.Errorf("unseenMentions = %d after jumping", s.unseenMentions)
+	}
+
+	// Mentions evicted from the scrollback are forgotten
+	s.chat.gotoBottom()
+	addLines(s.chat, 5, 12)
+	s.previousMention()
+	if len(s.mentions) != 1 || s.chat.entry(s.mentions[0]).text != "second mention" {
+		t.Errorf("mentions = %v, want only the one still in the scrollback", s.mentions)
+	}
+}
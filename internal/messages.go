@@ -5,7 +5,10 @@ import "github.com/jhalter/mobius/hotline"
 // Internal message types for BubbleTea communication
 
 type chatMsg struct {
-	text string
+	text    string
+	chatID  [4]byte // Private chat the message belongs to, zero for public chat
+	mention bool    // Matches our mention rules
+	ignored bool    // From an ignored user, shown collapsed
 }
 
 type userListMsg struct {
@@ -21,10 +24,11 @@ type errorMsg struct {
 }
 
 type serverMsgMsg struct {
-	from   string
-	userID [2]byte
-	text   string
-	time   string
+	from      string
+	userID    [2]byte
+	text      string
+	time      string
+	automatic bool // The message is another user's automatic response
 }
 
 // PrivateMessage represents a pending private message in the stack
@@ -45,11 +49,13 @@ type trackerListMsg struct {
 
 type serverConnectedMsg struct {
 	name string
+	info serverInfo // Details from the login reply
 }
 
 // serverConnectionAttemptMsg is sent after joinServer() completes (success or failure)
 type serverConnectionAttemptMsg struct {
-	err error
+	params *connectionParams // The attempt's pendingConnection, to ignore stale results
+	err    error
 }
 
 type filesMsg struct {
@@ -71,14 +77,14 @@ type taskStatusMsg struct {
 }
 
 type downloadReplyMsg struct {
-	txID         [4]byte
+	taskID       string
 	refNum       [4]byte
 	transferSize uint32
 	fileSize     uint32
 }
 
 type uploadReplyMsg struct {
-	txID   [4]byte
+	taskID string
 	refNum [4]byte
 }
 
@@ -2,7 +2,6 @@ package internal
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/binary"
 	"fmt"
 	"log/slog"
@@ -10,12 +9,14 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/jhalter/mobius/hotline"
 	"gopkg.in/yaml.v3"
 )
@@ -50,6 +51,10 @@ const (
 	ScreenComposeMessage
 	ScreenFilePicker
 	ScreenLoading
+	ScreenServerInfo
+	ScreenPrivateChat
+	ScreenChatLogs
+	ScreenLinks
 )
 
 // Model
@@ -62,114 +67,41 @@ type Model struct {
 	logger      *slog.Logger
 	debugBuffer *DebugBuffer
 	soundPlayer *SoundPlayer
+	knownHosts  *KnownHosts
+	history     *InputHistory
+	chatLog     *chatLogger
+	highlights  *highlighter
+	triggers    *triggerEngine
 
-	msgHandlers map[reflect.Type]msgHandler
+	// Protocol tracing (-trace) and trace replay (-replay)
+	tracer        *tracer
+	replayName    string
+	replayRecords []traceRecord
 
-	// Screen state
-	screenHistory []Screen // Stack of screens, current screen is last element
+	startURL *HotlineURL // hotline:// URL given on the command line
+
+	msgHandlers  map[reflect.Type]msgHandler
+	chatCommands map[string]chatCommand // Slash commands by name
 
 	width         int
-	height        int
+	height        int // Height available to screens, below the session tab bar
+	termHeight    int
 	welcomeBanner string // Randomly selected banner, loaded once at startup
 
-	// Hotline client
-	hlClient          *hotline.Client
-	serverName        string
-	pendingServerName string // Name to display when connection succeeds (from bookmark/tracker/address)
-	pendingServerAddr string // Address being connected to
-	userAccess        hotline.AccessBitmap
-	userList          []hotline.User
-
-	// Connection management
-	connectionCtx       context.Context
-	connectionCtxCancel context.CancelFunc
-	clientDisconnecting bool
-	connectionUsesTLS   bool
-
-	// Screens
-	homeScreen             *HomeScreen
-	joinServerScreen       *JoinServerScreen
-	bookmarkScreen         *BookmarkScreen
-	trackerScreen          *TrackerScreen
-	settingsScreen         *SettingsScreen
-	serverScreen           *ServerScreen
-	newsScreen             *NewsScreen
-	newsArticlePostScreen  *NewsArticlePostScreen
-	newsBundleFormScreen   *NewsBundleFormScreen
-	newsCategoryFormScreen *NewsCategoryFormScreen
-	legacyNewsPostScreen   *LegacyNewsPostScreen
-	accountsScreen         *AccountsScreen
-	filesScreen            *FilesScreen
-	tasksScreen            *TasksScreen
-	logsScreen             *LogsScreen
-	messageBoardScreen     *MessageBoardScreen
-	filePickerScreen       *FilePickerScreen
-	composeMessageScreen   *ComposeMessageScreen
-	modalScreen            *ModalScreen
-	loadingScreen          *LoadingScreen
+	// Server sessions; the active one is embedded so screens address it directly
+	sessions   []*Session
+	sessionsMu sync.Mutex // Guards sessions for lookups from transaction handlers
+	*Session
 
 	// File picker state
 	lastPickerLocation string // Remember last location
 
-	// Private message stack (for handling multiple incoming PMs)
-	privateMessages []PrivateMessage
-
-	// Task management for file downloads and uploads
-	taskManager      *TaskManager
-	downloadDir      string
-	pendingDownloads map[[4]byte]string // transaction ID -> task ID
-	pendingUploads   map[[4]byte]string // transaction ID -> task ID
+	downloadDir string
 
 	// Task widget
 	taskProgress map[string]progress.Model // task ID -> progress model
-}
-
-// CurrentScreen returns the current screen, or ScreenHome if history is empty
-func (m *Model) CurrentScreen() Screen {
-	if len(m.screenHistory) == 0 {
-		return ScreenHome
-	}
-	return m.screenHistory[len(m.screenHistory)-1]
-}
-
-// PreviousScreen returns the previous screen, or ScreenHome if insufficient history
-func (m *Model) PreviousScreen() Screen {
-	if len(m.screenHistory) < 2 {
-		return ScreenHome
-	}
-	return m.screenHistory[len(m.screenHistory)-2]
-}
-
-// PushScreen adds a new screen to history (modal/overlay pattern)
-func (m *Model) PushScreen(screen Screen) {
-	m.screenHistory = append(m.screenHistory, screen)
-}
-
-// PopScreen removes current screen and returns to previous
-// Returns the screen we're now on
-func (m *Model) PopScreen() Screen {
-	if len(m.screenHistory) <= 1 {
-		m.screenHistory = []Screen{ScreenHome}
-		return ScreenHome
-	}
-	m.screenHistory = m.screenHistory[:len(m.screenHistory)-1]
-	return m.screenHistory[len(m.screenHistory)-1]
-}
-
-// ReplaceScreen replaces the current screen without adding to history
-// Used when switching between peer screens (e.g., News -> MessageBoard from ServerUI)
-func (m *Model) ReplaceScreen(screen Screen) {
-	if len(m.screenHistory) == 0 {
-		m.screenHistory = []Screen{screen}
-	} else {
-		m.screenHistory[len(m.screenHistory)-1] = screen
-	}
-}
 
-// NavigateTo clears history and jumps to a screen (hard navigation)
-// Used for disconnect, logout, or other full resets
-func (m *Model) NavigateTo(screen Screen) {
-	m.screenHistory = []Screen{screen}
+	lastInput time.Time // Last keypress, for automatic away
 }
 
 // updatePrivateMessageModal creates or updates the modal for the current PM stack
@@ -243,6 +175,14 @@ func (m *Model) currentScreen() ScreenModel {
 		return m.modalScreen
 	case ScreenLoading:
 		return m.loadingScreen
+	case ScreenServerInfo:
+		return m.serverInfoScreen
+	case ScreenPrivateChat:
+		return m.privateChatScreen
+	case ScreenChatLogs:
+		return m.chatLogsScreen
+	case ScreenLinks:
+		return m.linksScreen
 	}
 	return nil
 }
@@ -254,8 +194,6 @@ func NewModel(cfgPath string, logger *slog.Logger, db *DebugBuffer) *Model {
 		os.Exit(1)
 	}
 
-	hlClient := hotline.NewClient(prefs.Username, logger)
-
 	// Initialize download directory
 	downloadDir := prefs.DownloadDir
 	if downloadDir == "" {
@@ -272,23 +210,63 @@ func NewModel(cfgPath string, logger *slog.Logger, db *DebugBuffer) *Model {
 		logger.Error("Failed to initialize sound player", "err", err)
 	}
 
-	return &Model{
+	// Load pinned TLS certificates
+	knownHostsPath := prefs.KnownHostsFile
+	if knownHostsPath == "" {
+		knownHostsPath = defaultKnownHostsPath(cfgPath)
+	}
+	knownHosts, err := LoadKnownHosts(knownHostsPath)
+	if err != nil {
+		logger.Error("Failed to load known hosts", "err", err)
+	}
+
+	// Load chat input history
+	historyPath := prefs.HistoryFile
+	if historyPath == "" {
+		historyPath = defaultInputHistoryPath(cfgPath)
+	}
+	history, err := LoadInputHistory(historyPath)
+	if err != nil {
+		logger.Error("Failed to load input history", "err", err)
+	}
+
+	// Chat logs go to the configured directory or the default one
+	chatLogDir := prefs.ChatLogDir
+	if chatLogDir == "" {
+		chatLogDir = defaultChatLogDir()
+	}
+
+	highlights, errs := newHighlighter(prefs.Mentions, prefs.Username)
+	for _, err := range errs {
+		logger.Error("Invalid mention pattern", "err", err)
+	}
+	triggers, errs := newTriggerEngine(prefs.Triggers, prefs.Username)
+	for _, err := range errs {
+		logger.Error("Invalid trigger rule", "err", err)
+	}
+
+	m := &Model{
 		msgHandlers:        make(map[reflect.Type]msgHandler),
+		chatCommands:       make(map[string]chatCommand),
 		cfgPath:            cfgPath,
 		prefs:              prefs,
 		logger:             logger,
 		debugBuffer:        db,
 		soundPlayer:        soundPlayer,
+		knownHosts:         knownHosts,
+		history:            history,
+		chatLog:            newChatLogger(chatLogDir),
+		highlights:         highlights,
+		triggers:           triggers,
 		welcomeBanner:      randomBanner(), // Load banner once at startup
-		hlClient:           hlClient,
-		taskManager:        NewTaskManager(),
 		downloadDir:        downloadDir,
-		pendingDownloads:   make(map[[4]byte]string),
-		pendingUploads:     make(map[[4]byte]string),
 		lastPickerLocation: startDir,
 		taskProgress:       make(map[string]progress.Model),
-		screenHistory:      []Screen{ScreenHome},
+		lastInput:          time.Now(),
 	}
+	m.Session = m.newSession()
+	m.sessions = []*Session{m.Session}
+	return m
 }
 
 func readConfig(cfgPath string) (*Settings, error) {
@@ -320,6 +298,20 @@ func (m *Model) Init() tea.Cmd {
 	m.registerHandler(serverMsgMsg{}, m.handleServerMsgMsg)
 	m.registerHandler(agreementMsg{}, m.handleAgreementMsg)
 	m.registerHandler(serverConnectedMsg{}, m.handleServerConnectedMsg)
+	m.registerHandler(serverAgreedMsg{}, m.handleServerAgreedMsg)
+	m.registerHandler(serverBannerMsg{}, m.handleServerBannerMsg)
+	m.registerHandler(bannerMsg{}, m.handleBannerMsg)
+	m.registerHandler(chatInviteMsg{}, m.handleChatInviteMsg)
+	m.registerHandler(chatCreatedMsg{}, m.handleChatCreatedMsg)
+	m.registerHandler(chatJoinedMsg{}, m.handleChatJoinedMsg)
+	m.registerHandler(chatMemberMsg{}, m.handleChatMemberMsg)
+	m.registerHandler(chatMemberLeftMsg{}, m.handleChatMemberLeftMsg)
+	m.registerHandler(chatSubjectMsg{}, m.handleChatSubjectMsg)
+	m.registerHandler(commandOutputMsg{}, m.handleCommandOutputMsg)
+	m.registerHandler(userInfoMsg{}, m.handleUserInfoMsg)
+	m.registerHandler(LinksRequestedMsg{}, m.handleLinksRequestedMsg)
+	m.registerHandler(chatOlderMsg{}, m.handleChatOlderMsg)
+	m.registerHandler(triggerMsg{}, m.handleTriggerMsg)
 	m.registerHandler(serverConnectionAttemptMsg{}, m.handleServerConnectionAttemptMsg)
 	m.registerHandler(trackerListMsg{}, m.handleTrackerListMsg)
 	m.registerHandler(SettingsSavedMsg{}, m.handleSettingsSavedMsg)
@@ -338,11 +330,63 @@ func (m *Model) Init() tea.Cmd {
 	m.registerHandler(ModalButtonClickedMsg{}, m.handleModalButtonClickedMsgHandler)
 	m.registerHandler(ModalCancelledMsg{}, m.handleModalCancelledMsgHandler)
 	m.registerHandler(LoadingCancelledMsg{}, m.handleLoadingCancelledMsgHandler)
+	m.registerHandler(connectPhaseMsg{}, m.handleConnectPhaseMsg)
+	m.registerHandler(replyMsg{}, m.handleReplyMsg)
+	m.registerHandler(keepAliveRTTMsg{}, m.handleKeepAliveRTTMsg)
+	m.registerHandler(requestTimeoutMsg{}, m.handleRequestTimeoutMsg)
+	m.registerHandler(reconnectTickMsg{}, m.handleReconnectTickMsg)
+	m.registerHandler(reconnectAttemptMsg{}, m.handleReconnectAttemptMsg)
 
-	return nil
+	m.registerChatCommands()
+
+	if m.replayRecords != nil {
+		return tea.Batch(awayCheck(), m.startReplay())
+	}
+	if m.startURL != nil {
+		return tea.Batch(awayCheck(), m.connectToURL(m.startURL))
+	}
+
+	return awayCheck()
 }
 
+// Update runs msg against the session it belongs to. Messages tagged with a
+// background session are handled with that session swapped in, so they update
+// its screens rather than the active ones.
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Keyboard activity and idle checks span all sessions
+	switch msg.(type) {
+	case awayCheckMsg:
+		return m, m.checkIdle()
+	case tea.KeyMsg:
+		m.userActive()
+	}
+
+	active := m.Session
+	s := active
+	if sm, ok := msg.(sessionMsg); ok {
+		if !m.hasSession(sm.session) {
+			return m, nil // Session was closed
+		}
+		s, msg = sm.session, sm.msg
+	}
+
+	m.Session = s
+	_, cmd := m.update(msg)
+	if s != active {
+		m.Session = active
+		switch msg.(type) {
+		case chatMsg, serverMsgMsg:
+			s.unread++
+		}
+	}
+	if s.closing {
+		m.removeSession(s)
+	}
+
+	return m, m.sessionCmd(s, cmd)
+}
+
+func (m *Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.logger.Debug("Update UI", "tea.Msg", fmt.Sprintf("%v", msg), "currentScreen", m.CurrentScreen())
 
 	// Handle global keybindings
@@ -350,6 +394,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if keyMsg.String() == "ctrl+q" {
 			return m, tea.Quit
 		}
+		if cmd, ok := m.handleSessionKeys(keyMsg); ok {
+			return m, cmd
+		}
 		if keyMsg.String() == "ctrl+l" {
 			m.logsScreen = NewLogsScreen(m.debugBuffer, m)
 			m.PushScreen(ScreenLogs)
@@ -358,17 +405,14 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	if _, ok := msg.(disconnectMsg); ok {
-		m.NavigateTo(ScreenHome)
-		_ = m.hlClient.Disconnect()
-
-		// Only send error if client didn't initiate disconnect
-		var cmd tea.Cmd
-		if !m.clientDisconnecting {
-			cmd = func() tea.Msg {
-				return errorMsg{text: "Server connection closed."}
-			}
+		// A connection abandoned while connecting has already been cleaned up
+		if m.connectionCtx == nil {
+			return m, nil
 		}
 
+		_ = m.hlClient.Disconnect()
+		clientInitiated := m.clientDisconnecting
+
 		// Clean up connection state
 		if m.connectionCtxCancel != nil {
 			m.connectionCtxCancel()
@@ -376,6 +420,36 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.connectionCtx = nil
 		m.clientDisconnecting = false
+		m.requests.clear()
+		m.clearChatRooms()
+		m.logChat("--- Disconnected ---")
+		m.ignores.clearIDs()
+		m.endResume()
+		m.away = nil
+		if m.serverScreen != nil {
+			m.serverScreen.SetAway(false)
+		}
+
+		// Try to get back to the server if we didn't hang up ourselves
+		if !clientInitiated && m.shouldReconnect() {
+			return m, m.scheduleReconnect()
+		}
+		m.activeConnection = nil
+		m.NavigateTo(ScreenHome)
+
+		// Hanging up closes the tab when other sessions are open
+		if clientInitiated && len(m.sessions) > 1 {
+			m.closing = true
+			return m, nil
+		}
+
+		// Only send error if client didn't initiate disconnect
+		var cmd tea.Cmd
+		if !clientInitiated {
+			cmd = func() tea.Msg {
+				return errorMsg{text: "Server connection closed."}
+			}
+		}
 
 		return m, cmd
 	}
@@ -406,6 +480,11 @@ func (m *Model) handleModalButtonClickedMsgHandler(msg tea.Msg) (tea.Model, tea.
 
 // handleModalCancelledMsg handles when the modal is cancelled (ESC pressed)
 func (m *Model) handleModalCancelledMsg() tea.Cmd {
+	// Dismissing a chat invitation declines it
+	if m.isShowingChatInviteModal() {
+		return m.answerChatInvite(false)
+	}
+
 	// If this is a PM modal, pop from the PM stack
 	if m.isShowingPrivateMessageModal() && len(m.privateMessages) > 0 {
 		m.privateMessages = m.privateMessages[:len(m.privateMessages)-1]
@@ -423,8 +502,44 @@ func (m *Model) handleModalCancelledMsg() tea.Cmd {
 
 // handleLoadingCancelledMsgHandler handles when the loading screen is cancelled (ESC pressed)
 func (m *Model) handleLoadingCancelledMsgHandler(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.reconnect != nil {
+		// Give up reconnecting and abort any attempt still in flight
+		var cmd tea.Cmd
+		if m.reconnect.connecting {
+			cmd = m.abortConnection()
+		}
+		m.cancelReconnect()
+		m.pendingConnection = nil
+		return m, cmd
+	}
+
+	// Abort a connection attempt in progress
+	var cmd tea.Cmd
+	if m.pendingConnection != nil {
+		cmd = m.abortConnection()
+	}
 	m.PopScreen()
-	return m, nil
+	return m, cmd
+}
+
+// abortConnection cancels the connection attempt in progress and hangs up the
+// connection if it got that far, so a login reply arriving after the user gave
+// up can't leave us logged in. The disconnect that follows is not reported.
+func (m *Model) abortConnection() tea.Cmd {
+	m.pendingConnection = nil
+	if m.connectionCtxCancel != nil {
+		m.connectionCtxCancel()
+		m.connectionCtxCancel = nil
+	}
+	m.connectionCtx = nil
+
+	c := m.hlClient
+	return func() tea.Msg {
+		if c.Connection != nil {
+			_ = c.Disconnect()
+		}
+		return nil
+	}
 }
 
 // handleModalButtonClickedMsg handles modal button clicks
@@ -434,17 +549,7 @@ func (m *Model) handleModalButtonClickedMsg(msg ModalButtonClickedMsg) tea.Cmd {
 	case ModalTypeAgreement:
 		if msg.ButtonClicked == "Agree" {
 			// User agreed - send TranAgreed asynchronously
-			return func() tea.Msg {
-				_ = m.hlClient.Send(hotline.NewTransaction(
-					hotline.TranAgreed,
-					[2]byte{},
-					hotline.NewField(hotline.FieldUserName, []byte(m.prefs.Username)),
-					hotline.NewField(hotline.FieldUserIconID, m.prefs.IconBytes()),
-					hotline.NewField(hotline.FieldUserFlags, []byte{0x00, 0x00}),
-					hotline.NewField(hotline.FieldOptions, []byte{0x00, 0x00}),
-				))
-				return nil
-			}
+			return m.agreeToServer()
 		}
 		// User disagreed - disconnect and return to home
 		m.clientDisconnecting = true
@@ -452,31 +557,47 @@ func (m *Model) handleModalButtonClickedMsg(msg ModalButtonClickedMsg) tea.Cmd {
 			m.connectionCtxCancel()
 		}
 		m.NavigateTo(ScreenHome)
+		c := m.hlClient
 		return func() tea.Msg {
-			_ = m.hlClient.Disconnect()
+			_ = c.Disconnect()
 			return nil
 		}
 
+	case ModalTypeTrustCertificate:
+		trust := m.pendingTrust
+		m.pendingTrust = nil
+		m.PopScreen()
+		if msg.ButtonClicked == "Trust" && trust != nil {
+			if err := m.knownHosts.Pin(trust.cert.hostKey, trust.cert.fingerprint); err != nil {
+				m.logger.Error("Failed to save known hosts", "err", err)
+			}
+			return m.connectToServer(trust.params)
+		}
+
 	case ModalTypeDisconnect:
 		if msg.ButtonClicked == "Exit" {
 			// Signal that client is initiating disconnect
 			m.clientDisconnecting = true
 
-			// Cancel context to unblock HandleTransactions
+			// Cancel context to unblock handleTransactions
 			if m.connectionCtxCancel != nil {
 				m.connectionCtxCancel()
 			}
 
 			m.NavigateTo(ScreenHome)
 			// Close the connection asynchronously
+			c := m.hlClient
 			return func() tea.Msg {
-				_ = m.hlClient.Disconnect()
+				_ = c.Disconnect()
 				return nil
 			}
 		}
 		// Cancel - return to previous screen
 		m.PopScreen()
 
+	case ModalTypeChatInvite:
+		return m.answerChatInvite(msg.ButtonClicked == "Join")
+
 	case ModalTypePrivateMessage:
 		// Get and pop current message from stack
 		if len(m.privateMessages) > 0 {
@@ -513,13 +634,18 @@ func (m *Model) handleModalButtonClickedMsg(msg ModalButtonClickedMsg) tea.Cmd {
 }
 
 func (m *Model) View() string {
-	if screen := m.currentScreen(); screen != nil {
-		return screen.View()
+	screen := m.currentScreen()
+	if screen == nil {
+		return ""
+	}
+	if m.tabBarHeight() > 0 {
+		return lipgloss.JoinVertical(lipgloss.Left, m.renderTabBar(), screen.View())
 	}
-	return ""
+	return screen.View()
 }
 
 func (m *Model) initiateFileUpload(localPath string) tea.Cmd {
+	s := m.Session
 	return func() tea.Msg {
 		// Get file info
 		fileInfo, err := os.Stat(localPath)
@@ -535,8 +661,8 @@ func (m *Model) initiateFileUpload(localPath string) tea.Cmd {
 
 		// Get file path from files screen
 		var filePath []string
-		if m.filesScreen != nil {
-			filePath = m.filesScreen.GetFilePath()
+		if s.filesScreen != nil {
+			filePath = s.filesScreen.GetFilePath()
 		}
 
 		// Create task
@@ -549,31 +675,28 @@ func (m *Model) initiateFileUpload(localPath string) tea.Cmd {
 			StartTime:  time.Now(),
 			LocalPath:  localPath,
 		}
-		m.taskManager.Add(task)
+		s.taskManager.Add(task)
 
 		// Create upload transaction
 		sizeBytes := make([]byte, 4)
 		binary.BigEndian.PutUint32(sizeBytes, uint32(fileInfo.Size()))
 
 		fields := []hotline.Field{
-			hotline.NewField(hotline.FieldFileName, []byte(fileName)),
+			hotline.NewField(hotline.FieldFileName, s.encodeText(fileName)),
 			hotline.NewField(hotline.FieldTransferSize, sizeBytes),
 		}
 
 		// Add file path if uploading to subfolder
 		if len(filePath) > 0 {
 			pathStr := strings.Join(filePath, "/")
-			pathBytes := hotline.EncodeFilePath(pathStr)
+			pathBytes := s.encodeFilePath(pathStr)
 			fields = append(fields, hotline.NewField(hotline.FieldFilePath, pathBytes))
 		}
 
 		t := hotline.NewTransaction(hotline.TranUploadFile, [2]byte{}, fields...)
 
-		// Map transaction ID to task ID
-		m.pendingUploads[t.ID] = task.ID
-
 		// Send transaction
-		if err := m.hlClient.Send(t); err != nil {
+		if err := m.requestForTask(s, t, "upload", task.ID); err != nil {
 			m.logger.Error("Failed to send upload transaction", "err", err)
 			return errorMsg{text: fmt.Sprintf("Failed to initiate upload: %v", err)}
 		}
@@ -591,108 +714,166 @@ func (m *Model) Start() error {
 	// Store program reference for sending messages from transaction handlers
 	m.program = tea.NewProgram(m, tea.WithAltScreen())
 
-	// Register transaction handlers
-	m.hlClient.HandleFunc(hotline.TranAgreed, m.HandleTranAgreed)
-	m.hlClient.HandleFunc(hotline.TranChatMsg, m.HandleClientChatMsg)
-	m.hlClient.HandleFunc(hotline.TranDownloadFile, m.HandleDownloadFile)
-	m.hlClient.HandleFunc(hotline.TranGetFileInfo, m.HandleGetFileInfo)
-	m.hlClient.HandleFunc(hotline.TranGetFileNameList, m.HandleGetFileNameList)
-	m.hlClient.HandleFunc(hotline.TranGetMsgs, m.TranGetMsgs)
-	m.hlClient.HandleFunc(hotline.TranGetNewsArtData, m.HandleGetNewsArtData)
-	m.hlClient.HandleFunc(hotline.TranGetNewsArtNameList, m.HandleGetNewsArtNameList)
-	m.hlClient.HandleFunc(hotline.TranGetNewsCatNameList, m.HandleGetNewsCatNameList)
-	m.hlClient.HandleFunc(hotline.TranGetUserNameList, m.HandleClientGetUserNameList)
-	m.hlClient.HandleFunc(hotline.TranKeepAlive, m.HandleKeepAlive)
-	m.hlClient.HandleFunc(hotline.TranListUsers, m.HandleListUsers)
-	m.hlClient.HandleFunc(hotline.TranLogin, m.HandleClientTranLogin)
-	m.hlClient.HandleFunc(hotline.TranNewMsg, m.HandleNewMsg)
-	m.hlClient.HandleFunc(hotline.TranNewNewsCat, m.HandleNewNewsCat)
-	m.hlClient.HandleFunc(hotline.TranNewNewsFldr, m.HandleNewNewsFldr)
-	m.hlClient.HandleFunc(hotline.TranNotifyChangeUser, m.HandleNotifyChangeUser)
-	m.hlClient.HandleFunc(hotline.TranNotifyChatDeleteUser, m.HandleNotifyDeleteUser)
-	m.hlClient.HandleFunc(hotline.TranNotifyDeleteUser, m.HandleNotifyDeleteUser)
-	m.hlClient.HandleFunc(hotline.TranPostNewsArt, m.HandlePostNewsArt)
-	m.hlClient.HandleFunc(hotline.TranServerMsg, m.HandleTranServerMsg)
-	m.hlClient.HandleFunc(hotline.TranShowAgreement, m.HandleClientTranShowAgreement)
-	m.hlClient.HandleFunc(hotline.TranUploadFile, m.HandleUploadFile)
-	m.hlClient.HandleFunc(hotline.TranUserAccess, m.HandleClientTranUserAccess)
-
 	_, err := m.program.Run()
+	if m.tracer != nil {
+		_ = m.tracer.Close()
+	}
 	return err
 }
 
-func (m *Model) joinServer(addr, login, password string, useTLS bool) error {
-	// Create cancellable context for this connection
-	m.connectionCtx, m.connectionCtxCancel = context.WithCancel(context.Background())
-	m.clientDisconnecting = false
-	m.connectionUsesTLS = false
+// registerTransactionHandlers registers the transaction handlers on a session's client
+func (m *Model) registerTransactionHandlers(c *hotline.Client) {
+	c.HandleFunc(hotline.TranAgreed, m.HandleTranAgreed)
+	c.HandleFunc(hotline.TranChatMsg, m.HandleClientChatMsg)
+	c.HandleFunc(hotline.TranDownloadBanner, m.HandleDownloadBanner)
+	c.HandleFunc(hotline.TranDownloadFile, m.HandleDownloadFile)
+	c.HandleFunc(hotline.TranGetFileInfo, m.HandleGetFileInfo)
+	c.HandleFunc(hotline.TranGetFileNameList, m.HandleGetFileNameList)
+	c.HandleFunc(hotline.TranGetMsgs, m.TranGetMsgs)
+	c.HandleFunc(hotline.TranGetClientInfoText, m.HandleGetClientInfoText)
+	c.HandleFunc(hotline.TranDisconnectUser, m.HandleDisconnectUser)
+	c.HandleFunc(hotline.TranGetNewsArtData, m.HandleGetNewsArtData)
+	c.HandleFunc(hotline.TranGetNewsArtNameList, m.HandleGetNewsArtNameList)
+	c.HandleFunc(hotline.TranGetNewsCatNameList, m.HandleGetNewsCatNameList)
+	c.HandleFunc(hotline.TranGetUserNameList, m.HandleClientGetUserNameList)
+	c.HandleFunc(hotline.TranInviteNewChat, m.HandleInviteNewChat)
+	c.HandleFunc(hotline.TranInviteToChat, m.HandleInviteToChat)
+	c.HandleFunc(hotline.TranJoinChat, m.HandleJoinChat)
+	c.HandleFunc(hotline.TranKeepAlive, m.HandleKeepAlive)
+	c.HandleFunc(hotline.TranListUsers, m.HandleListUsers)
+	c.HandleFunc(hotline.TranLogin, m.HandleClientTranLogin)
+	c.HandleFunc(hotline.TranNewMsg, m.HandleNewMsg)
+	c.HandleFunc(hotline.TranNewNewsCat, m.HandleNewNewsCat)
+	c.HandleFunc(hotline.TranNewNewsFldr, m.HandleNewNewsFldr)
+	c.HandleFunc(hotline.TranNotifyChangeUser, m.HandleNotifyChangeUser)
+	c.HandleFunc(hotline.TranNotifyChatChangeUser, m.HandleNotifyChatChangeUser)
+	c.HandleFunc(hotline.TranNotifyChatDeleteUser, m.HandleNotifyChatDeleteUser)
+	c.HandleFunc(hotline.TranNotifyChatSubject, m.HandleNotifyChatSubject)
+	c.HandleFunc(hotline.TranNotifyDeleteUser, m.HandleNotifyDeleteUser)
+	c.HandleFunc(hotline.TranPostNewsArt, m.HandlePostNewsArt)
+	c.HandleFunc(hotline.TranServerBanner, m.HandleServerBanner)
+	c.HandleFunc(hotline.TranServerMsg, m.HandleTranServerMsg)
+	c.HandleFunc(hotline.TranShowAgreement, m.HandleClientTranShowAgreement)
+	c.HandleFunc(hotline.TranUploadFile, m.HandleUploadFile)
+	c.HandleFunc(hotline.TranUserAccess, m.HandleClientTranUserAccess)
+}
 
-	// Append default port to address if no port supplied
-	if len(strings.Split(addr, ":")) == 1 {
-		if useTLS {
-			addr += ":5600"
-		} else {
-			addr += ":5500"
-		}
+// joinServer connects s to the server described by p. ctx is the session's
+// connection context from beginConnection; cancelling it aborts the attempt,
+// and each phase of the attempt is bounded by the configured connect timeout.
+func (m *Model) joinServer(ctx context.Context, s *Session, p connectionParams) error {
+	addr, login, password, useTLS := p.addr, p.login, p.password, p.useTLS
+
+	s.clientDisconnecting = false
+	s.connectionUsesTLS = false
+
+	addr, err := normalizeAddr(addr, useTLS)
+	if err != nil {
+		return err
+	}
+	if s.transferAddr, err = p.transfer.addr(addr); err != nil {
+		return err
 	}
 
-	var err error
+	timeout := m.prefs.connectTimeout()
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Open the connection, through the proxy when one is configured
+	d := newDialer(p.proxy, timeout)
+	d.onPhase = func(phase connectPhase) {
+		m.sendTo(s, connectPhaseMsg{phase: phase})
+	}
 	if useTLS {
-		// Create TLS connection
-		m.hlClient.Connection, err = tls.Dial("tcp", addr, &tls.Config{
-			InsecureSkipVerify: true,
-		})
+		// Verify the certificate against the known hosts pin or the CA chain
+		verifier, err := m.newCertVerifier(addr, p)
 		if err != nil {
-			if m.connectionCtxCancel != nil {
-				m.connectionCtxCancel()
-			}
-			return fmt.Errorf("TLS connection error: %v", err)
+			return &connectError{phase: phaseTLS, err: err}
 		}
-		m.connectionUsesTLS = true
 
-		// Perform handshake
-		if err := m.hlClient.Handshake(); err != nil {
-			if m.connectionCtxCancel != nil {
-				m.connectionCtxCancel()
-			}
-			return fmt.Errorf("handshake error: %v", err)
-		}
-
-		// Send login transaction
-		err = m.hlClient.Send(
-			hotline.NewTransaction(
-				hotline.TranLogin, [2]byte{0, 0},
-				hotline.NewField(hotline.FieldVersion, []byte{0x01, 0x5E}), //350
-				hotline.NewField(hotline.FieldUserName, []byte(m.prefs.Username)),
-				hotline.NewField(hotline.FieldUserIconID, m.prefs.IconBytes()),
-				hotline.NewField(hotline.FieldUserLogin, hotline.EncodeString([]byte(login))),
-				hotline.NewField(hotline.FieldUserPassword, hotline.EncodeString([]byte(password))),
-			),
-		)
+		// Create TLS connection
+		s.hlClient.Connection, err = d.DialTLSContext(attemptCtx, addr, verifier.tlsConfig())
 		if err != nil {
-			if m.connectionCtxCancel != nil {
-				m.connectionCtxCancel()
-			}
-			return fmt.Errorf("login error: %v", err)
+			return err
 		}
+		s.connectionUsesTLS = true
+		s.certVerifier = verifier
 	} else {
-		if err := m.hlClient.Connect(addr, login, password); err != nil {
-			if m.connectionCtxCancel != nil {
-				m.connectionCtxCancel()
-			}
-			return fmt.Errorf("error joining server: %v", err)
+		s.hlClient.Connection, err = d.DialContext(attemptCtx, addr)
+		if err != nil {
+			return err
 		}
-		m.connectionUsesTLS = false
+	}
+	d.onPhase = nil
+	s.dialer = d
+
+	// Closing the connection unblocks the handshake and the wait for the login
+	// reply if the attempt is cancelled or times out
+	conn := s.hlClient.Connection
+	stop := context.AfterFunc(attemptCtx, func() { _ = conn.Close() })
+
+	// Perform handshake
+	m.sendTo(s, connectPhaseMsg{phase: phaseHandshake})
+	if err := s.hlClient.Handshake(); err != nil {
+		_ = conn.Close()
+		return phaseError(attemptCtx, phaseHandshake, err)
 	}
 
+	// Record the transactions that follow the handshake
+	if m.tracer != nil {
+		s.hlClient.Connection = m.tracer.wrap(conn, addr)
+	}
+
+	// Read replies from here on; the scanner only reports a disconnect once the
+	// login has been answered, as failures before then are the attempt's to report
+	loginReply := make(chan struct{}, 1)
+	loggedIn := make(chan bool, 1)
+	s.loginReply = loginReply
+	s.clearSentTransactions()
 	go func() {
 		// Use the cancellable context
-		err = m.hlClient.HandleTransactions(m.connectionCtx)
+		err := m.handleTransactions(ctx, s)
 		m.logger.Error("Transaction scanning failed", "err", err)
 
-		m.program.Send(disconnectMsg{})
+		if <-loggedIn {
+			m.sendTo(s, disconnectMsg{})
+		}
 	}()
 
+	// Send login transaction
+	m.sendTo(s, connectPhaseMsg{phase: phaseLogin})
+	err = s.sendTransaction(
+		hotline.NewTransaction(
+			hotline.TranLogin, [2]byte{0, 0},
+			hotline.NewField(hotline.FieldVersion, []byte{0x01, 0x5E}), //350
+			hotline.NewField(hotline.FieldUserName, s.encodeText(m.prefs.Username)),
+			hotline.NewField(hotline.FieldUserIconID, m.prefs.IconBytes()),
+			hotline.NewField(hotline.FieldUserLogin, hotline.EncodeString(s.encodeText(login))),
+			hotline.NewField(hotline.FieldUserPassword, hotline.EncodeString(s.encodeText(password))),
+		),
+	)
+	if err != nil {
+		_ = conn.Close()
+		loggedIn <- false
+		return phaseError(attemptCtx, phaseLogin, err)
+	}
+
+	// Wait for the server to answer the login, accepted or not
+	select {
+	case <-loginReply:
+		// If the attempt ended just as the reply arrived, the connection is
+		// already closed and that is reported as a disconnect
+		stop()
+		loggedIn <- true
+	case <-attemptCtx.Done():
+		loggedIn <- false
+		return phaseError(attemptCtx, phaseLogin, attemptCtx.Err())
+	}
+
+	// Send keepalives and watch for a server that has stopped answering
+	s.health = newConnHealth(m.prefs.KeepAliveWaitForReply)
+	go m.monitorConnection(ctx, s, s.health)
+
 	return nil
 }
 
@@ -0,0 +1,345 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Proxy types accepted in ProxyConfig.Type
+const (
+	ProxyTypeSOCKS5 = "socks5"
+	ProxyTypeHTTP   = "http"
+	ProxyTypeNone   = "none" // Used in a bookmark to bypass the global proxy
+)
+
+// ProxyConfig describes a proxy used for outbound connections
+type ProxyConfig struct {
+	Type     string `yaml:"Type"` // "socks5", "http", or "none"
+	Addr     string `yaml:"Addr"` // Proxy "host:port"
+	Username string `yaml:"Username,omitempty"`
+	Password string `yaml:"Password,omitempty"`
+}
+
+// proxyFor returns the proxy to use for a bookmark, preferring its override
+// over the global setting. A nil result means connect directly.
+func (cp *Settings) proxyFor(bm *Bookmark) *ProxyConfig {
+	proxy := cp.Proxy
+	if bm != nil && bm.Proxy != nil {
+		proxy = bm.Proxy
+	}
+	if proxy == nil || proxy.Type == "" || proxy.Type == ProxyTypeNone {
+		return nil
+	}
+	return proxy
+}
+
+// dialer opens outbound TCP connections, optionally through a proxy. The
+// control, HTXF transfer and tracker connections all go through it.
+type dialer struct {
+	proxy   *ProxyConfig
+	timeout time.Duration
+
+	// onPhase, when set, is called as each connection phase starts
+	onPhase func(connectPhase)
+}
+
+func newDialer(proxy *ProxyConfig, timeout time.Duration) *dialer {
+	return &dialer{proxy: proxy, timeout: timeout}
+}
+
+func (d *dialer) phase(p connectPhase) {
+	if d.onPhase != nil {
+		d.onPhase(p)
+	}
+}
+
+// Dial connects to addr, tunnelling through the proxy when one is configured
+func (d *dialer) Dial(addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), addr)
+}
+
+// DialContext connects to addr, tunnelling through the proxy when one is
+// configured. Errors are *connectError values naming the phase that failed.
+func (d *dialer) DialContext(ctx context.Context, addr string) (net.Conn, error) {
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+
+	target := addr
+	if d.proxy != nil {
+		target = d.proxy.Addr
+	}
+	conn, err := d.dialTCP(ctx, target)
+	if err != nil || d.proxy == nil {
+		return conn, err
+	}
+
+	// Abort the proxy negotiation if ctx ends first
+	d.phase(phaseProxy)
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	switch d.proxy.Type {
+	case ProxyTypeSOCKS5:
+		err = socks5Connect(conn, addr, d.proxy.Username, d.proxy.Password)
+	case ProxyTypeHTTP:
+		conn, err = httpConnect(conn, addr, d.proxy.Username, d.proxy.Password)
+	default:
+		err = fmt.Errorf("unknown proxy type %q", d.proxy.Type)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, phaseError(ctx, phaseProxy, fmt.Errorf("%s: %w", d.proxy.Addr, err))
+	}
+
+	return conn, nil
+}
+
+// dialTCP resolves addr and connects to the first address that answers
+func (d *dialer) dialTCP(ctx context.Context, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, &connectError{phase: phaseDNS, err: err}
+	}
+
+	ips := []string{host}
+	if net.ParseIP(host) == nil {
+		d.phase(phaseDNS)
+		ips, err = net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			return nil, phaseError(ctx, phaseDNS, err)
+		}
+	}
+
+	d.phase(phaseTCP)
+	var nd net.Dialer
+	for _, ip := range ips {
+		var conn net.Conn
+		conn, err = nd.DialContext(ctx, "tcp", net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, phaseError(ctx, phaseTCP, err)
+}
+
+// DialTLS connects to addr and completes a TLS handshake over the connection
+func (d *dialer) DialTLS(addr string, cfg *tls.Config) (net.Conn, error) {
+	return d.DialTLSContext(context.Background(), addr, cfg)
+}
+
+// DialTLSContext connects to addr and completes a TLS handshake over the connection
+func (d *dialer) DialTLSContext(ctx context.Context, addr string, cfg *tls.Config) (net.Conn, error) {
+	conn, err := d.DialContext(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+
+	d.phase(phaseTLS)
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		_ = conn.Close()
+		return nil, phaseError(ctx, phaseTLS, err)
+	}
+
+	return tlsConn, nil
+}
+
+// SOCKS5 protocol constants (RFC 1928, RFC 1929)
+const (
+	socks5Version          = 0x05
+	socks5AuthNone         = 0x00
+	socks5AuthPassword     = 0x02
+	socks5AuthNoAcceptable = 0xFF
+	socks5CmdConnect       = 0x01
+	socks5AddrIPv4         = 0x01
+	socks5AddrDomain       = 0x03
+	socks5AddrIPv6         = 0x04
+)
+
+var socks5Replies = map[byte]string{
+	0x01: "general SOCKS server failure",
+	0x02: "connection not allowed by ruleset",
+	0x03: "network unreachable",
+	0x04: "host unreachable",
+	0x05: "connection refused",
+	0x06: "TTL expired",
+	0x07: "command not supported",
+	0x08: "address type not supported",
+}
+
+// socks5Connect asks a SOCKS5 proxy to open a tunnel to addr. Hostnames are
+// sent unresolved so DNS lookups happen on the proxy side.
+func socks5Connect(conn net.Conn, addr, username, password string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid port %q", portStr)
+	}
+
+	// Method negotiation
+	methods := []byte{socks5AuthNone}
+	if username != "" {
+		methods = append(methods, socks5AuthPassword)
+	}
+	greeting := append([]byte{socks5Version, byte(len(methods))}, methods...)
+	if _, err := conn.Write(greeting); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[0] != socks5Version {
+		return fmt.Errorf("unexpected SOCKS version %d", reply[0])
+	}
+
+	switch reply[1] {
+	case socks5AuthNone:
+	case socks5AuthPassword:
+		if len(username) > 255 || len(password) > 255 {
+			return errors.New("SOCKS5 username or password too long")
+		}
+		auth := []byte{0x01, byte(len(username))}
+		auth = append(auth, username...)
+		auth = append(auth, byte(len(password)))
+		auth = append(auth, password...)
+		if _, err := conn.Write(auth); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return err
+		}
+		if reply[1] != 0x00 {
+			return errors.New("SOCKS5 authentication failed")
+		}
+	case socks5AuthNoAcceptable:
+		return errors.New("SOCKS5 proxy rejected the authentication methods offered")
+	default:
+		return fmt.Errorf("SOCKS5 proxy chose unsupported authentication method %d", reply[1])
+	}
+
+	// Connect request
+	req := []byte{socks5Version, socks5CmdConnect, 0x00}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			req = append(req, socks5AddrIPv4)
+			req = append(req, ip4...)
+		} else {
+			req = append(req, socks5AddrIPv6)
+			req = append(req, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return fmt.Errorf("hostname too long: %s", host)
+		}
+		req = append(req, socks5AddrDomain, byte(len(host)))
+		req = append(req, host...)
+	}
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	// Reply: VER REP RSV ATYP BND.ADDR BND.PORT
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[1] != 0x00 {
+		if text, ok := socks5Replies[header[1]]; ok {
+			return fmt.Errorf("SOCKS5 connect to %s failed: %s", addr, text)
+		}
+		return fmt.Errorf("SOCKS5 connect to %s failed with code %d", addr, header[1])
+	}
+
+	var bindLen int
+	switch header[3] {
+	case socks5AddrIPv4:
+		bindLen = net.IPv4len
+	case socks5AddrIPv6:
+		bindLen = net.IPv6len
+	case socks5AddrDomain:
+		l := make([]byte, 1)
+		if _, err := io.ReadFull(conn, l); err != nil {
+			return err
+		}
+		bindLen = int(l[0])
+	default:
+		return fmt.Errorf("unexpected SOCKS5 address type %d", header[3])
+	}
+	if _, err := io.ReadFull(conn, make([]byte, bindLen+2)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// httpConnect asks an HTTP proxy to open a tunnel to addr with the CONNECT method
+func httpConnect(conn net.Conn, addr, username, password string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if username != "" {
+		creds := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+creds)
+	}
+	if err := req.Write(conn); err != nil {
+		return conn, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return conn, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return conn, fmt.Errorf("CONNECT to %s failed: %s", addr, resp.Status)
+	}
+
+	// Keep any bytes the proxy sent past the response headers
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a net.Conn whose reads drain a bufio.Reader first
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
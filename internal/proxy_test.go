@@ -0,0 +1,193 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// fakeProxy runs serve against the far end of a pipe and returns the near end
+// and a channel with serve's error
+func fakeProxy(t *testing.T, serve func(conn net.Conn) error) (net.Conn, <-chan error) {
+	t.Helper()
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(server)
+	}()
+	return client, done
+}
+
+// expect reads len(want) bytes from conn and checks they match
+func expect(conn net.Conn, want []byte) error {
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(conn, got); err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("got %q, want %q", got, want)
+	}
+	return nil
+}
+
+func TestSocks5ConnectDomain(t *testing.T) {
+	conn, done := fakeProxy(t, func(conn net.Conn) error {
+		if err := expect(conn, []byte{5, 1, 0}); err != nil {
+			return err
+		}
+		if _, err := conn.Write([]byte{5, 0}); err != nil {
+			return err
+		}
+		// The hostname is passed unresolved, with port 5500
+		want := append([]byte{5, 1, 0, 3, byte(len("hotline.example.com"))}, "hotline.example.com"...)
+		if err := expect(conn, append(want, 0x15, 0x7c)); err != nil {
+			return err
+		}
+		_, err := conn.Write([]byte{5, 0, 0, 1, 10, 0, 0, 1, 0x15, 0x7c})
+		return err
+	})
+
+	if err := socks5Connect(conn, "hotline.example.com:5500", "", ""); err != nil {
+		t.Fatalf("socks5Connect: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("proxy: %v", err)
+	}
+}
+
+func TestSocks5ConnectPassword(t *testing.T) {
+	conn, done := fakeProxy(t, func(conn net.Conn) error {
+		if err := expect(conn, []byte{5, 2, 0, 2}); err != nil {
+			return err
+		}
+		if _, err := conn.Write([]byte{5, 2}); err != nil {
+			return err
+		}
+		if err := expect(conn, []byte("\x01\x05alice\x06secret")); err != nil {
+			return err
+		}
+		if _, err := conn.Write([]byte{1, 0}); err != nil {
+			return err
+		}
+		want := []byte{5, 1, 0, 4, 0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0x15, 0x7c}
+		if err := expect(conn, want); err != nil {
+			return err
+		}
+		// A domain name bind address
+		_, err := conn.Write([]byte{5, 0, 0, 3, 5, 'p', 'r', 'o', 'x', 'y', 0, 80})
+		return err
+	})
+
+	if err := socks5Connect(conn, "[2001:db8::1]:5500", "alice", "secret"); err != nil {
+		t.Fatalf("socks5Connect: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("proxy: %v", err)
+	}
+}
+
+func TestSocks5ConnectErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies [][]byte // Written after each read of the client's next message
+		wantErr string
+	}{
+		{
+			name:    "no acceptable method",
+			replies: [][]byte{{5, 0xff}},
+			wantErr: "rejected the authentication methods",
+		},
+		{
+			name:    "bad password",
+			replies: [][]byte{{5, 2}, {1, 1}},
+			wantErr: "authentication failed",
+		},
+		{
+			name:    "connection refused",
+			replies: [][]byte{{5, 0}, {5, 5, 0, 1}},
+			wantErr: "connection refused",
+		},
+		{
+			name:    "wrong version",
+			replies: [][]byte{{4, 0}},
+			wantErr: "unexpected SOCKS version",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, _ := fakeProxy(t, func(conn net.Conn) error {
+				buf := make([]byte, 512)
+				for _, reply := range tt.replies {
+					if _, err := conn.Read(buf); err != nil {
+						return err
+					}
+					if _, err := conn.Write(reply); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+
+			err := socks5Connect(conn, "10.0.0.1:5500", "alice", "wrong")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("socks5Connect error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPConnect(t *testing.T) {
+	conn, done := fakeProxy(t, func(conn net.Conn) error {
+		br := bufio.NewReader(conn)
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			return err
+		}
+		if req.Method != http.MethodConnect || req.Host != "hotline.example.com:5500" {
+			return fmt.Errorf("got %s %s, want CONNECT hotline.example.com:5500", req.Method, req.Host)
+		}
+		if auth := req.Header.Get("Proxy-Authorization"); auth != "Basic YWxpY2U6c2VjcmV0" {
+			return fmt.Errorf("got Proxy-Authorization %q", auth)
+		}
+		// The server's first bytes arrive in the same write as the response
+		_, err = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\nTRTP"))
+		return err
+	})
+
+	tunnel, err := httpConnect(conn, "hotline.example.com:5500", "alice", "secret")
+	if err != nil {
+		t.Fatalf("httpConnect: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("proxy: %v", err)
+	}
+
+	got := make([]byte, 4)
+	if _, err := io.ReadFull(tunnel, got); err != nil || string(got) != "TRTP" {
+		t.Fatalf("read through tunnel = %q, %v; want TRTP", got, err)
+	}
+}
+
+func TestHTTPConnectRefused(t *testing.T) {
+	conn, _ := fakeProxy(t, func(conn net.Conn) error {
+		if _, err := http.ReadRequest(bufio.NewReader(conn)); err != nil {
+			return err
+		}
+		_, err := conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\nContent-Length: 0\r\n\r\n"))
+		return err
+	})
+
+	_, err := httpConnect(conn, "hotline.example.com:5500", "", "")
+	if err == nil || !strings.Contains(err.Error(), "407") {
+		t.Fatalf("httpConnect error = %v, want 407", err)
+	}
+}
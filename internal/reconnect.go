@@ -0,0 +1,281 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jhalter/mobius-hotline-client/internal/style"
+	"github.com/jhalter/mobius/hotline"
+)
+
+const (
+	reconnectBaseDelay   = 2 * time.Second
+	reconnectMaxDelay    = 60 * time.Second
+	reconnectMaxAttempts = 10
+)
+
+// connectionParams holds everything needed to (re)establish a server connection
+type connectionParams struct {
+	name     string
+	addr     string
+	login    string
+	password string
+	useTLS   bool
+
+	// TLS verification (from the bookmark)
+	tlsCAFile string
+	tlsStrict bool
+
+	// Proxy to connect through, nil to connect directly
+	proxy *ProxyConfig
+
+	// File transfer endpoint overrides (from the bookmark)
+	transfer transferEndpoint
+
+	// Name of the bookmark connected with, for its ignore list
+	bookmark string
+
+	// Text encoding of the server's strings
+	encoding string
+}
+
+// resumeLocation records the files or news location open when the connection dropped
+type resumeLocation struct {
+	screen       Screen // ScreenFiles, ScreenNews, or ScreenServerUI when there is nothing to reopen
+	filePath     []string
+	newsPath     []string
+	newsCategory bool
+}
+
+// reconnectState tracks an automatic reconnect in progress
+type reconnectState struct {
+	params     connectionParams
+	resume     resumeLocation
+	attempt    int       // Current attempt number, starting at 1
+	nextTry    time.Time // When the current attempt is due
+	connecting bool      // An attempt is in flight
+}
+
+// reconnectTickMsg drives the reconnect countdown
+type reconnectTickMsg struct {
+	state *reconnectState
+}
+
+// reconnectAttemptMsg is sent after a reconnect attempt's joinServer() completes
+type reconnectAttemptMsg struct {
+	state *reconnectState
+	err   error
+}
+
+// reconnectDelay returns the exponential backoff delay before the given
+// attempt. A random half of it is taken off, so clients dropped together by a
+// server restart don't all come back at once.
+func reconnectDelay(attempt int) time.Duration {
+	delay := reconnectBaseDelay
+	for i := 1; i < attempt && delay < reconnectMaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, reconnectMaxDelay)
+	return delay - time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// status returns the countdown text shown on the loading screen
+func (r *reconnectState) status() string {
+	if r.connecting {
+		return fmt.Sprintf("Reconnecting to %s (attempt %d of %d)...", r.params.name, r.attempt, reconnectMaxAttempts)
+	}
+	remaining := time.Until(r.nextTry).Round(time.Second)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return fmt.Sprintf("Connection lost. Reconnecting to %s in %s (attempt %d of %d)", r.params.name, remaining, r.attempt, reconnectMaxAttempts)
+}
+
+// shouldReconnect reports whether an unexpected disconnect should be retried
+func (m *Model) shouldReconnect() bool {
+	return m.activeConnection != nil && m.serverScreen != nil
+}
+
+// currentResumeLocation captures the files or news location the user is viewing
+func (m *Model) currentResumeLocation() resumeLocation {
+	loc := resumeLocation{screen: ScreenServerUI}
+	for _, screen := range m.screenHistory {
+		switch screen {
+		case ScreenFiles:
+			if m.filesScreen != nil {
+				loc.screen = ScreenFiles
+				loc.filePath = m.filesScreen.GetFilePath()
+			}
+		case ScreenNews:
+			if m.newsScreen != nil {
+				loc.screen = ScreenNews
+				loc.newsPath = m.newsScreen.GetPath()
+				loc.newsCategory = m.newsScreen.isViewingCategory
+			}
+		}
+	}
+	return loc
+}
+
+// scheduleReconnect starts (or continues) the reconnect supervisor after a dropped connection
+func (m *Model) scheduleReconnect() tea.Cmd {
+	if m.reconnect == nil {
+		m.reconnect = &reconnectState{
+			params: *m.activeConnection,
+			resume: m.currentResumeLocation(),
+		}
+		m.serverScreen.AddChatMessage(style.NoticeStyle.Render("Connection lost"))
+	}
+
+	r := m.reconnect
+	r.attempt++
+	r.connecting = false
+	if r.attempt > reconnectMaxAttempts {
+		m.cancelReconnect()
+		return func() tea.Msg {
+			return errorMsg{text: fmt.Sprintf("Server connection closed. Gave up after %d reconnect attempts.", reconnectMaxAttempts)}
+		}
+	}
+	delay := reconnectDelay(r.attempt)
+	r.nextTry = time.Now().Add(delay)
+	m.logger.Info("Scheduling reconnect", "server", r.params.addr, "attempt", r.attempt, "delay", delay)
+
+	// Keep the server screen underneath so chat scrollback is preserved
+	var loadingCmd tea.Cmd
+	m.loadingScreen, loadingCmd = NewLoadingScreen(r.status(), m)
+	m.NavigateTo(ScreenServerUI)
+	m.PushScreen(ScreenLoading)
+
+	return tea.Batch(loadingCmd, reconnectTick(r))
+}
+
+// cancelReconnect stops the supervisor and forgets the dropped connection
+func (m *Model) cancelReconnect() {
+	m.reconnect = nil
+	m.activeConnection = nil
+	m.NavigateTo(ScreenHome)
+}
+
+func reconnectTick(r *reconnectState) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return reconnectTickMsg{state: r}
+	})
+}
+
+func (m *Model) handleReconnectTickMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	tick := msg.(reconnectTickMsg)
+	r := m.reconnect
+	if r == nil || tick.state != r || r.connecting {
+		return m, nil
+	}
+
+	if time.Now().Before(r.nextTry) {
+		if m.loadingScreen != nil {
+			m.loadingScreen.SetMessage(r.status())
+		}
+		return m, reconnectTick(r)
+	}
+
+	r.connecting = true
+	if m.loadingScreen != nil {
+		m.loadingScreen.SetMessage(r.status())
+	}
+	m.pendingServerName = r.params.name
+	params := r.params
+	m.pendingConnection = &params
+	s := m.Session
+	ctx := s.beginConnection()
+	return m, func() tea.Msg {
+		err := m.joinServer(ctx, s, params)
+		return reconnectAttemptMsg{state: r, err: err}
+	}
+}
+
+func (m *Model) handleReconnectAttemptMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	attempt := msg.(reconnectAttemptMsg)
+	if attempt.state != m.reconnect {
+		// The user gave up while this attempt was in flight; hang up if it got through
+		if attempt.err == nil {
+			m.clientDisconnecting = true
+			c := m.hlClient
+			return m, func() tea.Msg {
+				_ = c.Disconnect()
+				return nil
+			}
+		}
+		return m, nil
+	}
+	if attempt.err != nil {
+		m.logger.Error("Reconnect attempt failed", "attempt", m.reconnect.attempt, "err", attempt.err)
+
+		// Retrying won't help if the server's certificate no longer checks out
+		if isCertError(attempt.err) {
+			m.cancelReconnect()
+			m.pendingConnection = nil
+			return m, func() tea.Msg {
+				return errorMsg{text: attempt.err.Error()}
+			}
+		}
+		return m, m.scheduleReconnect()
+	}
+	// Connected - keep the countdown up until serverConnectedMsg arrives
+	return m, nil
+}
+
+// finishReconnect restores the server screen and reopens the previous files or
+// news location. The returned command navigates there as the user would, so the
+// listing is requested from, and only delivered to, the reopened screen.
+func (m *Model) finishReconnect() tea.Cmd {
+	r := m.reconnect
+	m.reconnect = nil
+	m.autoAgree = true
+	m.resumed = true
+
+	m.NavigateTo(ScreenServerUI)
+	m.serverScreen.AddChatMessage(style.NoticeStyle.Render("Reconnected"))
+	m.logChat("--- Reconnected ---")
+
+	var navigate tea.Msg
+	switch r.resume.screen {
+	case ScreenFiles:
+		m.PushScreen(ScreenFiles)
+		navigate = FilesNavigateMsg{Path: r.resume.filePath}
+	case ScreenNews:
+		m.newsScreen.newsPath = r.resume.newsPath
+		m.PushScreen(ScreenNews)
+		if r.resume.newsCategory {
+			navigate = NewsNavigateToCategoryMsg{Path: r.resume.newsPath}
+		} else {
+			navigate = NewsNavigateToBundleMsg{Path: r.resume.newsPath}
+		}
+	default:
+		return nil
+	}
+	return func() tea.Msg { return navigate }
+}
+
+// endResume forgets that a reconnect is being resumed, once the agreement is
+// accepted or the connection drops again
+func (m *Model) endResume() {
+	m.autoAgree = false
+	m.resumed = false
+}
+
+// agreeToServer accepts the server agreement on the user's behalf
+func (m *Model) agreeToServer() tea.Cmd {
+	s := m.Session
+	name := m.encodeText(m.prefs.Username)
+	return func() tea.Msg {
+		_ = s.sendTransaction(hotline.NewTransaction(
+			hotline.TranAgreed,
+			[2]byte{},
+			hotline.NewField(hotline.FieldUserName, name),
+			hotline.NewField(hotline.FieldUserIconID, m.prefs.IconBytes()),
+			hotline.NewField(hotline.FieldUserFlags, []byte{0x00, 0x00}),
+			hotline.NewField(hotline.FieldOptions, []byte{0x00, 0x00}),
+		))
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		max     time.Duration // Delay before jitter
+	}{
+		{attempt: 1, max: 2 * time.Second},
+		{attempt: 2, max: 4 * time.Second},
+		{attempt: 3, max: 8 * time.Second},
+		{attempt: 5, max: 32 * time.Second},
+		{attempt: 6, max: reconnectMaxDelay},
+		{attempt: reconnectMaxAttempts, max: reconnectMaxDelay},
+		{attempt: 100, max: reconnectMaxDelay},
+	}
+	for _, tt := range tests {
+		seen := make(map[time.Duration]bool)
+		for range 200 {
+			d := reconnectDelay(tt.attempt)
+			if d < tt.max/2 || d > tt.max {
+				t.Fatalf("reconnectDelay(%d) = %v, want %v to %v", tt.attempt, d, tt.max/2, tt.max)
+			}
+			seen[d] = true
+		}
+		if len(seen) < 2 {
+			t.Errorf("reconnectDelay(%d) has no jitter", tt.attempt)
+		}
+	}
+}
+
+func TestFinishReconnectReopensFiles(t *testing.T) {
+	m, s := newTestModel(t, &Settings{Username: "tester"})
+	m.serverScreen = NewServerScreen(m)
+	m.filesScreen = NewFilesScreen(m)
+	m.reconnect = &reconnectState{resume: resumeLocation{screen: ScreenFiles, filePath: []string{"Uploads"}}}
+
+	cmd := m.finishReconnect()
+	if m.CurrentScreen() != ScreenFiles || !m.autoAgree || !m.resumed {
+		t.Fatalf("screen %v, autoAgree %v, resumed %v; want the files screen, resuming", m.CurrentScreen(), m.autoAgree, m.resumed)
+	}
+
+	// The folder is requested by the same message the files screen sends when
+	// the user opens it, so it goes through the session's request registry
+	msg, ok := m.sessionCmd(s, cmd)().(sessionMsg)
+	if nav, isNav := msg.msg.(FilesNavigateMsg); !ok || msg.session != s || !isNav || len(nav.Path) != 1 || nav.Path[0] != "Uploads" {
+		t.Fatalf("reopen message = %#v, want FilesNavigateMsg for Uploads tagged with the session", msg)
+	}
+
+	// Accepting the agreement ends the resume
+	m.handleServerAgreedMsg(serverAgreedMsg{})
+	if m.autoAgree || m.resumed {
+		t.Errorf("after the agreement, autoAgree %v, resumed %v", m.autoAgree, m.resumed)
+	}
+	if m.CurrentScreen() != ScreenFiles {
+		t.Errorf("agreement left screen %v, want the files screen kept", m.CurrentScreen())
+	}
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// replayMaxGap caps the pause between replayed transactions so long idle
+// stretches in a trace don't stall the replay
+const replayMaxGap = 2 * time.Second
+
+// EnableTrace records every transaction of every server connection to path
+func (m *Model) EnableTrace(path string) error {
+	tr, err := newTracer(path)
+	if err != nil {
+		return err
+	}
+	m.tracer = tr
+	return nil
+}
+
+// EnableReplay makes the client replay the trace at path instead of connecting to a server
+func (m *Model) EnableReplay(path string) error {
+	records, err := readTrace(path)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("%s contains no transactions", path)
+	}
+	m.replayName = filepath.Base(path)
+	m.replayRecords = records
+	return nil
+}
+
+// startReplay connects the active session to an in-memory pipe and feeds it the
+// server side of the trace. The registered transaction handlers process the
+// replayed transactions exactly as they would from a real server.
+func (m *Model) startReplay() tea.Cmd {
+	s := m.Session
+	name := "Replay: " + m.replayName
+	m.pendingServerName = name
+	m.pendingServerAddr = name
+	m.pendingConnection = &connectionParams{name: name, addr: name}
+	s.text.setEncoding(m.prefs.encodingFor(nil))
+
+	var loadingCmd tea.Cmd
+	m.loadingScreen, loadingCmd = NewLoadingScreen("Replaying trace...", m)
+	m.PushScreen(ScreenLoading)
+
+	client, server := net.Pipe()
+	s.hlClient.Connection = client
+	s.clearSentTransactions()
+	ctx := s.beginConnection()
+
+	records := m.replayRecords
+	go func() {
+		err := m.handleTransactions(ctx, s)
+		m.logger.Error("Transaction scanning failed", "err", err)
+		m.sendTo(s, disconnectMsg{})
+	}()
+	go m.feedReplay(s, server, records)
+
+	return loadingCmd
+}
+
+// feedReplay plays back the trace. Recorded client requests are registered with
+// the client so the replies that follow are routed to their handlers; whatever
+// the client sends during the replay is discarded.
+func (m *Model) feedReplay(s *Session, server net.Conn, records []traceRecord) {
+	go func() { _, _ = io.Copy(io.Discard, server) }()
+
+	// A trace can cover several sessions; replay the first server's
+	replayed := records[0].Server
+
+	var last time.Time
+	for i, rec := range records {
+		if rec.Server != replayed {
+			continue
+		}
+		if !last.IsZero() {
+			gap := rec.Time.Sub(last)
+			if gap > replayMaxGap {
+				gap = replayMaxGap
+			}
+			if gap > 0 {
+				time.Sleep(gap)
+			}
+		}
+		last = rec.Time
+
+		t, err := rec.transaction()
+		if err != nil {
+			m.logger.Error("Skipping unreadable trace record", "record", i+1, "err", err)
+			continue
+		}
+
+		switch rec.Dir {
+		case traceOut:
+			if !rec.Reply {
+				if err := s.sendTransaction(t); err != nil {
+					m.logger.Error("Replay stopped", "err", err)
+					return
+				}
+			}
+		case traceIn:
+			if _, err := io.Copy(server, &t); err != nil {
+				m.logger.Error("Replay stopped", "err", err)
+				return
+			}
+		}
+	}
+
+	m.logger.Info("Replay finished", "transactions", len(records))
+}
@@ -0,0 +1,209 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jhalter/mobius/hotline"
+)
+
+// requestTimeout is how long to wait for the server to reply to a request
+const requestTimeout = 30 * time.Second
+
+// pendingRequest is a sent transaction waiting for its reply
+type pendingRequest struct {
+	label    string    // Describes the request in timeout errors, e.g. "file list"
+	deadline time.Time // When the request times out
+	origin   Screen    // Screen that sent the request
+	depth    int       // Depth of origin in the screen history
+	taskID   string    // Transfer task waiting on the reply; task replies are never stale
+	expired  bool      // Timed out; the reply is dropped if it still arrives
+	timer    *time.Timer
+}
+
+// requestRegistry correlates a session's sent transactions with their replies.
+// It is shared between the UI and the transaction handler goroutine.
+type requestRegistry struct {
+	mu      sync.Mutex
+	pending map[[4]byte]*pendingRequest
+}
+
+func newRequestRegistry() *requestRegistry {
+	return &requestRegistry{pending: make(map[[4]byte]*pendingRequest)}
+}
+
+func (r *requestRegistry) add(id [4]byte, req *pendingRequest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[id] = req
+}
+
+// has reports whether id is a registered request, including one that timed out
+func (r *requestRegistry) has(id [4]byte) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.pending[id]
+	return ok
+}
+
+// take removes and returns the request for id
+func (r *requestRegistry) take(id [4]byte) *pendingRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	req, ok := r.pending[id]
+	if !ok {
+		return nil
+	}
+	delete(r.pending, id)
+	req.timer.Stop()
+	return req
+}
+
+// expire marks the request for id as timed out, returning it if it was still waiting
+func (r *requestRegistry) expire(id [4]byte) *pendingRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	req, ok := r.pending[id]
+	if !ok || req.expired || time.Now().Before(req.deadline) {
+		return nil
+	}
+	req.expired = true
+	return req
+}
+
+// clear forgets all requests, for when the connection closes
+func (r *requestRegistry) clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, req := range r.pending {
+		req.timer.Stop()
+		delete(r.pending, id)
+	}
+}
+
+// replyMsg carries the UI message built from a reply to a registered request
+type replyMsg struct {
+	id  [4]byte
+	msg tea.Msg // nil when the reply has nothing to show
+}
+
+// requestTimeoutMsg is sent when a registered request's deadline passes
+type requestTimeoutMsg struct {
+	id [4]byte
+}
+
+// request sends t on the active session and registers it so that its reply is
+// only delivered while the current screen is still open
+func (m *Model) request(t hotline.Transaction, label string) error {
+	return m.sendRequest(m.Session, t, &pendingRequest{
+		label:  label,
+		origin: m.CurrentScreen(),
+		depth:  len(m.screenHistory) - 1,
+	})
+}
+
+// requestForTask sends t and registers it on behalf of a transfer task. It is safe
+// to call from any goroutine.
+func (m *Model) requestForTask(s *Session, t hotline.Transaction, label, taskID string) error {
+	return m.sendRequest(s, t, &pendingRequest{label: label, taskID: taskID})
+}
+
+func (m *Model) sendRequest(s *Session, t hotline.Transaction, req *pendingRequest) error {
+	req.deadline = time.Now().Add(requestTimeout)
+	req.timer = time.AfterFunc(requestTimeout, func() {
+		m.sendTo(s, requestTimeoutMsg{id: t.ID})
+	})
+
+	// Register before sending so a fast reply can't arrive first
+	s.requests.add(t.ID, req)
+	if err := s.sendTransaction(t); err != nil {
+		s.requests.take(t.ID)
+		return err
+	}
+	return nil
+}
+
+// reply sends msg to the UI for the reply t. Replies to registered requests are
+// wrapped in a replyMsg so stale and timed out replies can be dropped.
+func (m *Model) reply(c *hotline.Client, t *hotline.Transaction, msg tea.Msg) {
+	if s := m.sessionFor(c); s != nil && s.requests.has(t.ID) {
+		m.send(c, replyMsg{id: t.ID, msg: msg})
+		return
+	}
+	if msg != nil {
+		m.send(c, msg)
+	}
+}
+
+// isCurrent reports whether the screen that sent req is still open. Screens pushed
+// over it, such as a private message modal, don't make the reply stale; only
+// navigating away from the origin does.
+func (req *pendingRequest) isCurrent(s *Session) bool {
+	if req.taskID != "" {
+		return true
+	}
+	return req.depth >= 0 && req.depth < len(s.screenHistory) && s.screenHistory[req.depth] == req.origin
+}
+
+// isStale reports whether reply should be dropped because the user navigated away
+// from the screen that sent req. Errors are never stale so they can't go unseen.
+func (req *pendingRequest) isStale(s *Session, reply tea.Msg) bool {
+	if _, ok := reply.(errorMsg); ok {
+		return false
+	}
+	return !req.isCurrent(s)
+}
+
+func (m *Model) handleReplyMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	r := msg.(replyMsg)
+	req := m.requests.take(r.id)
+	if req == nil || req.expired || r.msg == nil {
+		return m, nil
+	}
+	if req.isStale(m.Session, r.msg) {
+		m.logger.Debug("Dropping stale reply", "request", req.label)
+		return m, nil
+	}
+
+	// Hand transfer replies the task they belong to
+	switch inner := r.msg.(type) {
+	case downloadReplyMsg:
+		inner.taskID = req.taskID
+		r.msg = inner
+	case uploadReplyMsg:
+		inner.taskID = req.taskID
+		r.msg = inner
+	case errorMsg:
+		if req.taskID != "" {
+			m.failTask(req.taskID, inner.text)
+		}
+	}
+
+	return m.update(r.msg)
+}
+
+func (m *Model) handleRequestTimeoutMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	timeout := msg.(requestTimeoutMsg)
+	req := m.requests.expire(timeout.id)
+	if req == nil {
+		return m, nil
+	}
+
+	text := fmt.Sprintf("The server did not respond to the %s request.", req.label)
+	m.logger.Error("Request timed out", "request", req.label)
+	if req.taskID != "" {
+		m.failTask(req.taskID, text)
+	}
+
+	return m, func() tea.Msg {
+		return errorMsg{text: text}
+	}
+}
+
+// failTask marks a transfer task as failed
+func (m *Model) failTask(taskID, reason string) {
+	m.update(taskStatusMsg{taskID: taskID, status: TaskFailed, err: errors.New(reason)})
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestRequest(origin Screen, depth int, timeout time.Duration) *pendingRequest {
+	return &pendingRequest{
+		label:    "file list",
+		deadline: time.Now().Add(timeout),
+		origin:   origin,
+		depth:    depth,
+		timer:    time.NewTimer(time.Hour),
+	}
+}
+
+func TestRequestRegistryAddTake(t *testing.T) {
+	r := newRequestRegistry()
+	id := [4]byte{0, 0, 0, 1}
+	req := newTestRequest(ScreenServerUI, 1, requestTimeout)
+	r.add(id, req)
+
+	if !r.has(id) {
+		t.Fatal("has = false after add")
+	}
+	if got := r.take(id); got != req {
+		t.Fatalf("take = %v, want the added request", got)
+	}
+	if r.has(id) || r.take(id) != nil {
+		t.Fatal("request still registered after take")
+	}
+}
+
+func TestRequestRegistryExpire(t *testing.T) {
+	r := newRequestRegistry()
+	waiting := [4]byte{0, 0, 0, 1}
+	overdue := [4]byte{0, 0, 0, 2}
+	r.add(waiting, newTestRequest(ScreenServerUI, 1, requestTimeout))
+	r.add(overdue, newTestRequest(ScreenServerUI, 1, -time.Second))
+
+	if r.expire(waiting) != nil {
+		t.Error("expired a request before its deadline")
+	}
+	req := r.expire(overdue)
+	if req == nil || !req.expired {
+		t.Fatal("overdue request was not expired")
+	}
+	if r.expire(overdue) != nil {
+		t.Error("expired the same request twice")
+	}
+
+	// A late reply still finds the request, so it can be dropped quietly
+	if !r.has(overdue) {
+		t.Error("expired request was forgotten")
+	}
+
+	r.clear()
+	if r.has(waiting) || r.has(overdue) {
+		t.Error("clear left requests registered")
+	}
+}
+
+func TestPendingRequestIsStale(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []Screen
+		reply   any
+		stale   bool
+	}{
+		{
+			name:    "origin still open",
+			history: []Screen{ScreenHome, ScreenServerUI},
+			reply:   filesMsg{},
+		},
+		{
+			name:    "modal pushed over origin",
+			history: []Screen{ScreenHome, ScreenServerUI, ScreenModal},
+			reply:   filesMsg{},
+		},
+		{
+			name:    "navigated back from origin",
+			history: []Screen{ScreenHome},
+			reply:   filesMsg{},
+			stale:   true,
+		},
+		{
+			name:    "origin replaced by another screen",
+			history: []Screen{ScreenHome, ScreenNews},
+			reply:   filesMsg{},
+			stale:   true,
+		},
+		{
+			name:    "error after navigating away",
+			history: []Screen{ScreenHome},
+			reply:   errorMsg{text: "Permission denied"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestRequest(ScreenServerUI, 1, requestTimeout)
+			s := &Session{screenHistory: tt.history}
+			if got := req.isStale(s, tt.reply); got != tt.stale {
+				t.Errorf("isStale = %v, want %v", got, tt.stale)
+			}
+		})
+	}
+}
+
+func TestPendingRequestForTaskIsNeverStale(t *testing.T) {
+	req := &pendingRequest{label: "download", taskID: "task"}
+	s := &Session{screenHistory: []Screen{ScreenHome}}
+	if req.isStale(s, downloadReplyMsg{}) {
+		t.Error("task reply was stale")
+	}
+}
@@ -620,10 +620,11 @@ func (s *AccountsScreen) ResetEditState() {
 
 // HandleListUsers handles the transaction response for listing user accounts
 func (m *Model) HandleListUsers(ctx context.Context, c *hotline.Client, t *hotline.Transaction) (res []hotline.Transaction, err error) {
-	if m.checkTransactionError(t) {
+	if m.checkTransactionError(c, t) {
 		return nil, nil
 	}
 
+	s := m.sessionFor(c)
 	var accounts []accountItem
 
 	// Each FieldData contains one account
@@ -655,9 +656,9 @@ func (m *Model) HandleListUsers(ctx context.Context, c *hotline.Client, t *hotli
 
 			switch subField.Type {
 			case hotline.FieldUserLogin:
-				acct.login = string(hotline.EncodeString(subField.Data))
+				acct.login = s.decodeText(hotline.EncodeString(subField.Data))
 			case hotline.FieldUserName:
-				acct.name = string(subField.Data)
+				acct.name = s.decodeText(subField.Data)
 			case hotline.FieldUserAccess:
 				if len(subField.Data) >= 8 {
 					copy(acct.access[:], subField.Data)
@@ -670,18 +671,19 @@ func (m *Model) HandleListUsers(ctx context.Context, c *hotline.Client, t *hotli
 		accounts = append(accounts, acct)
 	}
 
-	m.program.Send(accountListMsg{accounts: accounts})
+	m.reply(c, t, accountListMsg{accounts: accounts})
 	return res, err
 }
 
 // submitAccountChanges submits account updates to the server
 func (m *Model) submitAccountChanges(msg AccountsSaveMsg) tea.Cmd {
+	s := m.Session
 	return func() tea.Msg {
 		// Build sub-fields
 		subFields := []hotline.Field{
 			hotline.NewField(hotline.FieldUserLogin,
-				hotline.EncodeString([]byte(msg.Login))),
-			hotline.NewField(hotline.FieldUserName, []byte(msg.Name)),
+				hotline.EncodeString(s.encodeText(msg.Login))),
+			hotline.NewField(hotline.FieldUserName, s.encodeText(msg.Name)),
 			hotline.NewField(hotline.FieldUserAccess, msg.AccessBits[:]),
 		}
 
@@ -689,7 +691,7 @@ func (m *Model) submitAccountChanges(msg AccountsSaveMsg) tea.Cmd {
 		if msg.PasswordChanged {
 			if len(msg.Password) > 0 {
 				subFields = append(subFields,
-					hotline.NewField(hotline.FieldUserPassword, []byte(msg.Password)))
+					hotline.NewField(hotline.FieldUserPassword, s.encodeText(msg.Password)))
 			}
 			// If password is empty and changed, don't include field (removes password)
 		} else {
@@ -710,7 +712,7 @@ func (m *Model) submitAccountChanges(msg AccountsSaveMsg) tea.Cmd {
 		}
 
 		// Send transaction
-		if err := m.hlClient.Send(hotline.NewTransaction(
+		if err := s.sendTransaction(hotline.NewTransaction(
 			hotline.TranUpdateUser,
 			[2]byte{},
 			hotline.NewField(hotline.FieldData, fieldData),
@@ -727,11 +729,12 @@ func (m *Model) submitAccountChanges(msg AccountsSaveMsg) tea.Cmd {
 
 // deleteAccount deletes the specified account from the server
 func (m *Model) deleteAccount(login string) tea.Cmd {
+	s := m.Session
 	return func() tea.Msg {
 		// For delete, send only FieldData with the login
-		loginData := hotline.EncodeString([]byte(login))
+		loginData := hotline.EncodeString(s.encodeText(login))
 
-		if err := m.hlClient.Send(hotline.NewTransaction(
+		if err := s.sendTransaction(hotline.NewTransaction(
 			hotline.TranUpdateUser,
 			[2]byte{},
 			hotline.NewField(hotline.FieldData, loginData),
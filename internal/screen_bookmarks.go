@@ -13,6 +13,25 @@ type Bookmark struct {
 	Login    string `yaml:"Login"`
 	Password string `yaml:"Password"`
 	TLS      bool   `yaml:"TLS"`
+
+	// TLSCAFile verifies the server certificate chain against this CA bundle instead of pinning it
+	TLSCAFile string `yaml:"TLSCAFile,omitempty"`
+	// TLSStrict requires normal chain verification against the system (or TLSCAFile) roots
+	TLSStrict bool `yaml:"TLSStrict,omitempty"`
+
+	// Proxy overrides the global proxy; use Type "none" to connect directly
+	Proxy *ProxyConfig `yaml:"Proxy,omitempty"`
+
+	// TransferHost and TransferPort override where file transfers connect, for servers
+	// behind port forwards or that transfer from another host (default: server port + 1)
+	TransferHost string `yaml:"TransferHost,omitempty"`
+	TransferPort int    `yaml:"TransferPort,omitempty"`
+
+	// Ignore adds name patterns to the global Ignore list on this server
+	Ignore []string `yaml:"Ignore,omitempty"`
+
+	// Encoding overrides the global Encoding, e.g. macroman for classic servers
+	Encoding string `yaml:"Encoding,omitempty"`
 }
 
 // Messages sent from BookmarkScreen to parent
@@ -0,0 +1,414 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jhalter/mobius-hotline-client/internal/style"
+)
+
+// Messages sent from ChatLogsScreen to parent
+
+// ChatLogsCancelledMsg signals user wants to close the chat log browser
+type ChatLogsCancelledMsg struct{}
+
+// chatLogResultsMsg delivers the matches of a chat log search
+type chatLogResultsMsg struct {
+	matches []chatLogMatch
+	err     error
+}
+
+// chatLogContextMsg delivers the log file around a match
+type chatLogContextMsg struct {
+	match chatLogMatch
+	lines []string
+	err   error
+}
+
+// chatLogsScreenKeyMap defines key bindings for the chat log browser help display
+type chatLogsScreenKeyMap struct {
+	Search key.Binding
+	Next   key.Binding
+	Up     key.Binding
+	Down   key.Binding
+	Open   key.Binding
+	Back   key.Binding
+}
+
+func (k chatLogsScreenKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Search, k.Next, k.Up, k.Down, k.Open, k.Back}
+}
+
+func (k chatLogsScreenKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Search, k.Next, k.Up, k.Down, k.Open, k.Back}}
+}
+
+// Chat log search fields, in tab order
+const (
+	chatLogFieldKeyword = iota
+	chatLogFieldUser
+	chatLogFieldFrom
+	chatLogFieldTo
+	chatLogFieldResults // Focus is on the results list
+)
+
+// ChatLogsScreen searches the chat logs of past sessions
+type ChatLogsScreen struct {
+	inputs        []textinput.Model
+	focus         int
+	results       viewport.Model
+	context       viewport.Model
+	width, height int
+	model         *Model
+	help          help.Model
+	keys          chatLogsScreenKeyMap
+
+	matches  []chatLogMatch
+	selected int
+	status   string        // Search summary or error
+	opened   *chatLogMatch // Match shown in context, nil when showing results
+}
+
+// NewChatLogsScreen creates the chat log browser
+func NewChatLogsScreen(m *Model) *ChatLogsScreen {
+	keys := chatLogsScreenKeyMap{
+		Search: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "search"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("tab", "shift+tab"),
+			key.WithHelp("tab", "next field"),
+		),
+		Up: key.NewBinding(
+			key.WithKeys("up"),
+			key.WithHelp("↑", "up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down"),
+			key.WithHelp("↓", "down"),
+		),
+		Open: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "open in context"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "back"),
+		),
+	}
+
+	placeholders := []string{"Keyword", "User", "From (YYYY-MM-DD)", "To (YYYY-MM-DD)"}
+	inputs := make([]textinput.Model, len(placeholders))
+	for i, p := range placeholders {
+		inputs[i] = textinput.New()
+		inputs[i].Placeholder = p
+		inputs[i].Prompt = ""
+		inputs[i].CharLimit = 64
+	}
+	inputs[chatLogFieldKeyword].Focus()
+
+	s := &ChatLogsScreen{
+		inputs:  inputs,
+		results: viewport.New(0, 0),
+		context: viewport.New(0, 0),
+		model:   m,
+		help:    help.New(),
+		keys:    keys,
+		status:  fmt.Sprintf("Logs are kept in %s", m.chatLog.Dir()),
+	}
+	s.SetSize(m.width, m.height)
+	s.updateKeys()
+	return s
+}
+
+// Init implements tea.Model
+func (s *ChatLogsScreen) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update implements ScreenModel
+func (s *ChatLogsScreen) Update(msg tea.Msg) (ScreenModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.SetSize(msg.Width, msg.Height)
+		return s, nil
+
+	case ChatLogsCancelledMsg:
+		s.model.PopScreen()
+		return s, nil
+
+	case chatLogResultsMsg:
+		s.showResults(msg)
+		return s, nil
+
+	case chatLogContextMsg:
+		s.showContext(msg)
+		return s, nil
+
+	case tea.KeyMsg:
+		return s.handleKeys(msg)
+	}
+
+	if s.focus < chatLogFieldResults {
+		var cmd tea.Cmd
+		s.inputs[s.focus], cmd = s.inputs[s.focus].Update(msg)
+		return s, cmd
+	}
+	return s, nil
+}
+
+// View implements tea.Model
+func (s *ChatLogsScreen) View() string {
+	var body string
+	if s.opened != nil {
+		body = lipgloss.JoinVertical(
+			lipgloss.Left,
+			style.CategoryStyle.Render(fmt.Sprintf("%s  %s", s.opened.server, s.opened.date)),
+			s.context.View(),
+		)
+	} else {
+		labels := []string{"Keyword", "User", "From", "To"}
+		var fields []string
+		for i, in := range s.inputs {
+			label := fmt.Sprintf("%-8s", labels[i])
+			if i == s.focus {
+				label = style.HotkeyStyle.Render(label)
+			}
+			fields = append(fields, label+" "+in.View())
+		}
+		body = lipgloss.JoinVertical(
+			lipgloss.Left,
+			strings.Join(fields, "\n"),
+			" ",
+			s.status,
+			s.results.View(),
+		)
+	}
+
+	return style.RenderSubscreen(s.width, s.height, "Chat Logs",
+		lipgloss.JoinVertical(lipgloss.Left, body, " ", s.help.View(s.keys)),
+	)
+}
+
+// SetSize updates dimensions
+func (s *ChatLogsScreen) SetSize(width, height int) {
+	s.width = width
+	s.height = height
+	for i := range s.inputs {
+		s.inputs[i].Width = min(40, max(width-24, 10))
+	}
+	s.results.Width = width - 10
+	s.results.Height = max(height-17, 3)
+	s.context.Width = width - 10
+	s.context.Height = max(height-11, 3)
+	s.renderResults()
+}
+
+// handleKeys handles keyboard input
+func (s *ChatLogsScreen) handleKeys(msg tea.KeyMsg) (ScreenModel, tea.Cmd) {
+	if s.opened != nil {
+		if msg.String() == "esc" {
+			s.opened = nil
+			s.updateKeys()
+			return s, nil
+		}
+		var cmd tea.Cmd
+		s.context, cmd = s.context.Update(msg)
+		return s, cmd
+	}
+
+	switch msg.String() {
+	case "esc":
+		return s, func() tea.Msg { return ChatLogsCancelledMsg{} }
+
+	case "tab":
+		s.setFocus((s.focus + 1) % (chatLogFieldResults + 1))
+		return s, nil
+
+	case "shift+tab":
+		s.setFocus((s.focus + chatLogFieldResults) % (chatLogFieldResults + 1))
+		return s, nil
+
+	case "enter":
+		if s.focus == chatLogFieldResults {
+			return s, s.openSelected()
+		}
+		return s, s.search()
+
+	case "up":
+		if s.focus == chatLogFieldResults && s.selected > 0 {
+			s.selected--
+			s.renderResults()
+		}
+		return s, nil
+
+	case "down":
+		if s.focus == chatLogFieldResults && s.selected < len(s.matches)-1 {
+			s.selected++
+			s.renderResults()
+		}
+		return s, nil
+	}
+
+	if s.focus < chatLogFieldResults {
+		var cmd tea.Cmd
+		s.inputs[s.focus], cmd = s.inputs[s.focus].Update(msg)
+		return s, cmd
+	}
+	return s, nil
+}
+
+// setFocus moves focus to a search field or the results list
+func (s *ChatLogsScreen) setFocus(focus int) {
+	s.focus = focus
+	for i := range s.inputs {
+		if i == focus {
+			s.inputs[i].Focus()
+		} else {
+			s.inputs[i].Blur()
+		}
+	}
+	s.updateKeys()
+}
+
+// updateKeys shows the bindings that apply to the focused part of the screen
+func (s *ChatLogsScreen) updateKeys() {
+	onResults := s.opened == nil && s.focus == chatLogFieldResults
+	s.keys.Search.SetEnabled(s.opened == nil && !onResults)
+	s.keys.Next.SetEnabled(s.opened == nil)
+	s.keys.Open.SetEnabled(onResults)
+	s.keys.Up.SetEnabled(s.opened != nil || onResults)
+	s.keys.Down.SetEnabled(s.opened != nil || onResults)
+}
+
+// search runs the query in the background
+func (s *ChatLogsScreen) search() tea.Cmd {
+	q := chatLogQuery{
+		keyword: strings.TrimSpace(s.inputs[chatLogFieldKeyword].Value()),
+		user:    strings.TrimSpace(s.inputs[chatLogFieldUser].Value()),
+	}
+
+	var err error
+	if q.from, err = parseChatLogDate(s.inputs[chatLogFieldFrom].Value()); err != nil {
+		s.status = err.Error()
+		return nil
+	}
+	if q.to, err = parseChatLogDate(s.inputs[chatLogFieldTo].Value()); err != nil {
+		s.status = err.Error()
+		return nil
+	}
+
+	s.status = "Searching..."
+	dir := s.model.chatLog.Dir()
+	return func() tea.Msg {
+		matches, err := searchChatLogs(dir, q)
+		return chatLogResultsMsg{matches: matches, err: err}
+	}
+}
+
+// parseChatLogDate parses an optional YYYY-MM-DD date
+func parseChatLogDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	d, err := time.Parse(chatLogDateFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD", value)
+	}
+	return d, nil
+}
+
+func (s *ChatLogsScreen) showResults(msg chatLogResultsMsg) {
+	s.matches = msg.matches
+	s.selected = 0
+	switch {
+	case msg.err != nil:
+		s.status = fmt.Sprintf("Search failed: %v", msg.err)
+	case len(s.matches) == 0:
+		s.status = "No matches"
+	case len(s.matches) >= maxChatLogResults:
+		s.status = fmt.Sprintf("First %d matches, newest first", len(s.matches))
+	default:
+		s.status = fmt.Sprintf("%d matches, newest first", len(s.matches))
+	}
+	if len(s.matches) > 0 {
+		s.setFocus(chatLogFieldResults)
+	}
+	s.renderResults()
+}
+
+// renderResults lists the matches, keeping the selected one in view
+func (s *ChatLogsScreen) renderResults() {
+	var b strings.Builder
+	for i, match := range s.matches {
+		line := fmt.Sprintf("%s  %-24s %s", match.date, truncate(match.server, 24), match.text)
+		line = truncate(line, max(s.results.Width-2, 10))
+		if i == s.selected && s.focus == chatLogFieldResults {
+			b.WriteString(style.HotkeyStyle.Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+	s.results.SetContent(b.String())
+
+	if s.selected < s.results.YOffset {
+		s.results.SetYOffset(s.selected)
+	} else if s.selected >= s.results.YOffset+s.results.Height {
+		s.results.SetYOffset(s.selected - s.results.Height + 1)
+	}
+}
+
+// openSelected loads the log file of the selected match
+func (s *ChatLogsScreen) openSelected() tea.Cmd {
+	if s.selected >= len(s.matches) {
+		return nil
+	}
+	match := s.matches[s.selected]
+	return func() tea.Msg {
+		lines, err := readChatLog(match.path)
+		return chatLogContextMsg{match: match, lines: lines, err: err}
+	}
+}
+
+// showContext shows the whole day's log with the match highlighted and centered
+func (s *ChatLogsScreen) showContext(msg chatLogContextMsg) {
+	if msg.err != nil {
+		s.status = fmt.Sprintf("Unable to open log: %v", msg.err)
+		return
+	}
+
+	var b strings.Builder
+	for i, line := range msg.lines {
+		if i == msg.match.line {
+			b.WriteString(style.HotkeyStyle.Render(line))
+		} else {
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+	s.context.SetContent(b.String())
+	s.context.SetYOffset(max(msg.match.line-s.context.Height/2, 0))
+
+	match := msg.match
+	s.opened = &match
+	s.updateKeys()
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:max(n-1, 0)]) + "…"
+}
@@ -254,6 +254,7 @@ func (s *FilesScreen) SetFilePath(path []string) {
 
 // InitiateDownload creates a download task and returns the download command
 func (s *FilesScreen) InitiateDownload(fileName string, filePath []string) tea.Cmd {
+	session := s.model.Session
 	return func() tea.Msg {
 		// Create task
 		taskID := uuid.New().String()
@@ -264,25 +265,22 @@ func (s *FilesScreen) InitiateDownload(fileName string, filePath []string) tea.C
 			Status:    TaskPending,
 			StartTime: time.Now(),
 		}
-		s.model.taskManager.Add(task)
+		session.taskManager.Add(task)
 
 		// Send download transaction
 		t := hotline.NewTransaction(
 			hotline.TranDownloadFile,
 			[2]byte{},
-			hotline.NewField(hotline.FieldFileName, []byte(fileName)),
+			hotline.NewField(hotline.FieldFileName, session.encodeText(fileName)),
 		)
 
 		// Add file path if in subdirectory
 		if len(filePath) > 0 {
 			pathStr := strings.Join(filePath, "/")
-			t.Fields = append(t.Fields, hotline.NewField(hotline.FieldFilePath, hotline.EncodeFilePath(pathStr)))
+			t.Fields = append(t.Fields, hotline.NewField(hotline.FieldFilePath, session.encodeFilePath(pathStr)))
 		}
 
-		// Map transaction ID to task ID
-		s.model.pendingDownloads[t.ID] = taskID
-
-		if err := s.model.hlClient.Send(t); err != nil {
+		if err := s.model.requestForTask(session, t, "download", taskID); err != nil {
 			s.model.logger.Error("Error sending download transaction", "err", err)
 		}
 
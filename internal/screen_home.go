@@ -18,6 +18,8 @@ type HomeTrackerMsg struct{}
 
 type HomeSettingsMsg struct{}
 
+type HomeChatLogsMsg struct{}
+
 type HomeQuitMsg struct{}
 
 type HomeRefreshBannerMsg struct{}
@@ -60,6 +62,8 @@ func (s *HomeScreen) Update(msg tea.Msg) (ScreenModel, tea.Cmd) {
 		return s, s.model.handleHomeTrackerMsg()
 	case HomeSettingsMsg:
 		return s, s.model.handleHomeSettingsMsg()
+	case HomeChatLogsMsg:
+		return s, s.model.handleHomeChatLogsMsg()
 	case HomeQuitMsg:
 		return s, tea.Quit
 	case HomeRefreshBannerMsg:
@@ -96,6 +100,7 @@ func (s *HomeScreen) View() string {
 						fmt.Sprintf("%s Join Server", style.HotkeyStyle.Render("(j)")),
 						fmt.Sprintf("%s Bookmarks", style.HotkeyStyle.Render("(b)")),
 						fmt.Sprintf("%s Browse Tracker", style.HotkeyStyle.Render("(t)")),
+						fmt.Sprintf("%s Chat Logs", style.HotkeyStyle.Render("(c)")),
 						fmt.Sprintf("%s Settings", style.HotkeyStyle.Render("(s)")),
 						fmt.Sprintf("%s Quit", style.HotkeyStyle.Render("(q)")),
 					},
@@ -122,6 +127,8 @@ func (s *HomeScreen) handleKeys(msg tea.KeyMsg) (ScreenModel, tea.Cmd) {
 		return s, func() tea.Msg { return HomeBookmarksMsg{} }
 	case "t":
 		return s, func() tea.Msg { return HomeTrackerMsg{} }
+	case "c":
+		return s, func() tea.Msg { return HomeChatLogsMsg{} }
 	case "s":
 		return s, func() tea.Msg { return HomeSettingsMsg{} }
 	case "ctrl+r":
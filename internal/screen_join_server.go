@@ -1,6 +1,9 @@
 package internal
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
@@ -58,6 +61,10 @@ type JoinServerBookmarkSavedMsg struct {
 	Login    string
 	Password string
 	TLS      bool
+	CAFile   string
+	Strict   bool
+	Transfer transferEndpoint
+	Encoding string
 	Index    int // Index of bookmark being edited
 }
 
@@ -67,6 +74,10 @@ type JoinServerBookmarkCreatedMsg struct {
 	Login    string
 	Password string
 	TLS      bool
+	CAFile   string
+	Strict   bool
+	Transfer transferEndpoint
+	Encoding string
 }
 
 type JoinServerCancelledMsg struct {
@@ -90,6 +101,11 @@ type JoinServerScreen struct {
 	login        string
 	password     string
 	useTLS       bool
+	tlsCAFile    string
+	tlsStrict    bool
+	transferHost string
+	transferPort string
+	encoding     string
 	saveBookmark bool
 }
 
@@ -107,43 +123,95 @@ func enterSubmitsKeyMap() *huh.KeyMap {
 }
 
 // buildJoinServerForm creates a Huh form based on the mode and initial values
-func buildJoinServerForm(mode JoinServerMode, name, server, login, password *string, useTLS, saveBookmark *bool) *huh.Form {
+func buildJoinServerForm(s *JoinServerScreen) *huh.Form {
 	var groups []*huh.Group
 
-	if mode == JoinServerModeEditBookmark || mode == JoinServerModeCreateBookmark {
-		// Edit/Create mode: name, server, login, password, TLS
+	if s.mode == JoinServerModeEditBookmark || s.mode == JoinServerModeCreateBookmark {
+		// Edit/Create mode: name, server, login, password, TLS and its verification options
 		groups = append(groups, huh.NewGroup(
 			huh.NewInput().
 				Key("name").
 				Title("Name").
 				Placeholder("Bookmark Name").
-				Value(name),
+				Value(&s.name),
 
 			huh.NewInput().
 				Key("server").
 				Title("Server").
 				Placeholder("hostname:port").
-				Value(server),
+				Value(&s.server).
+				Validate(func(str string) error {
+					_, err := normalizeAddr(str, s.useTLS)
+					return err
+				}),
 
 			huh.NewInput().
 				Key("login").
 				Title("Login").
 				Placeholder("guest").
-				Value(login),
+				Value(&s.login),
 
 			huh.NewInput().
 				Key("password").
 				Title("Password").
 				Placeholder("password").
 				EchoMode(huh.EchoModePassword).
-				Value(password),
+				Value(&s.password),
 
 			huh.NewConfirm().
 				Key("tls").
 				Title("Use TLS").
 				Affirmative("Yes").
 				Negative("No").
-				Value(useTLS),
+				Value(&s.useTLS),
+
+			huh.NewInput().
+				Key("tlsCAFile").
+				Title("TLS CA File").
+				Placeholder("optional, verify against this CA").
+				Value(&s.tlsCAFile),
+
+			huh.NewConfirm().
+				Key("tlsStrict").
+				Title("Strict TLS").
+				Description("Require a certificate trusted by the system roots").
+				Affirmative("Yes").
+				Negative("No").
+				Value(&s.tlsStrict),
+
+			huh.NewInput().
+				Key("transferHost").
+				Title("Transfer Host").
+				Placeholder("optional, defaults to the server").
+				Value(&s.transferHost).
+				Validate(func(str string) error {
+					if strings.TrimSpace(str) == "" {
+						return nil
+					}
+					return validateHost(str)
+				}),
+
+			huh.NewInput().
+				Key("transferPort").
+				Title("Transfer Port").
+				Placeholder("optional, defaults to the server port + 1").
+				Value(&s.transferPort).
+				Validate(func(str string) error {
+					_, err := parsePort(str)
+					return err
+				}),
+
+			huh.NewSelect[string]().
+				Key("encoding").
+				Title("Text Encoding").
+				Options(
+					huh.NewOption("Default", ""),
+					huh.NewOption("Auto-detect", encodingAuto),
+					huh.NewOption("UTF-8", encodingUTF8),
+					huh.NewOption("MacRoman", encodingMacRoman),
+					huh.NewOption("Shift-JIS", encodingShiftJIS),
+				).
+				Value(&s.encoding),
 		))
 	} else {
 		// Connect mode: server, login, password, TLS, Save
@@ -152,34 +220,38 @@ func buildJoinServerForm(mode JoinServerMode, name, server, login, password *str
 				Key("server").
 				Title("Server").
 				Placeholder("server:port").
-				Value(server),
+				Value(&s.server).
+				Validate(func(str string) error {
+					_, err := normalizeAddr(str, s.useTLS)
+					return err
+				}),
 
 			huh.NewInput().
 				Key("login").
 				Title("Login").
 				Placeholder("guest").
-				Value(login),
+				Value(&s.login),
 
 			huh.NewInput().
 				Key("password").
 				Title("Password").
 				Placeholder("password").
 				EchoMode(huh.EchoModePassword).
-				Value(password),
+				Value(&s.password),
 
 			huh.NewConfirm().
 				Key("tls").
 				Title("Use TLS").
 				Affirmative("Yes").
 				Negative("No").
-				Value(useTLS),
+				Value(&s.useTLS),
 
 			huh.NewConfirm().
 				Key("save").
 				Title("Save as Bookmark").
 				Affirmative("Yes").
 				Negative("No").
-				Value(saveBookmark),
+				Value(&s.saveBookmark),
 		))
 	}
 
@@ -203,7 +275,7 @@ func NewJoinServerScreen(m *Model) (*JoinServerScreen, tea.Cmd) {
 		keys:                 newJoinServerKeyMap(),
 	}
 
-	screen.form = buildJoinServerForm(JoinServerModeConnect, &screen.name, &screen.server, &screen.login, &screen.password, &screen.useTLS, &screen.saveBookmark)
+	screen.form = buildJoinServerForm(screen)
 
 	return screen, screen.form.Init()
 }
@@ -225,7 +297,7 @@ func NewJoinServerScreenForConnect(serverAddr, login, password string, useTLS bo
 		useTLS:               useTLS,
 	}
 
-	screen.form = buildJoinServerForm(JoinServerModeConnect, &screen.name, &screen.server, &screen.login, &screen.password, &screen.useTLS, &screen.saveBookmark)
+	screen.form = buildJoinServerForm(screen)
 
 	return screen, screen.form.Init()
 }
@@ -246,9 +318,18 @@ func NewJoinServerScreenForEdit(bm Bookmark, index int, m *Model) (*JoinServerSc
 		login:                bm.Login,
 		password:             bm.Password,
 		useTLS:               bm.TLS,
+		tlsCAFile:            bm.TLSCAFile,
+		tlsStrict:            bm.TLSStrict,
+		transferHost:         bm.TransferHost,
+	}
+	if bm.TransferPort != 0 {
+		screen.transferPort = strconv.Itoa(bm.TransferPort)
+	}
+	if bm.Encoding != "" {
+		screen.encoding, _ = parseEncoding(bm.Encoding)
 	}
 
-	screen.form = buildJoinServerForm(JoinServerModeEditBookmark, &screen.name, &screen.server, &screen.login, &screen.password, &screen.useTLS, &screen.saveBookmark)
+	screen.form = buildJoinServerForm(screen)
 
 	return screen, screen.form.Init()
 }
@@ -266,7 +347,7 @@ func NewJoinServerScreenForCreate(m *Model) (*JoinServerScreen, tea.Cmd) {
 		keys:                 newJoinServerKeyMap(),
 	}
 
-	screen.form = buildJoinServerForm(JoinServerModeCreateBookmark, &screen.name, &screen.server, &screen.login, &screen.password, &screen.useTLS, &screen.saveBookmark)
+	screen.form = buildJoinServerForm(screen)
 
 	return screen, screen.form.Init()
 }
@@ -340,6 +421,11 @@ func (s *JoinServerScreen) handleSubmit() tea.Cmd {
 	}
 	password := s.password
 	useTLS := s.useTLS
+	caFile := s.tlsCAFile
+	strict := s.tlsStrict
+	transferPort, _ := parsePort(s.transferPort)
+	transfer := transferEndpoint{host: strings.TrimSpace(s.transferHost), port: transferPort}
+	encoding := s.encoding
 	saveBookmark := s.saveBookmark
 
 	switch s.mode {
@@ -352,6 +438,10 @@ func (s *JoinServerScreen) handleSubmit() tea.Cmd {
 				Login:    login,
 				Password: password,
 				TLS:      useTLS,
+				CAFile:   caFile,
+				Strict:   strict,
+				Transfer: transfer,
+				Encoding: encoding,
 				Index:    index,
 			}
 		}
@@ -364,6 +454,10 @@ func (s *JoinServerScreen) handleSubmit() tea.Cmd {
 				Login:    login,
 				Password: password,
 				TLS:      useTLS,
+				CAFile:   caFile,
+				Strict:   strict,
+				Transfer: transfer,
+				Encoding: encoding,
 			}
 		}
 
@@ -0,0 +1,219 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jhalter/mobius-hotline-client/internal/style"
+)
+
+// Messages sent to and from LinksScreen
+
+// LinksRequestedMsg signals user wants to pick one of the links on a screen
+type LinksRequestedMsg struct {
+	Links []string
+}
+
+// LinksCancelledMsg signals user wants to close the links picker
+type LinksCancelledMsg struct{}
+
+// linksScreenKeyMap defines key bindings for the links picker help display
+type linksScreenKeyMap struct {
+	Up   key.Binding
+	Down key.Binding
+	Open key.Binding
+	Copy key.Binding
+	Back key.Binding
+}
+
+func (k linksScreenKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Open, k.Copy, k.Back}
+}
+
+func (k linksScreenKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Up, k.Down, k.Open, k.Copy, k.Back}}
+}
+
+// LinksScreen lists the links on the screen it was opened from
+type LinksScreen struct {
+	viewport      viewport.Model
+	width, height int
+	model         *Model
+	help          help.Model
+	keys          linksScreenKeyMap
+
+	links    []string
+	selected int
+	status   string
+}
+
+// NewLinksScreen creates the links picker
+func NewLinksScreen(links []string, m *Model) *LinksScreen {
+	keys := linksScreenKeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		Open: key.NewBinding(
+			key.WithKeys("enter", "o"),
+			key.WithHelp("enter", "open"),
+		),
+		Copy: key.NewBinding(
+			key.WithKeys("c", "y"),
+			key.WithHelp("c", "copy"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "back"),
+		),
+	}
+
+	s := &LinksScreen{
+		viewport: viewport.New(0, 0),
+		model:    m,
+		help:     help.New(),
+		keys:     keys,
+		links:    links,
+	}
+	switch len(links) {
+	case 0:
+		s.status = "No links on this screen"
+	case 1:
+		s.status = "1 link"
+	default:
+		s.status = fmt.Sprintf("%d links", len(links))
+	}
+	s.keys.Open.SetEnabled(len(links) > 0)
+	s.keys.Copy.SetEnabled(len(links) > 0)
+	s.SetSize(m.width, m.height)
+	return s
+}
+
+// Init implements tea.Model
+func (s *LinksScreen) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements ScreenModel
+func (s *LinksScreen) Update(msg tea.Msg) (ScreenModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.SetSize(msg.Width, msg.Height)
+		return s, nil
+
+	case LinksCancelledMsg:
+		s.model.PopScreen()
+		return s, nil
+
+	case tea.KeyMsg:
+		return s.handleKeys(msg)
+	}
+	return s, nil
+}
+
+// View implements tea.Model
+func (s *LinksScreen) View() string {
+	return style.RenderSubscreen(s.width, s.height, "Links",
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			s.status,
+			" ",
+			s.viewport.View(),
+			" ",
+			s.help.View(s.keys),
+		),
+	)
+}
+
+// SetSize updates dimensions
+func (s *LinksScreen) SetSize(width, height int) {
+	s.width = width
+	s.height = height
+	s.viewport.Width = width - 10
+	s.viewport.Height = max(height-14, 3)
+	s.render()
+}
+
+// handleKeys handles keyboard input
+func (s *LinksScreen) handleKeys(msg tea.KeyMsg) (ScreenModel, tea.Cmd) {
+	switch {
+	case key.Matches(msg, s.keys.Back):
+		return s, func() tea.Msg { return LinksCancelledMsg{} }
+
+	case key.Matches(msg, s.keys.Up):
+		if s.selected > 0 {
+			s.selected--
+			s.render()
+		}
+
+	case key.Matches(msg, s.keys.Down):
+		if s.selected < len(s.links)-1 {
+			s.selected++
+			s.render()
+		}
+
+	case key.Matches(msg, s.keys.Open):
+		link := s.links[s.selected]
+		if err := s.model.openLink(link); err != nil {
+			s.status = fmt.Sprintf("Unable to open link: %v", err)
+		} else {
+			s.status = fmt.Sprintf("Opened %s", link)
+		}
+
+	case key.Matches(msg, s.keys.Copy):
+		link := s.links[s.selected]
+		copyToClipboard(link)
+		s.status = fmt.Sprintf("Copied %s", link)
+	}
+	return s, nil
+}
+
+// render lists the links, keeping the selected one in view
+func (s *LinksScreen) render() {
+	var b strings.Builder
+	for i, link := range s.links {
+		line := truncate(link, max(s.viewport.Width-2, 10))
+		if i == s.selected {
+			b.WriteString(style.HotkeyStyle.Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+	s.viewport.SetContent(b.String())
+
+	if s.selected < s.viewport.YOffset {
+		s.viewport.SetYOffset(s.selected)
+	} else if s.selected >= s.viewport.YOffset+s.viewport.Height {
+		s.viewport.SetYOffset(s.selected - s.viewport.Height + 1)
+	}
+}
+
+// linksIn returns the links in some lines of text, newest line first
+func linksIn(lines []string) []string {
+	var links []string
+	for i := len(lines) - 1; i >= 0; i-- {
+		for _, link := range findLinks(lines[i]) {
+			if !slices.Contains(links, link) {
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
+func (m *Model) handleLinksRequestedMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m.linksScreen = NewLinksScreen(msg.(LinksRequestedMsg).Links, m)
+	m.PushScreen(ScreenLinks)
+	return m, m.linksScreen.Init()
+}
@@ -84,6 +84,11 @@ func (s *LoadingScreen) View() string {
 	)
 }
 
+// SetMessage replaces the text shown next to the spinner
+func (s *LoadingScreen) SetMessage(message string) {
+	s.message = message
+}
+
 // SetSize updates dimensions
 func (s *LoadingScreen) SetSize(width, height int) {
 	s.width = width
@@ -10,7 +10,6 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/jhalter/mobius-hotline-client/internal/style"
 	"github.com/jhalter/mobius/hotline"
-	"github.com/muesli/reflow/wordwrap"
 )
 
 // Messages sent from MessageBoardScreen to parent
@@ -28,19 +27,23 @@ type messageBoardScreenKeyMap struct {
 	PageUp   key.Binding
 	PageDown key.Binding
 	Post     key.Binding
+	Links    key.Binding
 	Back     key.Binding
 }
 
 func (k messageBoardScreenKeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Up, k.Down, k.PageUp, k.PageDown, k.Post, k.Back}
+	return []key.Binding{k.Up, k.Down, k.PageUp, k.PageDown, k.Post, k.Links, k.Back}
 }
 
 func (k messageBoardScreenKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.PageUp, k.PageDown, k.Post, k.Back},
+		{k.Up, k.Down, k.PageUp, k.PageDown, k.Post, k.Links, k.Back},
 	}
 }
 
+// messageBoardWidth is the width posts are wrapped to
+const messageBoardWidth = 58
+
 // MessageBoardScreen is a self-contained BubbleTea model for viewing the message board
 type MessageBoardScreen struct {
 	viewport      viewport.Model
@@ -74,14 +77,18 @@ func NewMessageBoardScreen(content string, m *Model) *MessageBoardScreen {
 			key.WithKeys("ctrl+p"),
 			key.WithHelp("^P", "post"),
 		),
+		Links: key.NewBinding(
+			key.WithKeys("ctrl+y"),
+			key.WithHelp("^Y", "links"),
+		),
 		Back: key.NewBinding(
 			key.WithKeys("esc"),
 			key.WithHelp("esc", "back"),
 		),
 	}
 
-	vp := viewport.New(m.width-10, m.height-10)
-	vp.SetContent(content)
+	vp := viewport.New(messageBoardWidth, m.height-10)
+	vp.SetContent(wrapLinks(content, messageBoardWidth, m.prefs.hyperlinks()))
 
 	return &MessageBoardScreen{
 		viewport: vp,
@@ -134,7 +141,7 @@ func (s *MessageBoardScreen) View() string {
 			lipgloss.JoinVertical(
 				lipgloss.Left,
 				style.SubTitleStyle.Render("Message Board"),
-				wordwrap.String(s.viewport.View(), 58),
+				s.viewport.View(),
 				" ",
 				lipgloss.JoinHorizontal(
 					lipgloss.Left,
@@ -152,7 +159,6 @@ func (s *MessageBoardScreen) View() string {
 func (s *MessageBoardScreen) SetSize(width, height int) {
 	s.width = width
 	s.height = height
-	s.viewport.Width = width - 10
 	s.viewport.Height = height - 10
 }
 
@@ -164,6 +170,10 @@ func (s *MessageBoardScreen) handleKeys(msg tea.KeyMsg) (ScreenModel, tea.Cmd) {
 
 	case "ctrl+p":
 		return s, func() tea.Msg { return MessageBoardPostRequestedMsg{} }
+
+	case "ctrl+y":
+		links := findLinks(s.content)
+		return s, func() tea.Msg { return LinksRequestedMsg{Links: links} }
 	}
 
 	// Pass all other keys to viewport for scrolling
@@ -5,7 +5,6 @@ import (
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/jhalter/mobius-hotline-client/internal/style"
-	"github.com/muesli/reflow/wordwrap"
 )
 
 // ModalType identifies the type of modal for proper handling
@@ -17,6 +16,8 @@ const (
 	ModalTypeAgreement
 	ModalTypeDisconnect
 	ModalTypeError
+	ModalTypeTrustCertificate
+	ModalTypeChatInvite
 )
 
 // Messages sent from ModalScreen to parent
@@ -197,7 +198,7 @@ func (s *ModalScreen) View() string {
 	if s.content != "" {
 		body = lipgloss.NewStyle().
 			Padding(1).
-			Render(wordwrap.String(s.content, 56))
+			Render(wrapLinks(s.content, 56, s.model.prefs.hyperlinks()))
 	}
 
 	// Render the huh form buttons
@@ -13,7 +13,6 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/jhalter/mobius-hotline-client/internal/style"
 	"github.com/jhalter/mobius/hotline"
-	"github.com/muesli/reflow/wordwrap"
 )
 
 // Messages sent from NewsScreen to parent
@@ -77,6 +76,7 @@ type newsArticleItem struct {
 	depth       int    // Nesting level
 	isExpanded  bool   // Are children shown?
 	hasChildren bool   // Has replies?
+	dimmed      bool   // Posted by an ignored name
 }
 
 func (i newsArticleItem) FilterValue() string { return i.title }
@@ -94,6 +94,9 @@ func (i newsArticleItem) Title() string {
 		indicator = "  "
 	}
 
+	if i.dimmed {
+		return indent + indicator + ignoredStyle.Render(i.title)
+	}
 	return indent + indicator + i.title
 }
 func (i newsArticleItem) Description() string {
@@ -331,6 +334,14 @@ func (s *NewsScreen) handleKeys(msg tea.KeyMsg) (ScreenModel, tea.Cmd) {
 			return NewsPostArticleMsg{Subject: subject, ParentID: parentID}
 		}
 
+	case "ctrl+y":
+		// Only offer links when viewing an article
+		if s.selectedArticle == nil {
+			return s, nil
+		}
+		links := findLinks(s.selectedArticle.content)
+		return s, func() tea.Msg { return LinksRequestedMsg{Links: links} }
+
 	case "ctrl+b":
 		// Only allow creating bundles when viewing bundle/root (not categories)
 		if !s.isViewingCategory {
@@ -502,7 +513,7 @@ func (s *NewsScreen) renderSplitView() string {
 	}
 
 	// Wrap article content to fit width
-	wrappedContent := wordwrap.String(s.selectedArticle.content, wrapWidth)
+	wrappedContent := wrapLinks(s.selectedArticle.content, wrapWidth, s.model.prefs.hyperlinks())
 
 	s.articleViewport.SetContent(wrappedContent)
 
@@ -763,6 +774,10 @@ func newNewsArticleDelegate() list.DefaultDelegate {
 				key.WithKeys("^R"),
 				key.WithHelp("^R", "reply"),
 			),
+			key.NewBinding(
+				key.WithKeys("^Y"),
+				key.WithHelp("^Y", "links"),
+			),
 			key.NewBinding(
 				key.WithKeys("esc"),
 				key.WithHelp("esc", "back"),
@@ -789,6 +804,10 @@ func newNewsArticleDelegate() list.DefaultDelegate {
 					key.WithKeys("^R"),
 					key.WithHelp("^R", "reply"),
 				),
+				key.NewBinding(
+					key.WithKeys("^Y"),
+					key.WithHelp("^Y", "links"),
+				),
 				key.NewBinding(
 					key.WithKeys("esc"),
 					key.WithHelp("esc", "back"),
@@ -0,0 +1,416 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jhalter/mobius-hotline-client/internal/style"
+	"github.com/jhalter/mobius/hotline"
+)
+
+// Messages sent from PrivateChatScreen to parent
+
+// PrivateChatSendMsg signals user wants to send a message to a private chat
+type PrivateChatSendMsg struct {
+	ChatID [4]byte
+	Text   string
+}
+
+// PrivateChatSetSubjectMsg signals user wants to change a private chat's subject
+type PrivateChatSetSubjectMsg struct {
+	ChatID  [4]byte
+	Subject string
+}
+
+// PrivateChatInviteMsg signals user wants to invite another user to a private chat
+type PrivateChatInviteMsg struct {
+	ChatID       [4]byte
+	TargetUserID [2]byte
+}
+
+// PrivateChatLeaveMsg signals user wants to leave a private chat
+type PrivateChatLeaveMsg struct {
+	ChatID [4]byte
+}
+
+// PrivateChatNextMsg signals user wants to switch to the next private chat
+type PrivateChatNextMsg struct{}
+
+// PrivateChatCancelledMsg signals user wants to go back to the server screen,
+// staying in the chat
+type PrivateChatCancelledMsg struct{}
+
+// privateChatScreenKeyMap defines key bindings for the private chat help display
+type privateChatScreenKeyMap struct {
+	Subject key.Binding
+	Next    key.Binding
+	Invite  key.Binding
+	Leave   key.Binding
+	Links   key.Binding
+	Back    key.Binding
+}
+
+func (k privateChatScreenKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Subject, k.Next, k.Invite, k.Leave, k.Links, k.Back}
+}
+
+func (k privateChatScreenKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Subject, k.Next, k.Invite, k.Leave, k.Links, k.Back}}
+}
+
+// PrivateChatScreen shows one private chat with its members
+type PrivateChatScreen struct {
+	chatViewport viewport.Model
+	chatInput    textinput.Model
+	userViewport viewport.Model
+	help         help.Model
+	keys         privateChatScreenKeyMap
+
+	width, height int
+	model         *Model
+
+	room            *chatRoom
+	editingSubject  bool // The input edits the subject instead of sending chat
+	focusOnUserList bool // true = invite list focused, false = chat input focused
+	selectedUserIdx int  // Index into inviteCandidates
+}
+
+// NewPrivateChatScreen creates a screen for a joined private chat
+func NewPrivateChatScreen(room *chatRoom, m *Model) *PrivateChatScreen {
+	chatInput := textinput.New()
+	chatInput.Placeholder = "Type a message..."
+	chatInput.Focus()
+
+	keys := privateChatScreenKeyMap{
+		Subject: key.NewBinding(
+			key.WithKeys("ctrl+e"),
+			key.WithHelp("^E", "subject"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("^R", "next chat"),
+		),
+		Invite: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "invite"),
+		),
+		Leave: key.NewBinding(
+			key.WithKeys("ctrl+w"),
+			key.WithHelp("^W", "leave"),
+		),
+		Links: key.NewBinding(
+			key.WithKeys("ctrl+y"),
+			key.WithHelp("^Y", "links"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "back"),
+		),
+	}
+
+	s := &PrivateChatScreen{
+		chatViewport: viewport.New(m.width-30, m.height-9),
+		chatInput:    chatInput,
+		userViewport: viewport.New(25, m.height-9),
+		help:         help.New(),
+		keys:         keys,
+		model:        m,
+		room:         room,
+	}
+	s.SetSize(m.width, m.height)
+	s.chatViewport.GotoBottom()
+	return s
+}
+
+// Init returns initial commands
+func (s *PrivateChatScreen) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages and returns updated screen + commands
+func (s *PrivateChatScreen) Update(msg tea.Msg) (ScreenModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.SetSize(msg.Width, msg.Height)
+		return s, nil
+
+	case PrivateChatSendMsg:
+		s.model.handlePrivateChatSendMsg(msg)
+		return s, nil
+
+	case PrivateChatSetSubjectMsg:
+		s.model.handlePrivateChatSetSubjectMsg(msg)
+		return s, nil
+
+	case PrivateChatInviteMsg:
+		s.model.handlePrivateChatInviteMsg(msg)
+		return s, nil
+
+	case PrivateChatLeaveMsg:
+		s.model.handlePrivateChatLeaveMsg(msg)
+		return s, nil
+
+	case PrivateChatNextMsg:
+		if next := s.model.nextChatRoom(s.room); next != nil && next != s.room {
+			s.model.openChatRoom(next)
+		}
+		return s, nil
+
+	case PrivateChatCancelledMsg:
+		s.model.PopScreen()
+		return s, nil
+
+	case tea.KeyMsg:
+		return s.handleKeys(msg)
+	}
+
+	var cmd tea.Cmd
+	s.chatInput, cmd = s.chatInput.Update(msg)
+	return s, cmd
+}
+
+// View renders the screen
+func (s *PrivateChatScreen) View() string {
+	var users strings.Builder
+	users.WriteString(style.CategoryStyle.Render("Members"))
+	users.WriteString("\n")
+	for _, u := range s.room.members {
+		users.WriteString("  " + u.Name + "\n")
+	}
+
+	// The rest of the server's users can be invited from the focused list
+	if s.focusOnUserList {
+		users.WriteString("\n")
+		users.WriteString(style.CategoryStyle.Render("Invite"))
+		users.WriteString("\n")
+		for i, u := range s.inviteCandidates() {
+			if i == s.selectedUserIdx {
+				users.WriteString("> " + u.Name + "\n")
+			} else {
+				users.WriteString("  " + u.Name + "\n")
+			}
+		}
+	}
+	s.userViewport.SetContent(users.String())
+
+	chatBorder := lipgloss.RoundedBorder()
+	chatBorderColor := style.ColorCyan
+	if !s.focusOnUserList {
+		chatBorder = lipgloss.DoubleBorder()
+		if !s.chatViewport.AtBottom() {
+			chatBorderColor = style.ColorLightGrey
+		}
+	}
+	chatView := lipgloss.NewStyle().
+		PaddingLeft(1).
+		Border(chatBorder).
+		BorderForeground(chatBorderColor).
+		Render(s.chatViewport.View())
+
+	userBorder := lipgloss.RoundedBorder()
+	if s.focusOnUserList {
+		userBorder = lipgloss.DoubleBorder()
+	}
+	userView := lipgloss.NewStyle().
+		Border(userBorder).
+		BorderForeground(style.ColorCyan).
+		Render(s.userViewport.View())
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		style.ServerTitleStyle.Render(s.title()),
+		s.help.View(s.keys),
+		lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			lipgloss.JoinVertical(lipgloss.Left, chatView, style.BoxStyle.Render(s.chatInput.View())),
+			userView,
+		),
+	)
+}
+
+// title returns the screen title with the chat's subject and other rooms' unread lines
+func (s *PrivateChatScreen) title() string {
+	subject := s.room.subject
+	if subject == "" {
+		subject = "No subject"
+	}
+	title := fmt.Sprintf("Private Chat - %s", subject)
+
+	unread := 0
+	for _, room := range s.model.chatRooms {
+		if room != s.room {
+			unread += room.unread
+		}
+	}
+	if unread > 0 {
+		title += fmt.Sprintf(" (%d unread in other chats)", unread)
+	}
+	return title
+}
+
+// SetSize updates dimensions
+func (s *PrivateChatScreen) SetSize(width, height int) {
+	s.width = width
+	s.height = height
+
+	s.chatViewport.Width = width - 30
+	s.chatViewport.Height = height - 9
+	s.chatInput.Width = width - 34
+
+	s.userViewport.Width = 25
+	s.userViewport.Height = height - 9
+
+	s.Refresh()
+}
+
+// Refresh redraws the chat from the room's messages, following new lines if the
+// view was at the bottom
+func (s *PrivateChatScreen) Refresh() {
+	atBottom := s.chatViewport.AtBottom()
+
+	wrapWidth := max(s.width-30-3, 5)
+	osc8 := s.model.prefs.hyperlinks()
+	var content strings.Builder
+	for _, line := range s.room.messages {
+		content.WriteString(wrapLinks(line, wrapWidth, osc8))
+		content.WriteString("\n")
+	}
+	s.chatViewport.SetContent(content.String())
+
+	if atBottom {
+		s.chatViewport.GotoBottom()
+	}
+
+	if n := len(s.inviteCandidates()); s.selectedUserIdx >= n {
+		s.selectedUserIdx = max(n-1, 0)
+	}
+}
+
+// inviteCandidates returns the server's users who aren't in the chat
+func (s *PrivateChatScreen) inviteCandidates() []hotline.User {
+	var users []hotline.User
+	for _, u := range s.model.userList {
+		if !s.room.isMember(u.ID) {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+// handleKeys handles keyboard input
+func (s *PrivateChatScreen) handleKeys(msg tea.KeyMsg) (ScreenModel, tea.Cmd) {
+	chatID := s.room.id
+
+	switch msg.String() {
+	case "esc":
+		if s.editingSubject {
+			s.stopEditingSubject()
+			return s, nil
+		}
+		return s, func() tea.Msg { return PrivateChatCancelledMsg{} }
+
+	case "tab":
+		s.focusOnUserList = !s.focusOnUserList
+		if s.focusOnUserList {
+			s.chatInput.Blur()
+		} else {
+			s.chatInput.Focus()
+		}
+		return s, nil
+
+	case "ctrl+e":
+		s.editingSubject = true
+		s.focusOnUserList = false
+		s.chatInput.Placeholder = "Chat subject"
+		s.chatInput.SetValue(s.room.subject)
+		s.chatInput.CursorEnd()
+		s.chatInput.Focus()
+		return s, nil
+
+	case "ctrl+r":
+		return s, func() tea.Msg { return PrivateChatNextMsg{} }
+
+	case "ctrl+w":
+		return s, func() tea.Msg { return PrivateChatLeaveMsg{ChatID: chatID} }
+
+	case "ctrl+y":
+		links := linksIn(s.room.messages)
+		return s, func() tea.Msg { return LinksRequestedMsg{Links: links} }
+
+	case "up":
+		if s.focusOnUserList {
+			if s.selectedUserIdx > 0 {
+				s.selectedUserIdx--
+			}
+		} else {
+			s.chatViewport.ScrollUp(1)
+		}
+		return s, nil
+
+	case "down":
+		if s.focusOnUserList {
+			if s.selectedUserIdx < len(s.inviteCandidates())-1 {
+				s.selectedUserIdx++
+			}
+		} else {
+			s.chatViewport.ScrollDown(1)
+		}
+		return s, nil
+
+	case "pgup":
+		s.chatViewport.PageUp()
+		return s, nil
+
+	case "pgdown":
+		s.chatViewport.PageDown()
+		return s, nil
+
+	case "enter":
+		if s.focusOnUserList {
+			candidates := s.inviteCandidates()
+			if s.selectedUserIdx < len(candidates) {
+				targetID := candidates[s.selectedUserIdx].ID
+				return s, func() tea.Msg {
+					return PrivateChatInviteMsg{ChatID: chatID, TargetUserID: targetID}
+				}
+			}
+			return s, nil
+		}
+
+		text := s.chatInput.Value()
+		if s.editingSubject {
+			s.stopEditingSubject()
+			return s, func() tea.Msg {
+				return PrivateChatSetSubjectMsg{ChatID: chatID, Subject: text}
+			}
+		}
+		if text != "" {
+			s.chatInput.SetValue("")
+			return s, func() tea.Msg {
+				return PrivateChatSendMsg{ChatID: chatID, Text: text}
+			}
+		}
+		return s, nil
+	}
+
+	if !s.focusOnUserList {
+		var cmd tea.Cmd
+		s.chatInput, cmd = s.chatInput.Update(msg)
+		return s, cmd
+	}
+
+	return s, nil
+}
+
+// stopEditingSubject puts the input back to sending chat
+func (s *PrivateChatScreen) stopEditingSubject() {
+	s.editingSubject = false
+	s.chatInput.Placeholder = "Type a message..."
+	s.chatInput.SetValue("")
+}
@@ -3,7 +3,9 @@ package internal
 import (
 	"encoding/binary"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -13,7 +15,6 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/jhalter/mobius-hotline-client/internal/style"
 	"github.com/jhalter/mobius/hotline"
-	"github.com/muesli/reflow/wordwrap"
 )
 
 // Messages sent from ServerScreen to parent
@@ -46,6 +47,36 @@ type ServerComposeMessageMsg struct {
 // ServerOpenTasksMsg signals user wants to open tasks screen
 type ServerOpenTasksMsg struct{}
 
+// ServerOpenInfoMsg signals user wants to open the server info screen
+type ServerOpenInfoMsg struct{}
+
+// ServerInviteToChatMsg signals user wants to invite a user to a new private chat
+type ServerInviteToChatMsg struct {
+	TargetUserID [2]byte
+}
+
+// ServerOpenChatMsg signals user wants to open the joined private chats
+type ServerOpenChatMsg struct{}
+
+// chatOlderPage is how many lines are loaded from the chat log at a time
+const chatOlderPage = 200
+
+// chatOlderStyle fades lines loaded from the chat log
+var chatOlderStyle = lipgloss.NewStyle().Faint(true)
+
+// chatOlderMsg delivers chat lines read back from the log for the scrollback
+type chatOlderMsg struct {
+	lines  []chatLogLine
+	cursor chatLogCursor // Place of the first line
+	newest bool          // The last lines logged, replacing a scrollback that was behind
+	err    error
+}
+
+// ServerToggleIgnoreMsg signals user wants to ignore or stop ignoring a user
+type ServerToggleIgnoreMsg struct {
+	TargetUserID [2]byte
+}
+
 // serverScreenKeyMap defines key bindings for the server UI help display
 type serverScreenKeyMap struct {
 	News         key.Binding
@@ -53,24 +84,31 @@ type serverScreenKeyMap struct {
 	Files        key.Binding
 	Logs         key.Binding
 	Accounts     key.Binding
+	Info         key.Binding
+	Chats        key.Binding
+	Invite       key.Binding
+	Ignore       key.Binding
+	Mentions     key.Binding
+	Links        key.Binding
+	NewSession   key.Binding
 	Disconnect   key.Binding
 	Send         key.Binding
 }
 
 func (k serverScreenKeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.MessageBoard, k.News, k.Files, k.Logs, k.Accounts, k.Disconnect}
+	return []key.Binding{k.MessageBoard, k.News, k.Files, k.Logs, k.Accounts, k.Info, k.Chats, k.Invite, k.Ignore, k.Mentions, k.Links, k.NewSession, k.Disconnect}
 }
 
 func (k serverScreenKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.MessageBoard, k.News, k.Files, k.Logs, k.Accounts, k.Disconnect},
+		{k.MessageBoard, k.News, k.Files, k.Logs, k.Accounts, k.Info, k.Chats, k.Invite, k.Ignore, k.Mentions, k.Links, k.NewSession, k.Disconnect},
 	}
 }
 
 // ServerScreen represents the main server UI after connecting
 type ServerScreen struct {
 	// Bubble Tea components
-	chatViewport viewport.Model
+	chat         *scrollback
 	chatInput    textinput.Model
 	userViewport viewport.Model
 	help         help.Model
@@ -83,13 +121,21 @@ type ServerScreen struct {
 	model *Model
 
 	// Screen-specific state
-	chatMessages    []string // Store original formatted messages for re-wrapping
-	chatContent     string   // Rendered chat content
-	chatWasAtBottom bool     // Track scroll position for smart auto-scroll
-	focusOnUserList bool     // true = user list focused, false = chat input focused
-	selectedUserIdx int      // index of selected user in userList
-	serverName      string   // Connected server name
+	mentions        []int         // Scrollback entries that mention us
+	mentionCursor   int           // Mention last jumped to; len(mentions) when none
+	unseenMentions  int           // Mentions added since we last jumped through them
+	ignoredRun      int           // Ignored lines collapsed into the last chat message
+	focusOnUserList bool          // true = user list focused, false = chat input focused
+	selectedUserIdx int           // index of selected user in userList
+	serverName      string        // Connected server name
+	rtt             time.Duration // Latest keepalive round-trip time, zero until measured
+	away            bool
 	userList        []hotline.User
+	completion      *nickCompletion // Name completion being cycled with tab, if any
+	historyIdx      int             // Input history line being shown, -1 when not browsing
+	historyDraft    string          // Input typed before browsing the history
+	olderCursor     *chatLogCursor  // Where the last lines loaded from the log began
+	loadingOlder    bool
 }
 
 // NewServerScreen creates a new server screen
@@ -120,6 +166,34 @@ func NewServerScreen(m *Model) *ServerScreen {
 			key.WithKeys("ctrl+a"),
 			key.WithHelp("^A", "accounts"),
 		),
+		Info: key.NewBinding(
+			key.WithKeys("ctrl+g"),
+			key.WithHelp("^G", "server info"),
+		),
+		Chats: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("^R", "private chats"),
+		),
+		Invite: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "invite to chat"),
+		),
+		Ignore: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "ignore"),
+		),
+		Mentions: key.NewBinding(
+			key.WithKeys("ctrl+x"),
+			key.WithHelp("^X", "mentions"),
+		),
+		Links: key.NewBinding(
+			key.WithKeys("ctrl+y"),
+			key.WithHelp("^Y", "links"),
+		),
+		NewSession: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("^O", "new session"),
+		),
 		Disconnect: key.NewBinding(
 			key.WithKeys("esc"),
 			key.WithHelp("esc", "disconnect"),
@@ -130,8 +204,11 @@ func NewServerScreen(m *Model) *ServerScreen {
 		),
 	}
 
-	return &ServerScreen{
-		chatViewport: viewport.New(m.width-30, m.height-9),
+	keys.Invite.SetEnabled(false)   // Only while the user list is focused
+	keys.Ignore.SetEnabled(false)   // Only while the user list is focused
+	keys.Mentions.SetEnabled(false) // Until someone mentions us
+
+	s := &ServerScreen{
 		chatInput:    chatInput,
 		userViewport: viewport.New(25, m.height-9),
 		help:         help.New(),
@@ -139,7 +216,11 @@ func NewServerScreen(m *Model) *ServerScreen {
 		width:        m.width,
 		height:       m.height,
 		model:        m,
+		historyIdx:   -1,
 	}
+	s.chat = newScrollback(m.prefs.scrollbackLines(), s.wrapChatMessage)
+	s.chat.setSize(m.width-30, m.height-9)
+	return s
 }
 
 // Init returns initial commands
@@ -185,6 +266,22 @@ func (s *ServerScreen) Update(msg tea.Msg) (ScreenModel, tea.Cmd) {
 		s.model.handleServerOpenTasksMsg()
 		return s, nil
 
+	case ServerOpenInfoMsg:
+		s.model.handleServerOpenInfoMsg()
+		return s, nil
+
+	case ServerInviteToChatMsg:
+		s.model.handleServerInviteToChatMsg(msg)
+		return s, nil
+
+	case ServerOpenChatMsg:
+		s.model.handleServerOpenChatMsg()
+		return s, nil
+
+	case ServerToggleIgnoreMsg:
+		s.model.handleServerToggleIgnoreMsg(msg)
+		return s, nil
+
 	case tea.KeyMsg:
 		return s.handleKeys(msg)
 	}
@@ -198,6 +295,7 @@ func (s *ServerScreen) Update(msg tea.Msg) (ScreenModel, tea.Cmd) {
 // View renders the screen
 func (s *ServerScreen) View() string {
 	// Shortcuts
+	s.keys.Chats.SetEnabled(len(s.model.chatRooms) > 0)
 	shortcuts := s.help.View(s.keys)
 
 	// User list
@@ -217,7 +315,9 @@ func (s *ServerScreen) View() string {
 		isAdmin := (flags & (1 << hotline.UserFlagAdmin)) != 0
 		isAway := (flags & (1 << hotline.UserFlagAway)) != 0
 
-		if isAdmin && isAway {
+		if s.model.ignores.ignores(u.ID, u.Name) {
+			userListContent.WriteString(ignoredStyle.Render(userName))
+		} else if isAdmin && isAway {
 			userListContent.WriteString(style.AwayAdminUserStyle.Render(userName))
 		} else if isAdmin {
 			userListContent.WriteString(style.AdminUserStyle.Render(userName))
@@ -238,7 +338,7 @@ func (s *ServerScreen) View() string {
 		chatBorder = lipgloss.DoubleBorder()
 
 		// Change to grey when in scrollback mode (not at bottom)
-		if !s.chatViewport.AtBottom() {
+		if !s.chat.atBottom() {
 			chatBorderColor = style.ColorLightGrey
 		}
 	}
@@ -247,7 +347,7 @@ func (s *ServerScreen) View() string {
 		PaddingLeft(1).
 		Border(chatBorder).
 		BorderForeground(chatBorderColor).
-		Render(s.chatViewport.View())
+		Render(s.chat.View())
 
 	// User list area - use double border when focused
 	userBorder := lipgloss.RoundedBorder()
@@ -261,7 +361,7 @@ func (s *ServerScreen) View() string {
 
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
-		style.ServerTitleStyle.Render(fmt.Sprintf("Mobius - Connected to %s", s.serverName)),
+		style.ServerTitleStyle.Render(s.title()),
 		shortcuts,
 		lipgloss.JoinHorizontal(
 			lipgloss.Top,
@@ -279,8 +379,7 @@ func (s *ServerScreen) SetSize(width, height int) {
 	chatWidth := width - 30
 	chatHeight := height - 9
 
-	s.chatViewport.Width = chatWidth
-	s.chatViewport.Height = chatHeight
+	s.chat.setSize(chatWidth, chatHeight)
 
 	// Update chat input width to match chat viewport
 	// Subtract additional padding for the input box border
@@ -288,9 +387,6 @@ func (s *ServerScreen) SetSize(width, height int) {
 
 	s.userViewport.Width = 25
 	s.userViewport.Height = height - 9
-
-	// Rebuild chat content with new width
-	s.rebuildChatContent()
 }
 
 // handleKeys handles keyboard input
@@ -300,8 +396,15 @@ func (s *ServerScreen) handleKeys(msg tea.KeyMsg) (ScreenModel, tea.Cmd) {
 		return s, func() tea.Msg { return ServerDisconnectRequestedMsg{} }
 
 	case "tab":
+		// Complete a partly typed name, otherwise toggle focus
+		if !s.focusOnUserList && s.completeNick() {
+			return s, nil
+		}
+
 		// Toggle focus between chat input and user list
 		s.focusOnUserList = !s.focusOnUserList
+		s.keys.Invite.SetEnabled(s.focusOnUserList)
+		s.keys.Ignore.SetEnabled(s.focusOnUserList)
 		if s.focusOnUserList {
 			// Blur chat input when switching to user list
 			s.chatInput.Blur()
@@ -326,12 +429,57 @@ func (s *ServerScreen) handleKeys(msg tea.KeyMsg) (ScreenModel, tea.Cmd) {
 	case "ctrl+t":
 		return s, func() tea.Msg { return ServerOpenTasksMsg{} }
 
+	case "ctrl+g":
+		return s, func() tea.Msg { return ServerOpenInfoMsg{} }
+
+	case "ctrl+r":
+		return s, func() tea.Msg { return ServerOpenChatMsg{} }
+
+	case "ctrl+x":
+		s.previousMention()
+		return s, nil
+
+	case "ctrl+y":
+		links := linksIn(s.chat.texts())
+		return s, func() tea.Msg { return LinksRequestedMsg{Links: links} }
+
+	case "i":
+		if s.focusOnUserList && s.selectedUserIdx < len(s.userList) {
+			targetID := s.userList[s.selectedUserIdx].ID
+			return s, func() tea.Msg {
+				return ServerInviteToChatMsg{TargetUserID: targetID}
+			}
+		}
+
+	case "x":
+		if s.focusOnUserList && s.selectedUserIdx < len(s.userList) {
+			targetID := s.userList[s.selectedUserIdx].ID
+			return s, func() tea.Msg {
+				return ServerToggleIgnoreMsg{TargetUserID: targetID}
+			}
+		}
+
+	case "ctrl+up":
+		if !s.focusOnUserList {
+			s.recallHistory(-1)
+		}
+		return s, nil
+
+	case "ctrl+down":
+		if !s.focusOnUserList {
+			s.recallHistory(1)
+		}
+		return s, nil
+
 	case "up":
 		if s.focusOnUserList && s.selectedUserIdx > 0 {
 			s.selectedUserIdx--
 		} else if !s.focusOnUserList {
-			// Scroll chat viewport up when chat input is focused
-			s.chatViewport.ScrollUp(1)
+			// Scroll chat up when chat input is focused, then into the log
+			if s.chat.atTop() {
+				return s, s.loadOlder()
+			}
+			s.chat.scrollUp(1)
 		}
 		return s, nil
 
@@ -340,35 +488,44 @@ func (s *ServerScreen) handleKeys(msg tea.KeyMsg) (ScreenModel, tea.Cmd) {
 			s.selectedUserIdx++
 		} else if !s.focusOnUserList {
 			// Scroll chat viewport down when chat input is focused
-			s.chatViewport.ScrollDown(1)
+			s.chat.scrollDown(1)
+			return s, s.catchUp()
 		}
 		return s, nil
 
 	case "pgup":
 		if !s.focusOnUserList {
-			// Page up in chat viewport
-			s.chatViewport.PageUp()
+			// Page up in chat, then into the log
+			if s.chat.atTop() {
+				return s, s.loadOlder()
+			}
+			s.chat.pageUp()
 		}
 		return s, nil
 
 	case "pgdown":
 		if !s.focusOnUserList {
 			// Page down in chat viewport
-			s.chatViewport.PageDown()
+			s.chat.pageDown()
+			return s, s.catchUp()
 		}
 		return s, nil
 
 	case "home":
 		if !s.focusOnUserList {
-			// Jump to top of chat
-			s.chatViewport.GotoTop()
+			// Jump to top of chat, then load older lines from the log
+			if s.chat.atTop() {
+				return s, s.loadOlder()
+			}
+			s.chat.gotoTop()
 		}
 		return s, nil
 
 	case "end":
 		if !s.focusOnUserList {
 			// Jump to bottom of chat
-			s.chatViewport.GotoBottom()
+			s.chat.gotoBottom()
+			return s, s.catchUp()
 		}
 		return s, nil
 
@@ -386,6 +543,7 @@ func (s *ServerScreen) handleKeys(msg tea.KeyMsg) (ScreenModel, tea.Cmd) {
 			text := s.chatInput.Value()
 			if text != "" {
 				s.chatInput.SetValue("")
+				s.historyIdx = -1
 				return s, func() tea.Msg {
 					return ServerSendChatMsg{Text: text}
 				}
@@ -404,11 +562,86 @@ func (s *ServerScreen) handleKeys(msg tea.KeyMsg) (ScreenModel, tea.Cmd) {
 	return s, nil
 }
 
+// completeNick completes the user name before the cursor, cycling through the
+// matches on repeated presses. It reports whether there was a name to complete.
+func (s *ServerScreen) completeNick() bool {
+	value := s.chatInput.Value()
+	if s.completion == nil || s.completion.result != value {
+		s.completion = newNickCompletion(value, s.chatInput.Position(), s.userList)
+		if s.completion == nil {
+			return false
+		}
+	}
+
+	value, pos := s.completion.next()
+	s.chatInput.SetValue(value)
+	s.chatInput.SetCursor(pos)
+	return true
+}
+
+// recallHistory replaces the input with an earlier (delta -1) or later (delta 1)
+// line from this server's input history. Moving past the newest line brings back
+// what was being typed.
+func (s *ServerScreen) recallHistory(delta int) {
+	lines := s.model.inputHistory()
+	if s.historyIdx < 0 || s.historyIdx > len(lines) {
+		s.historyIdx = len(lines)
+		s.historyDraft = s.chatInput.Value()
+	}
+
+	idx := s.historyIdx + delta
+	if idx < 0 || idx > len(lines) {
+		return
+	}
+	s.historyIdx = idx
+	if idx == len(lines) {
+		s.chatInput.SetValue(s.historyDraft)
+		s.historyIdx = -1
+	} else {
+		s.chatInput.SetValue(lines[idx])
+	}
+	s.chatInput.CursorEnd()
+}
+
 // SetServerName sets the connected server name
 func (s *ServerScreen) SetServerName(name string) {
 	s.serverName = name
 }
 
+// SetRTT sets the round-trip time shown in the title
+func (s *ServerScreen) SetRTT(rtt time.Duration) {
+	s.rtt = rtt
+}
+
+// SetAway sets whether the title shows us as away
+func (s *ServerScreen) SetAway(away bool) {
+	s.away = away
+}
+
+// title returns the screen title with the server name, latency and away status
+func (s *ServerScreen) title() string {
+	title := fmt.Sprintf("Mobius - Connected to %s", s.serverName)
+	if s.rtt > 0 {
+		title += fmt.Sprintf(" (%s)", s.rtt.Round(time.Millisecond))
+	}
+	if s.away {
+		title += " - Away"
+	}
+
+	// Point out private chat lines that arrived while we were here
+	unread := 0
+	for _, room := range s.model.chatRooms {
+		unread += room.unread
+	}
+	if unread > 0 {
+		title += fmt.Sprintf(" - %d unread in private chats", unread)
+	}
+	if s.unseenMentions > 0 {
+		title += fmt.Sprintf(" - %d new mentions", s.unseenMentions)
+	}
+	return title
+}
+
 // SetUserList updates the user list
 func (s *ServerScreen) SetUserList(users []hotline.User) {
 	s.userList = users
@@ -421,27 +654,81 @@ func (s *ServerScreen) SetUserList(users []hotline.User) {
 	}
 }
 
-// AddChatMessage adds a new chat message and updates the viewport
+// AddChatMessage adds a new chat message. The chat keeps following new lines
+// if it was scrolled to the bottom.
 func (s *ServerScreen) AddChatMessage(formattedMsg string) {
-	// Track if viewport was at bottom before adding
-	s.chatWasAtBottom = s.chatViewport.AtBottom()
+	s.chat.add(formattedMsg, time.Now())
+	s.ignoredRun = 0
+}
+
+// AddMention adds a chat message that mentions us, so it can be jumped to
+func (s *ServerScreen) AddMention(formattedMsg string) {
+	s.AddChatMessage(formattedMsg)
+	if s.chat.behind {
+		return
+	}
+	s.mentions = append(s.mentions, s.chat.end()-1)
+	s.mentionCursor = len(s.mentions)
+	s.unseenMentions++
+	s.keys.Mentions.SetEnabled(true)
+}
+
+// AddIgnoredLine counts a chat message from an ignored user, collapsing a run
+// of them into one line
+func (s *ServerScreen) AddIgnoredLine() {
+	run := s.ignoredRun + 1
+	text := "[1 line from ignored users]"
+	if run > 1 {
+		text = fmt.Sprintf("[%d lines from ignored users]", run)
+	}
+	text = ignoredStyle.UnsetStrikethrough().Render(text)
 
-	s.chatMessages = append(s.chatMessages, formattedMsg)
-	s.rebuildChatContent()
+	if s.ignoredRun > 0 {
+		s.chat.replaceLast(text)
+	} else {
+		s.AddChatMessage(text)
+	}
+	s.ignoredRun = run
+}
 
-	// Auto-scroll to bottom if user was already at bottom
-	if s.chatWasAtBottom {
-		s.chatViewport.GotoBottom()
+// previousMention scrolls the chat to the mention before the last one jumped to,
+// wrapping around to the newest. Mentions that have left the scrollback are
+// forgotten.
+func (s *ServerScreen) previousMention() {
+	s.mentions = slices.DeleteFunc(s.mentions, func(n int) bool { return n < s.chat.first })
+	s.mentionCursor = min(s.mentionCursor, len(s.mentions))
+	s.keys.Mentions.SetEnabled(len(s.mentions) > 0)
+	if len(s.mentions) == 0 {
+		return
 	}
+	s.mentionCursor--
+	if s.mentionCursor < 0 {
+		s.mentionCursor = len(s.mentions) - 1
+	}
+	s.unseenMentions = 0
+	s.chat.jumpTo(s.mentions[s.mentionCursor])
+}
+
+// ClearChat removes every message from the chat pane
+func (s *ServerScreen) ClearChat() {
+	s.chat.clear()
+	s.mentions = nil
+	s.olderCursor = nil
+	s.mentionCursor = 0
+	s.unseenMentions = 0
+	s.ignoredRun = 0
+	s.keys.Mentions.SetEnabled(false)
 }
 
 // FocusChatInput sets focus to the chat input
 func (s *ServerScreen) FocusChatInput() {
 	s.focusOnUserList = false
+	s.keys.Invite.SetEnabled(false)
+	s.keys.Ignore.SetEnabled(false)
 	s.chatInput.Focus()
 }
 
-// wrapChatMessage wraps a chat message to fit within the chat viewport width
+// wrapChatMessage wraps a chat message to fit within the chat pane
 func (s *ServerScreen) wrapChatMessage(formattedMsg string) string {
 	const borderWidth = 2
 	const paddingLeft = 1 // From chatView rendering (PaddingLeft)
@@ -457,20 +744,93 @@ func (s *ServerScreen) wrapChatMessage(formattedMsg string) string {
 		wrapWidth = 5 // Minimum for edge cases
 	}
 
-	// wordwrap.String handles ANSI codes correctly
-	return wordwrap.String(formattedMsg, wrapWidth)
+	return wrapLinks(formattedMsg, wrapWidth, s.model.prefs.hyperlinks())
 }
 
-// rebuildChatContent rebuilds entire chat content from stored messages,
-// applying word-wrapping based on current viewport width
-func (s *ServerScreen) rebuildChatContent() {
-	var content strings.Builder
-	for _, msg := range s.chatMessages {
-		content.WriteString(fmt.Sprintf("%s\n", s.wrapChatMessage(msg)))
+// loadOlder reads the chat log lines before the oldest one in the scrollback
+func (s *ServerScreen) loadOlder() tea.Cmd {
+	params := s.model.activeConnection
+	if s.loadingOlder || params == nil || s.model.chatLog == nil {
+		return nil
+	}
+	s.loadingOlder = true
+
+	dir, server := s.model.chatLog.Dir(), params.addr
+	cursor := s.olderCursor
+	oldest, ok := s.chat.oldest()
+	if !ok {
+		oldest = time.Now()
 	}
 
-	s.chatContent = content.String()
-	s.chatViewport.SetContent(s.chatContent)
+	// Lines shown from the oldest second were logged too; don't load them twice
+	shown := 0
+	s.chat.each(func(text string, when time.Time) {
+		if when.Truncate(time.Second).Equal(oldest.Truncate(time.Second)) {
+			shown += len(chatLogLines(text))
+		}
+	})
+
+	return func() tea.Msg {
+		if cursor == nil {
+			c, err := chatLogCursorAt(dir, server, oldest, shown)
+			if err != nil {
+				return chatOlderMsg{err: err}
+			}
+			cursor = &c
+		}
+		lines, c, err := readChatLogBefore(dir, server, *cursor, chatOlderPage)
+		return chatOlderMsg{lines: lines, cursor: c, err: err}
+	}
+}
+
+// catchUp loads the newest lines from the chat log once the view reaches the
+// bottom of a scrollback that dropped them
+func (s *ServerScreen) catchUp() tea.Cmd {
+	params := s.model.activeConnection
+	if !s.chat.behind || !s.chat.atBottom() || s.loadingOlder || params == nil || s.model.chatLog == nil {
+		return nil
+	}
+	s.loadingOlder = true
+
+	dir, server := s.model.chatLog.Dir(), params.addr
+	n := min(chatOlderPage, s.chat.limit)
+	return func() tea.Msg {
+		c, err := chatLogCursorAt(dir, server, time.Now(), 0)
+		if err != nil {
+			return chatOlderMsg{newest: true, err: err}
+		}
+		lines, c, err := readChatLogBefore(dir, server, c, n)
+		return chatOlderMsg{lines: lines, cursor: c, newest: true, err: err}
+	}
+}
+
+// AddOlder puts lines from the chat log in front of the scrollback, keeping
+// the view where it was. The newest lines replace the scrollback instead.
+func (s *ServerScreen) AddOlder(msg chatOlderMsg) {
+	s.loadingOlder = false
+	if msg.err != nil || len(msg.lines) == 0 {
+		// Without the log, show new messages again after the gap
+		s.chat.behind = s.chat.behind && !msg.newest
+		return
+	}
+	s.olderCursor = &msg.cursor
+
+	older := make([]string, len(msg.lines))
+	times := make([]time.Time, len(msg.lines))
+	for i, line := range msg.lines {
+		older[i] = chatOlderStyle.Render(line.text)
+		times[i] = line.when
+	}
+	if msg.newest {
+		s.chat.clear()
+		s.mentions = nil
+		s.mentionCursor = 0
+		s.keys.Mentions.SetEnabled(false)
+		s.chat.prepend(older, times)
+		return
+	}
+	s.chat.prepend(older, times)
+	s.chat.scrollUp(1)
 }
 
 // SetUserAccess updates keybindings based on user access permissions
@@ -478,3 +838,14 @@ func (s *ServerScreen) SetUserAccess(access hotline.AccessBitmap) {
 	s.keys.News.SetEnabled(access.IsSet(hotline.AccessNewsReadArt))
 	s.keys.Accounts.SetEnabled(access.IsSet(hotline.AccessModifyUser))
 }
+
+func (m *Model) handleChatOlderMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	older := msg.(chatOlderMsg)
+	if older.err != nil {
+		m.logger.Warn("Unable to read chat log", "err", older.err)
+	}
+	if m.serverScreen != nil {
+		m.serverScreen.AddOlder(older)
+	}
+	return m, nil
+}
@@ -0,0 +1,261 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jhalter/mobius-hotline-client/internal/style"
+	"github.com/jhalter/mobius/hotline"
+)
+
+// serverInfo is what the server told us about itself while logging in
+type serverInfo struct {
+	name        string // Self-reported server name, empty if not sent
+	version     uint16 // Protocol version from the login reply, zero if not sent
+	bannerID    uint16 // Community banner ID from the login reply
+	bannerType  string // Banner type announced with TranServerBanner
+	bannerURL   string // Banner link announced with TranServerBanner
+	connectedAt time.Time
+	agreement   string // Server agreement text, empty if the server has none
+}
+
+// Messages sent from ServerInfoScreen to parent
+
+// ServerInfoCancelledMsg signals user wants to close the server info screen
+type ServerInfoCancelledMsg struct{}
+
+// ServerInfoShowAgreementMsg signals user wants to re-read the server agreement
+type ServerInfoShowAgreementMsg struct{}
+
+// serverInfoScreenKeyMap defines key bindings for the server info screen help display
+type serverInfoScreenKeyMap struct {
+	Up        key.Binding
+	Down      key.Binding
+	Agreement key.Binding
+	Back      key.Binding
+}
+
+func (k serverInfoScreenKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Agreement, k.Back}
+}
+
+func (k serverInfoScreenKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{k.Up, k.Down, k.Agreement, k.Back}}
+}
+
+// ServerInfoScreen shows details of the connected server
+type ServerInfoScreen struct {
+	viewport      viewport.Model
+	width, height int
+	model         *Model
+	help          help.Model
+	keys          serverInfoScreenKeyMap
+
+	banner     string // Server banner rendered for the current width
+	bannerRows int
+	graphics   string // How the banner is drawn
+}
+
+// NewServerInfoScreen creates a server info screen for the active session
+func NewServerInfoScreen(m *Model) *ServerInfoScreen {
+	keys := serverInfoScreenKeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		Agreement: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "agreement"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "back"),
+		),
+	}
+	keys.Agreement.SetEnabled(m.serverInfo.agreement != "")
+
+	vp := viewport.New(m.width-10, m.height-10)
+	vp.SetContent(m.renderServerInfo())
+
+	s := &ServerInfoScreen{
+		viewport: vp,
+		model:    m,
+		help:     help.New(),
+		keys:     keys,
+		graphics: m.prefs.bannerGraphics(),
+	}
+	s.SetSize(m.width, m.height)
+	return s
+}
+
+// Init implements tea.Model
+func (s *ServerInfoScreen) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements ScreenModel
+func (s *ServerInfoScreen) Update(msg tea.Msg) (ScreenModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.SetSize(msg.Width, msg.Height)
+		return s, nil
+
+	case ServerInfoCancelledMsg:
+		s.model.PopScreen()
+		return s, s.clearGraphics()
+
+	case ServerInfoShowAgreementMsg:
+		return s, tea.Batch(s.clearGraphics(), s.model.handleServerInfoShowAgreementMsg())
+
+	case tea.KeyMsg:
+		return s.handleKeys(msg)
+	}
+
+	var cmd tea.Cmd
+	s.viewport, cmd = s.viewport.Update(msg)
+	return s, cmd
+}
+
+// View implements tea.Model
+func (s *ServerInfoScreen) View() string {
+	var sections []string
+	if s.banner != "" {
+		sections = append(sections, s.banner, " ")
+	}
+	sections = append(sections, s.viewport.View(), " ", s.help.View(s.keys))
+
+	return style.RenderSubscreen(s.width, s.height, "Server Info",
+		lipgloss.JoinVertical(lipgloss.Left, sections...),
+	)
+}
+
+// SetSize updates dimensions, redrawing the banner to fit
+func (s *ServerInfoScreen) SetSize(width, height int) {
+	s.width = width
+	s.height = height
+	s.viewport.Width = width - 10
+
+	s.banner, s.bannerRows = "", 0
+	if s.model.banner != nil {
+		// Leave the details at least half the screen
+		rows := min(maxBannerRows, (height-10)/2)
+		s.banner, s.bannerRows = renderBanner(s.model.banner, s.graphics, width-10, rows)
+	}
+
+	s.viewport.Height = height - 10
+	if s.bannerRows > 0 {
+		s.viewport.Height -= s.bannerRows + 1
+	}
+}
+
+// clearGraphics wipes an image drawn with Sixel or the kitty protocol, which
+// the terminal would otherwise leave on screen after the view changes
+func (s *ServerInfoScreen) clearGraphics() tea.Cmd {
+	if s.bannerRows == 0 || s.graphics == bannerGraphicsHalfBlock {
+		return nil
+	}
+	return tea.ClearScreen
+}
+
+// handleKeys handles keyboard input
+func (s *ServerInfoScreen) handleKeys(msg tea.KeyMsg) (ScreenModel, tea.Cmd) {
+	switch {
+	case key.Matches(msg, s.keys.Back):
+		return s, func() tea.Msg { return ServerInfoCancelledMsg{} }
+	case key.Matches(msg, s.keys.Agreement):
+		return s, func() tea.Msg { return ServerInfoShowAgreementMsg{} }
+	}
+
+	var cmd tea.Cmd
+	s.viewport, cmd = s.viewport.Update(msg)
+	return s, cmd
+}
+
+// renderServerInfo formats the active session's server details
+func (m *Model) renderServerInfo() string {
+	info := m.serverInfo
+	var b strings.Builder
+
+	row := func(label, value string) {
+		b.WriteString(style.CategoryStyle.Render(fmt.Sprintf("%-18s", label)))
+		b.WriteString(value)
+		b.WriteString("\n")
+	}
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+
+	row("Server name", orDash(info.name))
+	if m.activeConnection != nil {
+		row("Address", m.activeConnection.addr)
+	}
+	if info.version != 0 {
+		row("Protocol version", fmt.Sprintf("%d", info.version))
+	} else {
+		row("Protocol version", "-")
+	}
+	row("Community banner", fmt.Sprintf("%d", info.bannerID))
+	if info.bannerType != "" || info.bannerURL != "" {
+		row("Banner", strings.TrimSpace(orDash(info.bannerType)+" "+info.bannerURL))
+	}
+
+	tlsState := "No"
+	if m.connectionUsesTLS {
+		tlsState = "Yes"
+		if m.certVerifier != nil && m.certVerifier.fingerprint != "" {
+			tlsState += ", certificate " + m.certVerifier.fingerprint
+		}
+	}
+	row("TLS", tlsState)
+
+	if !info.connectedAt.IsZero() {
+		row("Connected", fmt.Sprintf("%s (%s ago)",
+			info.connectedAt.Format(time.DateTime),
+			time.Since(info.connectedAt).Round(time.Second)))
+	}
+
+	agreement := "None"
+	if info.agreement != "" {
+		agreement = "Press a to read it again"
+	}
+	row("Agreement", agreement)
+
+	b.WriteString("\n")
+	b.WriteString(style.CategoryStyle.Render("Permissions"))
+	b.WriteString("\n")
+	for _, category := range accessBitsByCategory {
+		var granted []string
+		for _, bit := range category.bits {
+			if m.userAccess.IsSet(bit.bit) {
+				granted = append(granted, bit.name)
+			}
+		}
+		if len(granted) > 0 {
+			b.WriteString(fmt.Sprintf("  %s: %s\n", category.category, strings.Join(granted, ", ")))
+		}
+	}
+
+	return b.String()
+}
+
+// serverBannerMsg reports the banner the server announced after login
+type serverBannerMsg struct {
+	bannerType string
+	url        string
+}
+
+// fieldBannerURL is the TranServerBanner field holding the banner's link
+var fieldBannerURL = hotline.FieldType{0x00, 0x99}
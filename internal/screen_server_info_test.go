@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/jhalter/mobius/hotline"
+)
+
+func TestRenderServerInfo(t *testing.T) {
+	m, _ := newTestModel(t, &Settings{})
+	m.activeConnection = &connectionParams{addr: "hotline.example.com:5500"}
+	m.serverInfo = serverInfo{
+		name:        "Example",
+		version:     190,
+		bannerID:    7,
+		bannerType:  "URL ",
+		bannerURL:   "https://example.com",
+		connectedAt: time.Now().Add(-time.Minute),
+		agreement:   "Be nice",
+	}
+	m.connectionUsesTLS = true
+	m.certVerifier = &certVerifier{fingerprint: "SHA256:abc"}
+	m.userAccess.Set(hotline.AccessDownloadFile)
+	m.userAccess.Set(hotline.AccessSendChat)
+
+	got := ansi.Strip(m.renderServerInfo())
+	for _, want := range []string{
+		"Server name       Example",
+		"Address           hotline.example.com:5500",
+		"Protocol version  190",
+		"Community banner  7",
+		"Banner            URL  https://example.com",
+		"TLS               Yes, certificate SHA256:abc",
+		"ago)",
+		"Agreement         Press a to read it again",
+		"Download Files",
+		"Send Chat",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("server info is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Upload Files") {
+		t.Errorf("server info lists a permission we don't have:\n%s", got)
+	}
+}
+
+func TestRenderServerInfoMissingDetails(t *testing.T) {
+	m, _ := newTestModel(t, &Settings{})
+
+	got := ansi.Strip(m.renderServerInfo())
+	for _, want := range []string{
+		"Server name       -",
+		"Protocol version  -",
+		"TLS               No",
+		"Agreement         None",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("server info is missing %q:\n%s", want, got)
+		}
+	}
+	for _, absent := range []string{"Address", "Banner ", "Connected"} {
+		if strings.Contains(got, absent) {
+			t.Errorf("server info shows %q without the details:\n%s", absent, got)
+		}
+	}
+}
+
+func TestServerInfoAgreementKey(t *testing.T) {
+	for _, agreement := range []string{"", "Be nice"} {
+		m, _ := newTestModel(t, &Settings{})
+		m.serverInfo.agreement = agreement
+		s := NewServerInfoScreen(m)
+
+		_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+		var msg tea.Msg
+		if cmd != nil {
+			msg = cmd()
+		}
+		_, showing := msg.(ServerInfoShowAgreementMsg)
+		if showing != (agreement != "") {
+			t.Errorf("with agreement %q, a returned %#v", agreement, msg)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"encoding/binary"
 	"strconv"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -43,6 +44,63 @@ type Settings struct {
 	EnableBell   bool       `yaml:"EnableBell"`
 	EnableSounds bool       `yaml:"EnableSounds"`
 	DownloadDir  string     `yaml:"DownloadDir"`
+
+	// KnownHostsFile stores pinned TLS certificate fingerprints (defaults to a file next to the config)
+	KnownHostsFile string `yaml:"KnownHostsFile,omitempty"`
+
+	// HistoryFile stores the lines typed into chat for each server (defaults to a file next to the config)
+	HistoryFile string `yaml:"HistoryFile,omitempty"`
+
+	// Proxy is used for server, file transfer and tracker connections unless a bookmark overrides it
+	Proxy *ProxyConfig `yaml:"Proxy,omitempty"`
+
+	// ConnectTimeout limits each server connection attempt, in seconds (defaults to 15)
+	ConnectTimeout int `yaml:"ConnectTimeout,omitempty"`
+
+	// KeepAliveInterval is the time between keepalives, in seconds (defaults to 60)
+	KeepAliveInterval int `yaml:"KeepAliveInterval,omitempty"`
+	// KeepAliveMaxMissed unanswered keepalives in a row close the connection (defaults to 3)
+	KeepAliveMaxMissed int `yaml:"KeepAliveMaxMissed,omitempty"`
+	// KeepAliveWaitForReply only counts missed keepalives once the server has answered one,
+	// for servers that never answer them
+	KeepAliveWaitForReply bool `yaml:"KeepAliveWaitForReply,omitempty"`
+
+	// AutoAwayMinutes of keyboard inactivity mark us away on every server (0 disables)
+	AutoAwayMinutes int `yaml:"AutoAwayMinutes,omitempty"`
+	// AwayMessage is sent once to each user who messages us while away (empty disables)
+	AwayMessage string `yaml:"AwayMessage,omitempty"`
+
+	// BannerGraphics selects how server banners are drawn: auto, sixel, kitty, halfblock or off
+	BannerGraphics string `yaml:"BannerGraphics,omitempty"`
+
+	// ChatLogDir holds per-server chat logs (defaults to a logs directory in the user config directory)
+	ChatLogDir string `yaml:"ChatLogDir,omitempty"`
+	// ScrollbackLines is how many chat messages the server screen keeps (defaults to 5000).
+	// Older ones can be loaded back from the chat log.
+	ScrollbackLines int `yaml:"ScrollbackLines,omitempty"`
+
+	// Mentions highlight public chat lines with our name or other keywords
+	Mentions MentionConfig `yaml:"Mentions,omitempty"`
+
+	// Ignore hides chat, private messages and join/leave notices from users whose
+	// names match these patterns, e.g. "spammer*", on every server
+	Ignore []string `yaml:"Ignore,omitempty"`
+	// IgnoredChat is "hide" (default) to drop ignored users' chat, or "collapse" to count it
+	IgnoredChat string `yaml:"IgnoredChat,omitempty"`
+	// DimIgnoredNews fades news articles posted by ignored names
+	DimIgnoredNews bool `yaml:"DimIgnoredNews,omitempty"`
+
+	// Triggers react to chat and private messages with replies, sounds, commands or files
+	Triggers TriggerConfig `yaml:"Triggers,omitempty"`
+
+	// Encoding of server text: auto (default), utf-8, macroman or shift-jis. Bookmarks can override it.
+	Encoding string `yaml:"Encoding,omitempty"`
+
+	// Hyperlinks makes links clickable with OSC 8: auto, on or off
+	Hyperlinks string `yaml:"Hyperlinks,omitempty"`
+	// OpenCommand opens links from the links picker, e.g. "firefox --new-tab"
+	// (defaults to open, xdg-open or the Windows URL handler)
+	OpenCommand string `yaml:"OpenCommand,omitempty"`
 }
 
 func (cp *Settings) IconBytes() []byte {
@@ -61,6 +119,8 @@ type SettingsSavedMsg struct {
 	IconID       int
 	Tracker      string
 	DownloadDir  string
+	ChatLogDir   string
+	Ignore       []string
 	EnableBell   bool
 	EnableSounds bool
 }
@@ -80,12 +140,14 @@ type SettingsScreen struct {
 	iconID       string
 	tracker      string
 	downloadDir  string
+	chatLogDir   string
+	ignore       string
 	enableBell   bool
 	enableSounds bool
 }
 
 // buildSettingsForm creates a Huh form for editing settings
-func buildSettingsForm(username, iconID, tracker, downloadDir *string, enableBell, enableSounds *bool) *huh.Form {
+func buildSettingsForm(username, iconID, tracker, downloadDir, chatLogDir, ignore *string, enableBell, enableSounds *bool) *huh.Form {
 	return huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().
@@ -112,6 +174,22 @@ func buildSettingsForm(username, iconID, tracker, downloadDir *string, enableBel
 				Placeholder("Download Directory").
 				Value(downloadDir),
 
+			huh.NewInput().
+				Key("chatLogDir").
+				Title("Chat Log Directory").
+				Placeholder(defaultChatLogDir()).
+				Value(chatLogDir),
+
+			huh.NewInput().
+				Key("ignore").
+				Title("Ignored Users").
+				Placeholder("Names or patterns, comma separated").
+				Validate(func(s string) error {
+					_, err := parseIgnorePatterns(s)
+					return err
+				}).
+				Value(ignore),
+
 			huh.NewConfirm().
 				Key("enableBell").
 				Title("Terminal Bell").
@@ -145,11 +223,13 @@ func NewSettingsScreen(prefs *Settings, m *Model) (*SettingsScreen, tea.Cmd) {
 		iconID:       strconv.Itoa(prefs.IconID),
 		tracker:      prefs.Tracker,
 		downloadDir:  prefs.DownloadDir,
+		chatLogDir:   prefs.ChatLogDir,
+		ignore:       strings.Join(prefs.Ignore, ", "),
 		enableBell:   prefs.EnableBell,
 		enableSounds: prefs.EnableSounds,
 	}
 
-	screen.form = buildSettingsForm(&screen.username, &screen.iconID, &screen.tracker, &screen.downloadDir, &screen.enableBell, &screen.enableSounds)
+	screen.form = buildSettingsForm(&screen.username, &screen.iconID, &screen.tracker, &screen.downloadDir, &screen.chatLogDir, &screen.ignore, &screen.enableBell, &screen.enableSounds)
 
 	return screen, screen.form.Init()
 }
@@ -205,6 +285,8 @@ func (s *SettingsScreen) handleSubmit() tea.Cmd {
 	username := s.username
 	tracker := s.tracker
 	downloadDir := s.downloadDir
+	chatLogDir := s.chatLogDir
+	ignore, _ := parseIgnorePatterns(s.ignore)
 	enableBell := s.enableBell
 	enableSounds := s.enableSounds
 
@@ -219,6 +301,8 @@ func (s *SettingsScreen) handleSubmit() tea.Cmd {
 			IconID:       iconID,
 			Tracker:      tracker,
 			DownloadDir:  downloadDir,
+			ChatLogDir:   chatLogDir,
+			Ignore:       ignore,
 			EnableBell:   enableBell,
 			EnableSounds: enableSounds,
 		}
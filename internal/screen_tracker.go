@@ -3,7 +3,6 @@ package internal
 import (
 	"encoding/binary"
 	"fmt"
-	"net"
 	"time"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -158,9 +157,9 @@ func newTrackerDelegate() list.DefaultDelegate {
 }
 
 // fetchTrackerList fetches the server list from the configured tracker
-func fetchTrackerList(tracker string) tea.Cmd {
+func fetchTrackerList(tracker string, d *dialer) tea.Cmd {
 	return func() tea.Msg {
-		conn, err := net.DialTimeout("tcp", tracker, 5*time.Second)
+		conn, err := d.Dial(tracker)
 		if err != nil {
 			return errorMsg{text: fmt.Sprintf("Error connecting to tracker:\n%v", err)}
 		}
@@ -108,7 +108,7 @@ func (m *Model) renderCompactTask(task *Task) string {
 }
 
 // encodeNewsPath encodes a news path into bytes for the FieldNewsPath field
-func encodeNewsPath(path []string) []byte {
+func (s *Session) encodeNewsPath(path []string) []byte {
 	if len(path) == 0 {
 		return []byte{}
 	}
@@ -120,11 +120,12 @@ func encodeNewsPath(path []string) []byte {
 	buf = append(buf, countBytes...)
 
 	for _, name := range path {
+		encoded := s.encodeText(name)
 		buf = append(buf, 0, 0)
 		// Add length byte
-		buf = append(buf, byte(len(name)))
+		buf = append(buf, byte(len(encoded)))
 		// Add name
-		buf = append(buf, []byte(name)...)
+		buf = append(buf, encoded...)
 	}
 
 	return buf
@@ -0,0 +1,315 @@
+package internal
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// defaultScrollbackLines is how many chat messages the server screen keeps when
+// ScrollbackLines isn't set
+const defaultScrollbackLines = 5000
+
+// scrollbackLines returns the configured scrollback size
+func (cp *Settings) scrollbackLines() int {
+	if cp.ScrollbackLines > 0 {
+		return cp.ScrollbackLines
+	}
+	return defaultScrollbackLines
+}
+
+// scrollbackEntry is one chat message and its wrapped lines, cached until the
+// width changes
+type scrollbackEntry struct {
+	text    string
+	when    time.Time
+	wrapped []string
+	gen     int // Wrap generation wrapped was made for
+}
+
+// scrollback is a bounded chat history that only wraps and renders the lines on
+// screen. Entries are numbered in the order they were added, so a number stays
+// valid while older entries are evicted or loaded in front.
+type scrollback struct {
+	entries []scrollbackEntry // Ring buffer
+	head    int               // Slot of the oldest entry
+	count   int
+	first   int // Number of the oldest entry
+	limit   int
+
+	width, height int
+	wrap          func(string) string
+	gen           int // Bumped when wrapped lines go stale
+
+	// Scroll position: the first visible line, as an entry number and a line
+	// within it. Ignored while following the newest line.
+	top, topLine int
+	follow       bool
+
+	// Newer messages were dropped to stay within the limit while the oldest
+	// were on screen. Only the chat log has them until they are loaded again.
+	behind bool
+}
+
+func newScrollback(limit int, wrap func(string) string) *scrollback {
+	return &scrollback{limit: limit, wrap: wrap, follow: true}
+}
+
+// entry returns the entry numbered n, which must be retained
+func (b *scrollback) entry(n int) *scrollbackEntry {
+	return &b.entries[(b.head+n-b.first)%len(b.entries)]
+}
+
+// end returns the number the next entry will get
+func (b *scrollback) end() int {
+	return b.first + b.count
+}
+
+// lines returns the wrapped lines of entry n
+func (b *scrollback) lines(n int) []string {
+	e := b.entry(n)
+	if e.wrapped == nil || e.gen != b.gen {
+		e.wrapped = strings.Split(b.wrap(e.text), "\n")
+		e.gen = b.gen
+	}
+	return e.wrapped
+}
+
+// grow makes room for one more entry
+func (b *scrollback) grow() {
+	if b.count < len(b.entries) {
+		return
+	}
+	entries := make([]scrollbackEntry, max(2*len(b.entries), 64))
+	for i := range b.count {
+		entries[i] = b.entries[(b.head+i)%len(b.entries)]
+	}
+	b.entries = entries
+	b.head = 0
+}
+
+// add appends a message, evicting the oldest ones over the limit. If the
+// oldest are on screen, the message is dropped instead and the history is
+// behind until the newest lines are loaded again.
+func (b *scrollback) add(text string, when time.Time) {
+	for !b.behind && b.count >= b.limit {
+		if !b.follow && b.first >= b.top {
+			b.behind = true
+			break
+		}
+		b.entries[b.head] = scrollbackEntry{}
+		b.head = (b.head + 1) % len(b.entries)
+		b.first++
+		b.count--
+	}
+	if b.behind {
+		return
+	}
+
+	b.grow()
+	b.entries[(b.head+b.count)%len(b.entries)] = scrollbackEntry{text: text, when: when}
+	b.count++
+}
+
+// prepend inserts older messages and when each was sent, oldest first, in
+// front of the history. Over the limit, the newest messages below the view
+// are dropped and the history is behind.
+func (b *scrollback) prepend(texts []string, times []time.Time) {
+	for i := len(texts) - 1; i >= 0; i-- {
+		b.grow()
+		b.head = (b.head - 1 + len(b.entries)) % len(b.entries)
+		b.first--
+		b.count++
+		b.entries[b.head] = scrollbackEntry{text: texts[i], when: times[i]}
+	}
+
+	if b.count > b.limit {
+		b.anchor()
+	}
+	for b.count > b.limit && b.end()-1 > b.top {
+		b.entries[(b.head+b.count-1)%len(b.entries)] = scrollbackEntry{}
+		b.count--
+		b.behind = true
+	}
+}
+
+// replaceLast changes the text of the newest message
+func (b *scrollback) replaceLast(text string) {
+	if b.count == 0 || b.behind {
+		return
+	}
+	e := b.entry(b.end() - 1)
+	e.text = text
+	e.wrapped = nil
+}
+
+// clear removes every message
+func (b *scrollback) clear() {
+	b.first = b.end()
+	b.entries = nil
+	b.head, b.count = 0, 0
+	b.top, b.topLine = b.first, 0
+	b.follow = true
+	b.behind = false
+}
+
+// texts returns the text of every message, oldest first
+func (b *scrollback) texts() []string {
+	texts := make([]string, 0, b.count)
+	for n := b.first; n < b.end(); n++ {
+		texts = append(texts, b.entry(n).text)
+	}
+	return texts
+}
+
+// each calls fn with every message and when it was added, oldest first
+func (b *scrollback) each(fn func(text string, when time.Time)) {
+	for n := b.first; n < b.end(); n++ {
+		e := b.entry(n)
+		fn(e.text, e.when)
+	}
+}
+
+// oldest returns when the oldest message was added
+func (b *scrollback) oldest() (time.Time, bool) {
+	if b.count == 0 {
+		return time.Time{}, false
+	}
+	return b.entry(b.first).when, true
+}
+
+// setSize changes the view size and the width messages are wrapped to
+func (b *scrollback) setSize(width, height int) {
+	b.width, b.height = width, height
+	b.rewrap()
+}
+
+// rewrap marks every wrapped line stale, to be wrapped again when next shown
+func (b *scrollback) rewrap() {
+	b.gen++
+}
+
+// anchor pins the scroll position to what is currently shown, so scrolling
+// starts from there rather than from the newest line
+func (b *scrollback) anchor() {
+	if !b.follow {
+		return
+	}
+	b.follow = false
+	b.top, b.topLine = b.end(), 0
+	for rows := 0; rows < b.height && b.top > b.first; {
+		b.top--
+		n := len(b.lines(b.top))
+		b.topLine = max(n-(b.height-rows), 0)
+		rows += n - b.topLine
+	}
+}
+
+// settle goes back to following the newest line once it is on screen
+func (b *scrollback) settle() {
+	rows := -b.topLine
+	for n := b.top; n < b.end(); n++ {
+		if rows += len(b.lines(n)); rows > b.height {
+			return
+		}
+	}
+	b.follow = true
+}
+
+// atBottom reports whether the newest line is shown
+func (b *scrollback) atBottom() bool {
+	return b.follow
+}
+
+// atTop reports whether the oldest line is shown
+func (b *scrollback) atTop() bool {
+	if b.follow {
+		rows := 0
+		for n := b.first; n < b.end() && rows <= b.height; n++ {
+			rows += len(b.lines(n))
+		}
+		return rows <= b.height
+	}
+	return b.top == b.first && b.topLine == 0
+}
+
+func (b *scrollback) scrollUp(rows int) {
+	b.anchor()
+	b.topLine -= rows
+	for b.topLine < 0 && b.top > b.first {
+		b.top--
+		b.topLine += len(b.lines(b.top))
+	}
+	if b.topLine < 0 {
+		b.topLine = 0
+	}
+	b.settle()
+}
+
+func (b *scrollback) scrollDown(rows int) {
+	if b.follow {
+		return
+	}
+	b.topLine += rows
+	for b.top < b.end()-1 && b.topLine >= len(b.lines(b.top)) {
+		b.topLine -= len(b.lines(b.top))
+		b.top++
+	}
+	b.settle()
+}
+
+func (b *scrollback) pageUp() {
+	b.scrollUp(max(b.height, 1))
+}
+
+func (b *scrollback) pageDown() {
+	b.scrollDown(max(b.height, 1))
+}
+
+func (b *scrollback) gotoTop() {
+	b.follow = false
+	b.top, b.topLine = b.first, 0
+	b.settle()
+}
+
+func (b *scrollback) gotoBottom() {
+	b.follow = true
+}
+
+// jumpTo scrolls entry n to the top of the view, reporting false if it has been
+// evicted
+func (b *scrollback) jumpTo(n int) bool {
+	if n < b.first || n >= b.end() {
+		return false
+	}
+	b.follow = false
+	b.top, b.topLine = n, 0
+	b.settle()
+	return true
+}
+
+// View renders the visible lines, padded to the view size
+func (b *scrollback) View() string {
+	var rows []string
+	if b.follow {
+		for n := b.end() - 1; n >= b.first && len(rows) < b.height; n-- {
+			rows = append(append([]string(nil), b.lines(n)...), rows...)
+		}
+		rows = rows[max(len(rows)-b.height, 0):]
+	} else {
+		skip := b.topLine
+		for n := b.top; n < b.end() && len(rows) < b.height; n++ {
+			rows = append(rows, b.lines(n)[skip:]...)
+			skip = 0
+		}
+		rows = rows[:min(len(rows), b.height)]
+	}
+
+	return lipgloss.NewStyle().
+		Width(b.width).
+		Height(b.height).
+		MaxHeight(b.height).
+		MaxWidth(b.width).
+		Render(strings.Join(rows, "\n"))
+}
@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestScrollback(limit, height int) *scrollback {
+	b := newScrollback(limit, func(s string) string { return s })
+	b.setSize(20, height)
+	return b
+}
+
+func addLines(b *scrollback, from, to int) {
+	for i := from; i < to; i++ {
+		b.add(fmt.Sprintf("line %d", i), time.Time{})
+	}
+}
+
+func TestScrollbackEvictsOldest(t *testing.T) {
+	b := newTestScrollback(100, 5)
+	addLines(b, 0, 250)
+
+	texts := b.texts()
+	if len(texts) != 100 || texts[0] != "line 150" || texts[99] != "line 249" {
+		t.Fatalf("kept %d lines, %q to %q; want line 150 to line 249", len(texts), texts[0], texts[len(texts)-1])
+	}
+	if b.first != 150 || b.end() != 250 {
+		t.Errorf("entries numbered %d to %d, want 150 to 250", b.first, b.end())
+	}
+	if got := strings.Split(b.View(), "\n"); !strings.HasPrefix(got[4], "line 249") {
+		t.Errorf("last line shown = %q, want line 249", got[4])
+	}
+}
+
+func TestScrollbackKeepsLinesOnScreen(t *testing.T) {
+	b := newTestScrollback(10, 3)
+	addLines(b, 0, 10)
+	b.gotoTop()
+
+	// Lines being read aren't evicted, so new ones are dropped instead
+	addLines(b, 10, 15)
+	if b.count != 10 || b.first != 0 || !b.behind {
+		t.Fatalf("kept %d lines from %d, behind %v; want 10 from 0, behind", b.count, b.first, b.behind)
+	}
+	if !b.atTop() || b.atBottom() {
+		t.Error("scroll position moved")
+	}
+	if got := strings.Fields(b.View()); got[0] != "line" || got[1] != "0" {
+		t.Errorf("top line = %q, want line 0", got[:2])
+	}
+	b.replaceLast("changed")
+	if got := b.entry(b.end() - 1).text; got != "line 9" {
+		t.Errorf("replaceLast while behind changed the newest kept line to %q", got)
+	}
+
+	// Until the newest lines are loaded again, nothing is added at the bottom
+	b.gotoBottom()
+	addLines(b, 15, 16)
+	if b.end() != 10 {
+		t.Errorf("added lines while behind, end = %d", b.end())
+	}
+	b.clear()
+	addLines(b, 16, 17)
+	if got := b.texts(); !slices.Equal(got, []string{"line 16"}) || b.behind {
+		t.Errorf("after clear, texts = %q, behind %v", got, b.behind)
+	}
+}
+
+func TestScrollbackBoundedAtTop(t *testing.T) {
+	b := newTestScrollback(10, 3)
+	addLines(b, 0, 10)
+	b.scrollUp(1)
+
+	// Scrolled up but not at the oldest line, the oldest are evicted as usual
+	addLines(b, 10, 12)
+	if b.count != 10 || b.first != 2 || b.behind {
+		t.Fatalf("kept %d lines from %d, behind %v; want 10 from 2", b.count, b.first, b.behind)
+	}
+
+	// Stay at the top while new lines arrive and older ones are loaded in front
+	b.gotoTop()
+	for i := range 5 {
+		addLines(b, 12+10*i, 22+10*i)
+		older := make([]string, 8)
+		times := make([]time.Time, 8)
+		for j := range older {
+			older[j] = fmt.Sprintf("older %d.%d", i, j)
+		}
+		b.prepend(older, times)
+		b.scrollUp(1)
+		b.gotoTop()
+
+		if b.count > b.limit {
+			t.Fatalf("round %d: kept %d lines, limit %d", i, b.count, b.limit)
+		}
+	}
+	if got := b.texts(); got[0] != "older 4.0" || !b.behind {
+		t.Errorf("oldest = %q, behind %v; want the last loaded lines and behind", got[0], b.behind)
+	}
+	if !b.atTop() {
+		t.Error("view left the top")
+	}
+}
+
+func TestScrollbackScrolling(t *testing.T) {
+	b := newTestScrollback(100, 3)
+	addLines(b, 0, 10)
+	if !b.atBottom() || b.atTop() {
+		t.Fatal("new scrollback should follow the newest line")
+	}
+
+	b.scrollUp(2)
+	if b.atBottom() {
+		t.Fatal("still following after scrolling up")
+	}
+	if got := viewLines(b); !slices.Equal(got, []string{"line 5", "line 6", "line 7"}) {
+		t.Errorf("after scrolling up 2, view = %q", got)
+	}
+
+	b.pageUp()
+	b.pageUp()
+	b.pageUp()
+	if !b.atTop() {
+		t.Error("not at top after paging past it")
+	}
+
+	b.scrollDown(100)
+	if !b.atBottom() {
+		t.Error("not following after scrolling to the bottom")
+	}
+	if got := viewLines(b); !slices.Equal(got, []string{"line 7", "line 8", "line 9"}) {
+		t.Errorf("at bottom, view = %q", got)
+	}
+}
+
+func TestScrollbackWrappedLines(t *testing.T) {
+	b := newScrollback(100, func(s string) string { return strings.ReplaceAll(s, " ", "\n") })
+	b.setSize(20, 2)
+	b.add("a b c", time.Time{})
+	b.add("d e", time.Time{})
+
+	if got := viewLines(b); !slices.Equal(got, []string{"d", "e"}) {
+		t.Errorf("view = %q, want the last two wrapped lines", got)
+	}
+	b.scrollUp(1)
+	if got := viewLines(b); !slices.Equal(got, []string{"c", "d"}) {
+		t.Errorf("after scrolling up 1, view = %q", got)
+	}
+}
+
+func TestScrollbackPrepend(t *testing.T) {
+	b := newTestScrollback(100, 3)
+	now := time.Now()
+	b.add("new", now)
+
+	older := now.Add(-time.Hour)
+	b.prepend([]string{"old 1", "old 2"}, []time.Time{older, older.Add(time.Second)})
+
+	if got := b.texts(); !slices.Equal(got, []string{"old 1", "old 2", "new"}) {
+		t.Errorf("texts = %q", got)
+	}
+	if oldest, ok := b.oldest(); !ok || !oldest.Equal(older) {
+		t.Errorf("oldest = %v, want %v", oldest, older)
+	}
+	if b.first != -2 {
+		t.Errorf("first entry is %d, want -2", b.first)
+	}
+
+	// Entry numbers stay valid across prepends
+	n := b.end() - 1
+	b.prepend([]string{"older"}, []time.Time{older.Add(-time.Hour)})
+	if b.entry(n).text != "new" {
+		t.Errorf("entry %d = %q, want new", n, b.entry(n).text)
+	}
+	if !b.jumpTo(n) || b.jumpTo(b.end()) {
+		t.Error("jumpTo accepted the wrong entries")
+	}
+}
+
+func TestScrollbackClear(t *testing.T) {
+	b := newTestScrollback(100, 3)
+	addLines(b, 0, 5)
+	b.scrollUp(1)
+	b.clear()
+
+	if b.count != 0 || !b.atBottom() {
+		t.Errorf("after clear: %d lines, following %v", b.count, b.atBottom())
+	}
+	if _, ok := b.oldest(); ok {
+		t.Error("oldest reported a line after clear")
+	}
+	addLines(b, 5, 6)
+	if got := b.texts(); !slices.Equal(got, []string{"line 5"}) {
+		t.Errorf("texts after clear = %q", got)
+	}
+}
+
+// viewLines returns the non-blank lines shown, without padding
+func viewLines(b *scrollback) []string {
+	var lines []string
+	for _, line := range strings.Split(b.View(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,466 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jhalter/mobius-hotline-client/internal/style"
+	"github.com/jhalter/mobius/hotline"
+)
+
+// Session holds the state of a single server connection: its client, user list,
+// chat history, tasks and screens. The Model embeds the active session.
+type Session struct {
+	// Hotline client
+	hlClient          *hotline.Client
+	serverName        string
+	pendingServerName string // Name to display when connection succeeds (from bookmark/tracker/address)
+	pendingServerAddr string // Address being connected to
+	userAccess        hotline.AccessBitmap
+	userList          []hotline.User
+	serverInfo        serverInfo  // What the server reported about itself at login
+	banner            image.Image // Server banner, nil until it has been loaded
+
+	// Connection management
+	connectionCtx       context.Context
+	connectionCtxCancel context.CancelFunc
+	clientDisconnecting bool
+	loginReply          chan struct{} // Signalled when the server answers the login of a connection attempt
+	connectionUsesTLS   bool
+	pendingConnection   *connectionParams // Connection currently being attempted
+	activeConnection    *connectionParams // Connection we are logged in to, used for reconnecting
+	reconnect           *reconnectState   // Non-nil while an automatic reconnect is pending
+	autoAgree           bool              // Accept the next agreement without prompting (after a reconnect)
+	resumed             bool              // Screens were restored after a reconnect; keep them once agreed
+	pendingBookmark     *Bookmark         // Bookmark being connected to, for its per-server options
+	ignores             *ignoreList       // Users whose chat and messages are hidden
+	text                *textCodec        // Encoding of the server's strings
+	certVerifier        *certVerifier     // Verifier of the TLS control connection, reused for transfers
+	dialer              *dialer           // Dialer of the control connection, reused for transfers
+	transferAddr        string            // Address file transfers connect to
+	health              *connHealth       // Keepalive state of the control connection
+	pendingTrust        *pendingTrust     // Unknown certificate awaiting the user's decision
+	away                *awayState        // Non-nil while we are away
+	urlTarget           *HotlineURL       // hotline:// URL to open once connected
+	pendingFileTarget   string            // Last URL path segment, opened or downloaded once its folder is listed
+
+	// Writes to the control connection are serialized by sendMu. The types of
+	// requests awaiting replies have their own lock so that reading a reply
+	// never waits on a blocked write.
+	sendMu    sync.Mutex
+	sentMu    sync.Mutex
+	sentTypes map[[4]byte][2]byte
+
+	// When each trigger rule last fired here by rule name, for rate limiting.
+	// Keyed by name so cooldowns survive the rules being rebuilt.
+	triggerFired map[string]time.Time
+
+	// Private chats
+	chatRooms        []*chatRoom         // Joined private chats, in the order they were opened
+	chatInvites      []chatInvite        // Invitations awaiting an answer, most recent last
+	pendingChatJoins map[[4]byte][4]byte // Join request transaction ID -> chat ID
+
+	// Screen state
+	screenHistory []Screen // Stack of screens, current screen is last element
+
+	// Screens
+	homeScreen             *HomeScreen
+	joinServerScreen       *JoinServerScreen
+	bookmarkScreen         *BookmarkScreen
+	trackerScreen          *TrackerScreen
+	settingsScreen         *SettingsScreen
+	serverScreen           *ServerScreen
+	newsScreen             *NewsScreen
+	newsArticlePostScreen  *NewsArticlePostScreen
+	newsBundleFormScreen   *NewsBundleFormScreen
+	newsCategoryFormScreen *NewsCategoryFormScreen
+	legacyNewsPostScreen   *LegacyNewsPostScreen
+	accountsScreen         *AccountsScreen
+	filesScreen            *FilesScreen
+	tasksScreen            *TasksScreen
+	logsScreen             *LogsScreen
+	messageBoardScreen     *MessageBoardScreen
+	filePickerScreen       *FilePickerScreen
+	composeMessageScreen   *ComposeMessageScreen
+	modalScreen            *ModalScreen
+	loadingScreen          *LoadingScreen
+	serverInfoScreen       *ServerInfoScreen
+	privateChatScreen      *PrivateChatScreen
+	chatLogsScreen         *ChatLogsScreen
+	linksScreen            *LinksScreen
+
+	// Private message stack (for handling multiple incoming PMs)
+	privateMessages []PrivateMessage
+
+	// Task management for file downloads and uploads
+	taskManager *TaskManager
+	requests    *requestRegistry // Sent transactions waiting for a reply
+
+	// Tab state
+	unread  int  // Chat lines and private messages received while in the background
+	closing bool // Remove the session once the current message is handled
+}
+
+// sessionMsg tags a message with the session it belongs to, so that events from
+// background connections update their own screens rather than the active ones
+type sessionMsg struct {
+	session *Session
+	msg     tea.Msg
+}
+
+// newSession creates a disconnected session sitting on the home screen
+func (m *Model) newSession() *Session {
+	s := &Session{
+		hlClient:      hotline.NewClient(m.prefs.Username, m.logger),
+		taskManager:   NewTaskManager(),
+		requests:      newRequestRegistry(),
+		ignores:       newIgnoreList(),
+		text:          newTextCodec(),
+		screenHistory: []Screen{ScreenHome},
+	}
+	m.registerTransactionHandlers(s.hlClient)
+	return s
+}
+
+// CurrentScreen returns the current screen, or ScreenHome if history is empty
+func (s *Session) CurrentScreen() Screen {
+	if len(s.screenHistory) == 0 {
+		return ScreenHome
+	}
+	return s.screenHistory[len(s.screenHistory)-1]
+}
+
+// PreviousScreen returns the previous screen, or ScreenHome if insufficient history
+func (s *Session) PreviousScreen() Screen {
+	if len(s.screenHistory) < 2 {
+		return ScreenHome
+	}
+	return s.screenHistory[len(s.screenHistory)-2]
+}
+
+// PushScreen adds a new screen to history (modal/overlay pattern)
+func (s *Session) PushScreen(screen Screen) {
+	s.screenHistory = append(s.screenHistory, screen)
+}
+
+// PopScreen removes current screen and returns to previous
+// Returns the screen we're now on
+func (s *Session) PopScreen() Screen {
+	if len(s.screenHistory) <= 1 {
+		s.screenHistory = []Screen{ScreenHome}
+		return ScreenHome
+	}
+	s.screenHistory = s.screenHistory[:len(s.screenHistory)-1]
+	return s.screenHistory[len(s.screenHistory)-1]
+}
+
+// ReplaceScreen replaces the current screen without adding to history
+// Used when switching between peer screens (e.g., News -> MessageBoard from ServerUI)
+func (s *Session) ReplaceScreen(screen Screen) {
+	if len(s.screenHistory) == 0 {
+		s.screenHistory = []Screen{screen}
+	} else {
+		s.screenHistory[len(s.screenHistory)-1] = screen
+	}
+}
+
+// NavigateTo clears history and jumps to a screen (hard navigation)
+// Used for disconnect, logout, or other full resets
+func (s *Session) NavigateTo(screen Screen) {
+	s.screenHistory = []Screen{screen}
+}
+
+// isConnected reports whether the session is logged in or trying to get back in
+func (s *Session) isConnected() bool {
+	return s.activeConnection != nil || s.pendingConnection != nil
+}
+
+// tabTitle returns the label shown for the session in the tab bar
+func (s *Session) tabTitle() string {
+	title := "Home"
+	switch {
+	case s.reconnect != nil:
+		title = s.reconnect.params.name + " (reconnecting)"
+	case s.activeConnection != nil:
+		title = s.serverName
+	case s.pendingConnection != nil:
+		title = s.pendingConnection.name + " (connecting)"
+	}
+	if s.unread > 0 {
+		title += fmt.Sprintf(" [%d]", s.unread)
+	}
+	return title
+}
+
+// resizeScreens updates the dimensions of every screen in the session
+func (s *Session) resizeScreens(w, h int) {
+	if s.homeScreen != nil {
+		s.homeScreen.SetSize(w, h)
+	}
+	if s.joinServerScreen != nil {
+		s.joinServerScreen.SetSize(w, h)
+	}
+	if s.bookmarkScreen != nil {
+		s.bookmarkScreen.SetSize(w, h)
+	}
+	if s.trackerScreen != nil {
+		s.trackerScreen.SetSize(w, h)
+	}
+	if s.settingsScreen != nil {
+		s.settingsScreen.SetSize(w, h)
+	}
+	if s.serverScreen != nil {
+		s.serverScreen.SetSize(w, h)
+	}
+	if s.newsScreen != nil {
+		s.newsScreen.SetSize(w, h)
+	}
+	if s.newsArticlePostScreen != nil {
+		s.newsArticlePostScreen.SetSize(w, h)
+	}
+	if s.newsBundleFormScreen != nil {
+		s.newsBundleFormScreen.SetSize(w, h)
+	}
+	if s.newsCategoryFormScreen != nil {
+		s.newsCategoryFormScreen.SetSize(w, h)
+	}
+	if s.legacyNewsPostScreen != nil {
+		s.legacyNewsPostScreen.SetSize(w, h)
+	}
+	if s.accountsScreen != nil {
+		s.accountsScreen.SetSize(w, h)
+	}
+	if s.filesScreen != nil {
+		s.filesScreen.SetSize(w, h)
+	}
+	if s.tasksScreen != nil {
+		s.tasksScreen.SetSize(w, h)
+	}
+	if s.logsScreen != nil {
+		s.logsScreen.SetSize(w, h)
+	}
+	if s.messageBoardScreen != nil {
+		s.messageBoardScreen.SetSize(w, h)
+	}
+	if s.filePickerScreen != nil {
+		s.filePickerScreen.SetSize(w, h)
+	}
+	if s.composeMessageScreen != nil {
+		s.composeMessageScreen.SetSize(w, h)
+	}
+	if s.modalScreen != nil {
+		s.modalScreen.SetSize(w, h)
+	}
+	if s.serverInfoScreen != nil {
+		s.serverInfoScreen.SetSize(w, h)
+	}
+	if s.privateChatScreen != nil {
+		s.privateChatScreen.SetSize(w, h)
+	}
+	if s.chatLogsScreen != nil {
+		s.chatLogsScreen.SetSize(w, h)
+	}
+	if s.linksScreen != nil {
+		s.linksScreen.SetSize(w, h)
+	}
+}
+
+// sessionFor returns the session that owns the given client, or nil if it has been closed
+func (m *Model) sessionFor(c *hotline.Client) *Session {
+	m.sessionsMu.Lock()
+	defer m.sessionsMu.Unlock()
+	for _, s := range m.sessions {
+		if s.hlClient == c {
+			return s
+		}
+	}
+	return nil
+}
+
+// hasSession reports whether the session is still open
+func (m *Model) hasSession(s *Session) bool {
+	m.sessionsMu.Lock()
+	defer m.sessionsMu.Unlock()
+	for _, open := range m.sessions {
+		if open == s {
+			return true
+		}
+	}
+	return false
+}
+
+// send delivers a message from a transaction handler to the session owning the client
+func (m *Model) send(c *hotline.Client, msg tea.Msg) {
+	if s := m.sessionFor(c); s != nil {
+		m.sendTo(s, msg)
+	}
+}
+
+// loginReplied tells the connection attempt waiting in joinServer that the
+// server has answered its login. It is called from the transaction handler.
+func (s *Session) loginReplied() {
+	if s == nil {
+		return
+	}
+	select {
+	case s.loginReply <- struct{}{}:
+	default:
+	}
+}
+
+// sendTo delivers a message from a background goroutine to the given session
+func (m *Model) sendTo(s *Session, msg tea.Msg) {
+	m.program.Send(sessionMsg{session: s, msg: msg})
+}
+
+// sessionCmd tags the messages produced by cmd with the session that started it.
+// Only this package's message types are tagged; framework messages such as
+// tea.QuitMsg and component ticks must reach the runtime and screens untouched.
+func (m *Model) sessionCmd(s *Session, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		msg := cmd()
+		switch msg := msg.(type) {
+		case nil, sessionMsg:
+			return msg
+		case tea.BatchMsg:
+			cmds := make(tea.BatchMsg, len(msg))
+			for i, c := range msg {
+				cmds[i] = m.sessionCmd(s, c)
+			}
+			return cmds
+		}
+		if reflect.TypeOf(msg).PkgPath() != reflect.TypeOf(sessionMsg{}).PkgPath() {
+			return msg
+		}
+		return sessionMsg{session: s, msg: msg}
+	}
+}
+
+// openSession adds a new session tab and switches to it
+func (m *Model) openSession() {
+	s := m.newSession()
+	m.sessionsMu.Lock()
+	m.sessions = append(m.sessions, s)
+	m.sessionsMu.Unlock()
+
+	m.Session = s
+	m.relayout()
+	m.homeScreen = NewHomeScreen(m)
+}
+
+// switchSession makes the session at index i the active one
+func (m *Model) switchSession(i int) tea.Cmd {
+	if i < 0 || i >= len(m.sessions) || m.sessions[i] == m.Session {
+		return nil
+	}
+	m.Session = m.sessions[i]
+	m.unread = 0
+
+	// Restart the spinner, its ticks went to whichever screen was active
+	if m.CurrentScreen() == ScreenLoading && m.loadingScreen != nil {
+		return m.loadingScreen.Init()
+	}
+	return nil
+}
+
+// activeSessionIndex returns the tab index of the active session
+func (m *Model) activeSessionIndex() int {
+	for i, s := range m.sessions {
+		if s == m.Session {
+			return i
+		}
+	}
+	return 0
+}
+
+// removeSession closes a session tab, switching to a neighbour if it was active
+func (m *Model) removeSession(s *Session) {
+	if len(m.sessions) <= 1 {
+		s.closing = false
+		return
+	}
+
+	idx := 0
+	m.sessionsMu.Lock()
+	for i, open := range m.sessions {
+		if open == s {
+			idx = i
+			m.sessions = append(m.sessions[:i], m.sessions[i+1:]...)
+			break
+		}
+	}
+	m.sessionsMu.Unlock()
+
+	if m.Session == s {
+		m.Session = m.sessions[max(idx-1, 0)]
+		m.unread = 0
+	}
+	m.relayout()
+}
+
+// handleSessionKeys handles the global session switching hotkeys
+func (m *Model) handleSessionKeys(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch key := msg.String(); key {
+	case "ctrl+o":
+		// Open another connection alongside the current one
+		if m.isConnected() {
+			m.openSession()
+		}
+		return nil, true
+	case "ctrl+pgdown":
+		return m.switchSession((m.activeSessionIndex() + 1) % len(m.sessions)), true
+	case "ctrl+pgup":
+		return m.switchSession((m.activeSessionIndex() + len(m.sessions) - 1) % len(m.sessions)), true
+	case "alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9":
+		return m.switchSession(int(key[len(key)-1] - '1')), true
+	}
+	return nil, false
+}
+
+// tabBarHeight returns the number of lines used by the session tab bar
+func (m *Model) tabBarHeight() int {
+	if len(m.sessions) > 1 {
+		return 1
+	}
+	return 0
+}
+
+// relayout resizes every session's screens to fit below the tab bar
+func (m *Model) relayout() {
+	m.height = m.termHeight - m.tabBarHeight()
+	m.resizeAllScreens(m.width, m.height)
+}
+
+// renderTabBar renders one tab per session, highlighting the active one
+func (m *Model) renderTabBar() string {
+	activeTab := lipgloss.NewStyle().Bold(true).Foreground(style.ColorFuscia).Padding(0, 1)
+	inactiveTab := lipgloss.NewStyle().Foreground(style.ColorLightGrey).Padding(0, 1)
+	unreadTab := lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Padding(0, 1)
+
+	var tabs []string
+	for i, s := range m.sessions {
+		label := fmt.Sprintf("%d:%s", i+1, s.tabTitle())
+		switch {
+		case s == m.Session:
+			tabs = append(tabs, activeTab.Render(label))
+		case s.unread > 0:
+			tabs = append(tabs, unreadTab.Render(label))
+		default:
+			tabs = append(tabs, inactiveTab.Render(label))
+		}
+	}
+
+	bar := strings.Join(tabs, style.HotkeyStyle.Render("│"))
+	hint := lipgloss.NewStyle().Foreground(style.ColorDarkGrey).Render("  alt+N/ctrl+pgup/pgdn: switch  ctrl+o: new")
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(bar + hint)
+}
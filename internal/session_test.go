@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSessionCmdTagging(t *testing.T) {
+	m, s := newTestModel(t, &Settings{})
+	other := m.newSession()
+
+	tests := []struct {
+		name string
+		msg  tea.Msg
+		want tea.Msg // Message the wrapped command returns
+	}{
+		{name: "package message", msg: errorMsg{text: "x"}, want: sessionMsg{session: s, msg: errorMsg{text: "x"}}},
+		{name: "pointer to package message", msg: &reconnectState{}, want: nil},
+		{name: "nil", msg: nil, want: nil},
+		{name: "already tagged", msg: sessionMsg{session: other, msg: chatMsg{}}, want: sessionMsg{session: other, msg: chatMsg{}}},
+		{name: "quit", msg: tea.QuitMsg{}, want: tea.QuitMsg{}},
+		{name: "window size", msg: tea.WindowSizeMsg{Width: 80}, want: tea.WindowSizeMsg{Width: 80}},
+		{name: "component message", msg: progress.FrameMsg{}, want: progress.FrameMsg{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.sessionCmd(s, func() tea.Msg { return tt.msg })()
+			if tt.want == nil {
+				// Unnamed types have no package and pass through untouched
+				if _, tagged := got.(sessionMsg); tagged {
+					t.Errorf("message was tagged: %#v", got)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	if m.sessionCmd(s, nil) != nil {
+		t.Error("sessionCmd(nil) returned a command")
+	}
+}
+
+func TestSessionCmdBatch(t *testing.T) {
+	m, s := newTestModel(t, &Settings{})
+	batch := tea.Batch(
+		func() tea.Msg { return chatMsg{} },
+		func() tea.Msg { return tea.QuitMsg{} },
+	)
+
+	cmds, ok := m.sessionCmd(s, batch)().(tea.BatchMsg)
+	if !ok || len(cmds) != 2 {
+		t.Fatalf("batch became %#v", cmds)
+	}
+	if got, ok := cmds[0]().(sessionMsg); !ok || got.session != s {
+		t.Errorf("first command returned %#v, want a tagged chatMsg", got)
+	}
+	if got := cmds[1](); got != (tea.QuitMsg{}) {
+		t.Errorf("second command returned %#v, want tea.QuitMsg untouched", got)
+	}
+}
+
+func TestUpdateDropsClosedSession(t *testing.T) {
+	m, _ := newTestModel(t, &Settings{})
+	closed := m.newSession()
+
+	if _, cmd := m.Update(sessionMsg{session: closed, msg: errorMsg{text: "x"}}); cmd != nil {
+		t.Error("message for a closed session produced a command")
+	}
+	if closed.CurrentScreen() != ScreenHome {
+		t.Errorf("closed session moved to screen %v", closed.CurrentScreen())
+	}
+}
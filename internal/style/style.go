@@ -59,6 +59,11 @@ var (
 
 	UsernameStyle = lipgloss.NewStyle().Bold(true)
 
+	// Join, leave and status notices in chat
+	NoticeStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(ColorDarkGrey)
+
 	SubScreenStyle = lipgloss.NewStyle().
 			Border(lipgloss.DoubleBorder()).
 			BorderForeground(ColorCyan). // Cyan border
@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/jhalter/mobius/hotline"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Text encodings of protocol strings, selected with Settings.Encoding and
+// Bookmark.Encoding
+const (
+	encodingAuto     = "auto"
+	encodingUTF8     = "utf-8"
+	encodingMacRoman = "macroman"
+	encodingShiftJIS = "shift-jis"
+)
+
+// textEncodings lists the encodings in the order the bookmark form offers them
+var textEncodings = []string{encodingAuto, encodingUTF8, encodingMacRoman, encodingShiftJIS}
+
+// parseEncoding returns the encoding a name or common alias refers to. Empty
+// means auto.
+func parseEncoding(name string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", encodingAuto:
+		return encodingAuto, nil
+	case encodingUTF8, "utf8":
+		return encodingUTF8, nil
+	case encodingMacRoman, "mac", "macintosh", "mac-roman":
+		return encodingMacRoman, nil
+	case encodingShiftJIS, "shift_jis", "shiftjis", "sjis":
+		return encodingShiftJIS, nil
+	}
+	return "", fmt.Errorf("unknown encoding %q (want %s)", name, strings.Join(textEncodings, ", "))
+}
+
+// encodingFor returns the text encoding for a connection, preferring the
+// bookmark's over the global setting
+func (cp *Settings) encodingFor(bm *Bookmark) string {
+	name := cp.Encoding
+	if bm != nil && bm.Encoding != "" {
+		name = bm.Encoding
+	}
+	enc, err := parseEncoding(name)
+	if err != nil {
+		return encodingAuto
+	}
+	return enc
+}
+
+// textCodec converts strings between UTF-8 and a server's encoding. In auto
+// mode, text that isn't valid UTF-8 is read as MacRoman, and once the server
+// has sent any, we write MacRoman too.
+type textCodec struct {
+	mu     sync.Mutex
+	name   string
+	legacy bool // Auto mode has seen text that isn't UTF-8
+}
+
+func newTextCodec() *textCodec {
+	return &textCodec{name: encodingAuto}
+}
+
+// setEncoding switches to a parsed encoding name for a new connection
+func (tc *textCodec) setEncoding(name string) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.name = name
+	tc.legacy = false
+}
+
+// encoding returns the x/text encoding in use, nil for UTF-8
+func (tc *textCodec) encoding() encoding.Encoding {
+	switch tc.name {
+	case encodingMacRoman:
+		return charmap.Macintosh
+	case encodingShiftJIS:
+		return japanese.ShiftJIS
+	case encodingAuto:
+		if tc.legacy {
+			return charmap.Macintosh
+		}
+	}
+	return nil
+}
+
+func (tc *textCodec) decode(b []byte) string {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if tc.name == encodingAuto && !tc.legacy && !utf8.Valid(b) {
+		tc.legacy = true
+	}
+	enc := tc.encoding()
+	if enc == nil || (tc.name == encodingAuto && utf8.Valid(b)) {
+		return string(b)
+	}
+	text, err := enc.NewDecoder().Bytes(b)
+	if err != nil {
+		return string(b)
+	}
+	return string(text)
+}
+
+// encode converts text to the server's encoding, replacing characters it
+// can't represent with "?"
+func (tc *textCodec) encode(text string) []byte {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	enc := tc.encoding()
+	if enc == nil {
+		return []byte(text)
+	}
+	encoder := enc.NewEncoder()
+	if b, err := encoder.Bytes([]byte(text)); err == nil {
+		return b
+	}
+
+	// Fall back to a rune at a time, so only the unsupported ones become "?"
+	var b []byte
+	for _, r := range text {
+		encoded, err := encoder.Bytes([]byte(string(r)))
+		if err != nil {
+			encoded = []byte("?")
+		}
+		b = append(b, encoded...)
+	}
+	return b
+}
+
+// decodeText converts a string field from the server to UTF-8. A nil session,
+// whose connection has gone, leaves it as is.
+func (s *Session) decodeText(b []byte) string {
+	if s == nil {
+		return string(b)
+	}
+	return s.text.decode(b)
+}
+
+// encodeText converts a string to the server's encoding for sending
+func (s *Session) encodeText(text string) []byte {
+	if s == nil {
+		return []byte(text)
+	}
+	return s.text.encode(text)
+}
+
+// encodeFilePath encodes a slash separated file path for FieldFilePath
+func (s *Session) encodeFilePath(path string) []byte {
+	return hotline.EncodeFilePath(string(s.encodeText(path)))
+}
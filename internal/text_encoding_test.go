@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":           encodingAuto,
+		"Auto":       encodingAuto,
+		"utf8":       encodingUTF8,
+		"UTF-8":      encodingUTF8,
+		"mac":        encodingMacRoman,
+		" MacRoman ": encodingMacRoman,
+		"sjis":       encodingShiftJIS,
+		"Shift_JIS":  encodingShiftJIS,
+	}
+	for name, want := range tests {
+		if got, err := parseEncoding(name); err != nil || got != want {
+			t.Errorf("parseEncoding(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := parseEncoding("latin1"); err == nil {
+		t.Error("parseEncoding accepted an unknown encoding")
+	}
+}
+
+func TestEncodingFor(t *testing.T) {
+	prefs := &Settings{Encoding: "macroman"}
+	if got := prefs.encodingFor(nil); got != encodingMacRoman {
+		t.Errorf("global encoding = %q", got)
+	}
+	if got := prefs.encodingFor(&Bookmark{Encoding: "sjis"}); got != encodingShiftJIS {
+		t.Errorf("bookmark encoding = %q", got)
+	}
+	if got := prefs.encodingFor(&Bookmark{}); got != encodingMacRoman {
+		t.Errorf("bookmark without an encoding = %q", got)
+	}
+	if got := (&Settings{Encoding: "bogus"}).encodingFor(nil); got != encodingAuto {
+		t.Errorf("invalid encoding = %q, want auto", got)
+	}
+}
+
+func TestTextCodecRoundTrip(t *testing.T) {
+	tests := []struct {
+		encoding string
+		text     string
+		encoded  []byte
+	}{
+		{encoding: encodingUTF8, text: "café ✓", encoded: []byte("café ✓")},
+		{encoding: encodingMacRoman, text: "café • ™", encoded: []byte{'c', 'a', 'f', 0x8e, ' ', 0xa5, ' ', 0xaa}},
+		{encoding: encodingShiftJIS, text: "日本", encoded: []byte{0x93, 0xfa, 0x96, 0x7b}},
+	}
+	for _, tt := range tests {
+		tc := newTextCodec()
+		tc.setEncoding(tt.encoding)
+		encoded := tc.encode(tt.text)
+		if !bytes.Equal(encoded, tt.encoded) {
+			t.Errorf("%s: encode(%q) = %x, want %x", tt.encoding, tt.text, encoded, tt.encoded)
+		}
+		if got := tc.decode(encoded); got != tt.text {
+			t.Errorf("%s: decode(%x) = %q, want %q", tt.encoding, encoded, got, tt.text)
+		}
+	}
+}
+
+func TestTextCodecReplacesUnsupported(t *testing.T) {
+	tc := newTextCodec()
+	tc.setEncoding(encodingMacRoman)
+	if got := tc.encode("naïve ✓ 日本"); !bytes.Equal(got, []byte("na\x95ve ? ??")) {
+		t.Errorf("encode = %q, want unsupported characters as ?", got)
+	}
+}
+
+func TestTextCodecAuto(t *testing.T) {
+	tc := newTextCodec()
+
+	// UTF-8 passes through, and we keep writing UTF-8
+	if got := tc.decode([]byte("café")); got != "café" {
+		t.Errorf("decode UTF-8 = %q", got)
+	}
+	if got := tc.encode("café"); !bytes.Equal(got, []byte("café")) {
+		t.Errorf("encode before legacy text = %x", got)
+	}
+
+	// Once the server sends text that isn't UTF-8, it is read and written as MacRoman
+	if got := tc.decode([]byte{'c', 'a', 'f', 0x8e}); got != "café" {
+		t.Errorf("decode MacRoman = %q", got)
+	}
+	if got := tc.encode("café"); !bytes.Equal(got, []byte{'c', 'a', 'f', 0x8e}) {
+		t.Errorf("encode after legacy text = %x", got)
+	}
+	// Valid UTF-8 is still read as UTF-8
+	if got := tc.decode([]byte("✓")); got != "✓" {
+		t.Errorf("decode UTF-8 after legacy text = %q", got)
+	}
+
+	// A new connection starts over
+	tc.setEncoding(encodingAuto)
+	if got := tc.encode("café"); !bytes.Equal(got, []byte("café")) {
+		t.Errorf("encode after setEncoding = %x", got)
+	}
+}
+
+func TestSessionTextWithoutConnection(t *testing.T) {
+	var s *Session
+	if got := s.decodeText([]byte("hi")); got != "hi" {
+		t.Errorf("nil session decodeText = %q", got)
+	}
+	if got := s.encodeText("hi"); string(got) != "hi" {
+		t.Errorf("nil session encodeText = %q", got)
+	}
+}
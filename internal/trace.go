@@ -0,0 +1,218 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jhalter/mobius/hotline"
+)
+
+// Trace directions
+const (
+	traceIn  = "in"  // Server to client
+	traceOut = "out" // Client to server
+)
+
+// traceRecord is one transaction in a protocol trace. Traces are stored as one
+// JSON record per line; fields are only ever added to this format, never renamed.
+type traceRecord struct {
+	Time   time.Time    `json:"time"`
+	Server string       `json:"server"`
+	Dir    string       `json:"dir"`
+	Type   uint16       `json:"type"`
+	ID     uint32       `json:"id"`
+	Reply  bool         `json:"reply"`
+	Flags  byte         `json:"flags,omitempty"`
+	Error  uint32       `json:"error,omitempty"`
+	Fields []traceField `json:"fields"`
+}
+
+// traceField is a transaction field. Data holds the raw bytes in hex; Text repeats
+// them as a string when they are printable.
+type traceField struct {
+	Type     uint16 `json:"type"`
+	Data     string `json:"data"`
+	Text     string `json:"text,omitempty"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+func newTraceRecord(dir, server string, t *hotline.Transaction) traceRecord {
+	rec := traceRecord{
+		Time:   time.Now(),
+		Server: server,
+		Dir:    dir,
+		Type:   binary.BigEndian.Uint16(t.Type[:]),
+		ID:     binary.BigEndian.Uint32(t.ID[:]),
+		Reply:  t.IsReply == 1,
+		Flags:  t.Flags,
+		Error:  binary.BigEndian.Uint32(t.ErrorCode[:]),
+		Fields: make([]traceField, 0, len(t.Fields)),
+	}
+
+	for _, f := range t.Fields {
+		tf := traceField{Type: binary.BigEndian.Uint16(f.Type[:])}
+
+		// Keep passwords out of traces users send us
+		if f.Type == hotline.FieldUserPassword {
+			tf.Redacted = true
+		} else {
+			tf.Data = hex.EncodeToString(f.Data)
+			if isPrintable(f.Data) {
+				tf.Text = string(f.Data)
+			}
+		}
+		rec.Fields = append(rec.Fields, tf)
+	}
+
+	return rec
+}
+
+// transaction rebuilds the transaction described by the record
+func (rec traceRecord) transaction() (hotline.Transaction, error) {
+	var t hotline.Transaction
+	t.Flags = rec.Flags
+	if rec.Reply {
+		t.IsReply = 1
+	}
+	binary.BigEndian.PutUint16(t.Type[:], rec.Type)
+	binary.BigEndian.PutUint32(t.ID[:], rec.ID)
+	binary.BigEndian.PutUint32(t.ErrorCode[:], rec.Error)
+
+	for _, tf := range rec.Fields {
+		data, err := hex.DecodeString(tf.Data)
+		if err != nil {
+			return t, fmt.Errorf("field %d: %w", tf.Type, err)
+		}
+		var fieldType hotline.FieldType
+		binary.BigEndian.PutUint16(fieldType[:], tf.Type)
+		t.Fields = append(t.Fields, hotline.NewField(fieldType, data))
+	}
+
+	return t, nil
+}
+
+func isPrintable(b []byte) bool {
+	if len(b) == 0 || !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if r < 0x20 && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// tracer writes trace records for every session to a single file
+type tracer struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+func newTracer(path string) (*tracer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &tracer{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (tr *tracer) record(rec traceRecord) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	_ = tr.enc.Encode(rec)
+}
+
+func (tr *tracer) Close() error {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return tr.f.Close()
+}
+
+// wrap returns conn with every transaction read from or written to it traced.
+// It must be applied after the protocol handshake, which isn't a transaction.
+func (tr *tracer) wrap(conn net.Conn, server string) net.Conn {
+	return &traceConn{Conn: conn, tracer: tr, server: server}
+}
+
+// traceConn reassembles the transactions passing through a connection
+type traceConn struct {
+	net.Conn
+	tracer *tracer
+	server string
+
+	inMu  sync.Mutex
+	in    []byte
+	outMu sync.Mutex
+	out   []byte
+}
+
+func (c *traceConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.inMu.Lock()
+		c.in = c.scan(traceIn, append(c.in, p[:n]...))
+		c.inMu.Unlock()
+	}
+	return n, err
+}
+
+func (c *traceConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	if n > 0 {
+		c.outMu.Lock()
+		c.out = c.scan(traceOut, append(c.out, p[:n]...))
+		c.outMu.Unlock()
+	}
+	return n, err
+}
+
+// scan records each complete transaction in buf and returns the remaining bytes
+func (c *traceConn) scan(dir string, buf []byte) []byte {
+	for len(buf) >= 20 {
+		tranLen := 20 + int(binary.BigEndian.Uint32(buf[12:16]))
+		if len(buf) < tranLen {
+			break
+		}
+
+		var t hotline.Transaction
+		if _, err := t.Write(buf[:tranLen]); err == nil {
+			c.tracer.record(newTraceRecord(dir, c.server, &t))
+		}
+		buf = buf[tranLen:]
+	}
+	return buf
+}
+
+// readTrace loads the records of a trace file
+func readTrace(path string) ([]traceRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var records []traceRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec traceRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/jhalter/mobius/hotline"
+)
+
+func transactionBytes(t *testing.T, tran hotline.Transaction) []byte {
+	t.Helper()
+	b, err := io.ReadAll(&tran)
+	if err != nil {
+		t.Fatalf("encoding transaction: %v", err)
+	}
+	return b
+}
+
+func TestTraceRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	tr, err := newTracer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chat := hotline.NewTransaction(hotline.TranChatSend, [2]byte{},
+		hotline.NewField(hotline.FieldData, []byte("hello\r")),
+		hotline.NewField(hotline.FieldChatOptions, []byte{0, 0}),
+	)
+	login := hotline.NewTransaction(hotline.TranLogin, [2]byte{},
+		hotline.NewField(hotline.FieldUserLogin, []byte("admin")),
+		hotline.NewField(hotline.FieldUserPassword, []byte("secret")),
+	)
+	reply := hotline.NewTransaction(hotline.TranChatMsg, [2]byte{},
+		hotline.NewField(hotline.FieldData, []byte{0x00, 0xff}),
+	)
+	reply.IsReply = 1
+
+	client, server := net.Pipe()
+	defer func() { _ = server.Close() }()
+	conn := tr.wrap(client, "hotline.example.com:5500")
+
+	// Transactions split across writes, and two in one write, are reassembled
+	chatBytes, loginBytes, replyBytes := transactionBytes(t, chat), transactionBytes(t, login), transactionBytes(t, reply)
+	out := [][]byte{chatBytes[:7], append(chatBytes[7:], loginBytes...)}
+	go func() {
+		for _, b := range out {
+			_, _ = conn.Write(b)
+		}
+		_, _ = server.Write(replyBytes)
+	}()
+	if _, err := io.ReadFull(server, make([]byte, len(chatBytes)+len(loginBytes))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(conn, make([]byte, len(replyBytes))); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := readTrace(path)
+	if err != nil {
+		t.Fatalf("readTrace: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+
+	wantDirs := []string{traceOut, traceOut, traceIn}
+	for i, rec := range records {
+		if rec.Dir != wantDirs[i] || rec.Server != "hotline.example.com:5500" {
+			t.Errorf("record %d: dir %q server %q", i, rec.Dir, rec.Server)
+		}
+	}
+
+	// Printable fields are repeated as text, and passwords never written
+	if got := records[0].Fields[0].Text; got != "hello\r" {
+		t.Errorf("chat text = %q, want %q", got, "hello\r")
+	}
+	if f := records[1].Fields[1]; !f.Redacted || f.Data != "" {
+		t.Errorf("password field = %+v, want redacted", f)
+	}
+	if records[2].Fields[0].Text != "" || !records[2].Reply {
+		t.Errorf("binary reply record = %+v", records[2])
+	}
+
+	// Replayed transactions match what was sent
+	for i, want := range map[int][]byte{0: chatBytes, 2: replyBytes} {
+		got, err := records[i].transaction()
+		if err != nil {
+			t.Fatalf("record %d transaction: %v", i, err)
+		}
+		if b := transactionBytes(t, got); !bytes.Equal(b, want) {
+			t.Errorf("record %d replays as %x, want %x", i, b, want)
+		}
+	}
+}
+
+func TestReadTraceInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	tr, err := newTracer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.record(traceRecord{Dir: traceIn})
+	_, _ = tr.f.WriteString("{not json\n")
+	_ = tr.Close()
+
+	if _, err := readTrace(path); err == nil {
+		t.Fatal("readTrace accepted an invalid line")
+	}
+}
@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jhalter/mobius/hotline"
+)
+
+// tranHeaderLen is the length of a transaction's fixed header, before its fields
+const tranHeaderLen = 20
+
+// sendTransaction writes t to the session's control connection. Every write
+// goes through here: the keepalive loop, transaction handlers and UI commands
+// all send concurrently. Requests are recorded so their replies can be handed
+// to the handler for the request's type.
+func (s *Session) sendTransaction(t hotline.Transaction) error {
+	if s == nil {
+		return errors.New("session closed")
+	}
+	// Record the request first so a fast reply can't arrive before it
+	if t.IsReply == 0 {
+		s.sentMu.Lock()
+		if s.sentTypes == nil {
+			s.sentTypes = make(map[[4]byte][2]byte)
+		}
+		s.sentTypes[t.ID] = t.Type
+		s.sentMu.Unlock()
+	}
+
+	s.sendMu.Lock()
+	_, err := io.Copy(s.hlClient.Connection, &t)
+	s.sendMu.Unlock()
+	if err != nil {
+		s.replyType(t.ID)
+		return fmt.Errorf("error sending transaction: %w", err)
+	}
+	return nil
+}
+
+// replyType returns the type of the request a reply answers and forgets the request
+func (s *Session) replyType(id [4]byte) ([2]byte, bool) {
+	s.sentMu.Lock()
+	defer s.sentMu.Unlock()
+	typ, ok := s.sentTypes[id]
+	delete(s.sentTypes, id)
+	return typ, ok
+}
+
+// clearSentTransactions forgets the requests sent on the previous connection
+func (s *Session) clearSentTransactions() {
+	s.sentMu.Lock()
+	defer s.sentMu.Unlock()
+	clear(s.sentTypes)
+}
+
+// scanTransaction is a bufio.SplitFunc that splits a stream into transactions
+func scanTransaction(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(data) < tranHeaderLen {
+		return 0, nil, nil
+	}
+	tranLen := tranHeaderLen + int(binary.BigEndian.Uint32(data[12:16]))
+	if len(data) < tranLen {
+		return 0, nil, nil
+	}
+	return tranLen, data[:tranLen], nil
+}
+
+// handleTransactions reads transactions from the session's control connection
+// and runs their handlers until the connection closes. It stands in for
+// hotline.Client.HandleTransactions, whose record of sent requests isn't safe
+// to use while other goroutines send.
+func (m *Model) handleTransactions(ctx context.Context, s *Session) error {
+	c := s.hlClient
+	scanner := bufio.NewScanner(c.Connection)
+	scanner.Split(scanTransaction)
+
+	for scanner.Scan() {
+		// The scanner reuses its buffer, and fields keep slices of what they're given
+		buf := make([]byte, len(scanner.Bytes()))
+		copy(buf, scanner.Bytes())
+
+		var t hotline.Transaction
+		if _, err := t.Write(buf); err != nil {
+			return err
+		}
+
+		if t.IsReply == 1 {
+			typ, ok := s.replyType(t.ID)
+			if !ok {
+				m.logger.Warn("Ignoring reply to unknown request", "id", t.ID)
+				continue
+			}
+			t.Type = typ
+		}
+
+		handler, ok := c.Handlers[t.Type]
+		if !ok {
+			continue
+		}
+		res, err := handler(ctx, c, &t)
+		if err != nil {
+			m.logger.Error("Error handling transaction", "type", t.Type, "err", err)
+		}
+		for _, r := range res {
+			if err := s.sendTransaction(r); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,178 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jhalter/mobius/hotline"
+)
+
+// echoServer answers every request it reads with an empty reply
+func echoServer(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	scanner.Split(scanTransaction)
+	for scanner.Scan() {
+		var t hotline.Transaction
+		if _, err := t.Write(append([]byte(nil), scanner.Bytes()...)); err != nil {
+			return
+		}
+		reply := hotline.Transaction{IsReply: 1, ID: t.ID}
+		if _, err := io.Copy(conn, &reply); err != nil {
+			return
+		}
+	}
+}
+
+func TestSendTransactionConcurrent(t *testing.T) {
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+	go echoServer(server)
+
+	m, s := newTestModel(t, &Settings{Username: "tester"})
+	s.hlClient.Connection = client
+	s.health = newConnHealth(false)
+
+	var chatReplies atomic.Int32
+	s.hlClient.HandleFunc(hotline.TranChatSend, func(ctx context.Context, c *hotline.Client, t *hotline.Transaction) ([]hotline.Transaction, error) {
+		chatReplies.Add(1)
+		return nil, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- m.handleTransactions(ctx, s) }()
+
+	// Keepalives and UI sends race each other, as they do in a live session
+	const senders, each = 4, 25
+	var wg sync.WaitGroup
+	for range senders {
+		wg.Go(func() {
+			for range each {
+				if err := s.sendTransaction(hotline.NewTransaction(hotline.TranChatSend, [2]byte{})); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		})
+	}
+	for range each {
+		keepAlive := hotline.NewTransaction(hotline.TranKeepAlive, [2]byte{})
+		s.health.ping(keepAlive.ID, each+1)
+		if err := s.sendTransaction(keepAlive); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for chatReplies.Load() < senders*each && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := chatReplies.Load(); got != senders*each {
+		t.Errorf("handled %d chat replies, want %d", got, senders*each)
+	}
+	s.health.mu.Lock()
+	answered := s.health.answered
+	s.health.mu.Unlock()
+	if !answered {
+		t.Error("keepalive replies never reached connHealth")
+	}
+
+	// Answered requests are forgotten, keepalives included
+	deadline = time.Now().Add(5 * time.Second)
+	for {
+		s.sentMu.Lock()
+		pending := len(s.sentTypes)
+		s.sentMu.Unlock()
+		if pending == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d answered requests still recorded", pending)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_ = client.Close()
+	<-done
+}
+
+func TestHandleTransactionsUnknownReply(t *testing.T) {
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+	m, s := newTestModel(t, &Settings{Username: "tester"})
+	s.hlClient.Connection = client
+
+	done := make(chan error, 1)
+	go func() { done <- m.handleTransactions(context.Background(), s) }()
+
+	// A reply nobody asked for is dropped rather than crashing the reader
+	reply := hotline.Transaction{IsReply: 1, ID: [4]byte{0xde, 0xad, 0xbe, 0xef}}
+	if _, err := io.Copy(server, &reply); err != nil {
+		t.Fatal(err)
+	}
+	_ = server.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("handleTransactions: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handleTransactions didn't return when the connection closed")
+	}
+}
+
+// sentConn is a control connection that records what is written to it
+type sentConn struct {
+	net.Conn // Unused; only Write and Close are called
+	mu       sync.Mutex
+	buf      bytes.Buffer
+}
+
+func (c *sentConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.Write(b)
+}
+
+func (c *sentConn) Close() error { return nil }
+
+// sent returns the transactions written since the last call
+func (c *sentConn) sent(t *testing.T) []hotline.Transaction {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var sent []hotline.Transaction
+	scanner := bufio.NewScanner(&c.buf)
+	scanner.Split(scanTransaction)
+	for scanner.Scan() {
+		var tran hotline.Transaction
+		if _, err := tran.Write(append([]byte(nil), scanner.Bytes()...)); err != nil {
+			t.Fatal(err)
+		}
+		sent = append(sent, tran)
+	}
+	return sent
+}
+
+// recordSent replaces the session's connection with a sentConn
+func recordSent(s *Session) *sentConn {
+	c := &sentConn{}
+	s.hlClient.Connection = c
+	return c
+}
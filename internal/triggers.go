@@ -0,0 +1,481 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jhalter/mobius/hotline"
+)
+
+// Message sources a trigger rule can be limited to with TriggerRule.Source
+const (
+	triggerSourceChat    = "chat"
+	triggerSourcePrivate = "private"
+)
+
+// defaultTriggerCooldown is the least time between firings of a rule without a Cooldown
+const defaultTriggerCooldown = 10 * time.Second
+
+// triggerSounds maps TriggerRule.Sound names to sound events
+var triggerSounds = map[string]SoundEvent{
+	"chat":     SoundChatMsg,
+	"join":     SoundUserJoin,
+	"leave":    SoundUserLeave,
+	"message":  SoundServerMsg,
+	"error":    SoundError,
+	"login":    SoundLoggedIn,
+	"news":     SoundNewNews,
+	"transfer": SoundTransferComplete,
+	"mention":  SoundMention,
+}
+
+// TriggerConfig holds rules that react to chat and private messages, e.g. to
+// answer common questions in an unattended session
+type TriggerConfig struct {
+	// DryRun logs what every rule would do, in the Logs screen, instead of doing it
+	DryRun bool          `yaml:"DryRun,omitempty"`
+	Rules  []TriggerRule `yaml:"Rules,omitempty"`
+}
+
+// TriggerRule matches incoming messages and acts on them. Reply, Message and
+// Command expand $1 or ${name} to the groups captured by Match, and $from,
+// $server and $text to the sender, server and message. File only expands
+// $server, so that other users can't choose where it writes.
+type TriggerRule struct {
+	Name string `yaml:"Name"`
+	// Match is a regular expression tested against the message, without the sender's name
+	Match string `yaml:"Match"`
+	// From only matches senders whose names match this pattern, e.g. "admin*"
+	From string `yaml:"From,omitempty"`
+	// Server only matches on servers whose bookmark name or address match this pattern
+	Server string `yaml:"Server,omitempty"`
+	// Source is "chat" or "private" (defaults to both)
+	Source string `yaml:"Source,omitempty"`
+
+	// Reply answers in the chat or private message the match came from
+	Reply string `yaml:"Reply,omitempty"`
+	// Message sends the sender a private message
+	Message string `yaml:"Message,omitempty"`
+	// Sound plays chat, join, leave, message, error, login, news, transfer or mention
+	Sound string `yaml:"Sound,omitempty"`
+	// Command runs a local program, the first element, with the rest as arguments
+	Command []string `yaml:"Command,omitempty"`
+	// File appends the message to this file; $server is the only variable it expands
+	File string `yaml:"File,omitempty"`
+
+	// Cooldown is the least time between firings on a server, in seconds (defaults to 10)
+	Cooldown int `yaml:"Cooldown,omitempty"`
+	// DryRun logs this rule's actions instead of doing them
+	DryRun bool `yaml:"DryRun,omitempty"`
+}
+
+// trigger is a compiled TriggerRule
+type trigger struct {
+	rule     TriggerRule
+	name     string // Rule name, or its position if unnamed
+	re       *regexp.Regexp
+	cooldown time.Duration
+	dryRun   bool
+}
+
+// triggerEvent is a chat line or private message that may set off triggers
+type triggerEvent struct {
+	source string
+	from   string
+	userID [2]byte // Sender of a private message; chat lines only carry the name
+	chatID [4]byte // Private chat the line was said in, zero for public chat
+	text   string  // Message without the sender's name
+	line   string  // Message as shown
+}
+
+// triggerMatch is a rule that matched an event, with the groups it captured
+type triggerMatch struct {
+	trigger *trigger
+	groups  []string
+}
+
+// triggerMsg asks the UI to run the actions of rules that matched a message
+type triggerMsg struct {
+	event   triggerEvent
+	matches []triggerMatch
+}
+
+// triggerEngine matches messages against the trigger rules. It is used from the
+// transaction handler goroutine and rebuilt from the UI.
+type triggerEngine struct {
+	mu       sync.RWMutex
+	name     string // Our username, as the server shows it in chat
+	triggers []*trigger
+}
+
+// newTriggerEngine compiles the trigger rules. Invalid rules are returned as
+// errors and left out.
+func newTriggerEngine(cfg TriggerConfig, username string) (*triggerEngine, []error) {
+	e := &triggerEngine{}
+	errs := e.set(cfg, username)
+	return e, errs
+}
+
+// set replaces the rules
+func (e *triggerEngine) set(cfg TriggerConfig, username string) []error {
+	var triggers []*trigger
+	var errs []error
+	for i, rule := range cfg.Rules {
+		t, err := compileTrigger(rule, i)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		t.dryRun = t.dryRun || cfg.DryRun
+		triggers = append(triggers, t)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.name = chatName(username)
+	e.triggers = triggers
+	return errs
+}
+
+// compileTrigger checks a rule and compiles its pattern
+func compileTrigger(rule TriggerRule, i int) (*trigger, error) {
+	t := &trigger{rule: rule, name: rule.Name, cooldown: defaultTriggerCooldown, dryRun: rule.DryRun}
+	if t.name == "" {
+		t.name = "#" + strconv.Itoa(i+1)
+	}
+	if rule.Cooldown > 0 {
+		t.cooldown = time.Duration(rule.Cooldown) * time.Second
+	}
+
+	var err error
+	if t.re, err = regexp.Compile(rule.Match); err != nil {
+		return nil, fmt.Errorf("trigger %s: %w", t.name, err)
+	}
+	for _, pattern := range []string{rule.From, rule.Server} {
+		if pattern == "" {
+			continue
+		}
+		if err := validIgnorePattern(pattern); err != nil {
+			return nil, fmt.Errorf("trigger %s: %w", t.name, err)
+		}
+	}
+	switch rule.Source {
+	case "", triggerSourceChat, triggerSourcePrivate:
+	default:
+		return nil, fmt.Errorf("trigger %s: unknown source %q", t.name, rule.Source)
+	}
+	if _, ok := triggerSounds[rule.Sound]; rule.Sound != "" && !ok {
+		return nil, fmt.Errorf("trigger %s: unknown sound %q", t.name, rule.Sound)
+	}
+	if err := validTriggerFile(rule.File); err != nil {
+		return nil, fmt.Errorf("trigger %s: %w", t.name, err)
+	}
+	if rule.Reply == "" && rule.Message == "" && rule.Sound == "" && len(rule.Command) == 0 && rule.File == "" {
+		return nil, fmt.Errorf("trigger %s: no actions", t.name)
+	}
+	return t, nil
+}
+
+// chatEvent turns a public or private chat line into an event. Our own lines,
+// emotes and join, leave and rename notices aren't events.
+func (e *triggerEngine) chatEvent(line string, chatID [4]byte) (triggerEvent, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	text := strings.TrimSpace(line)
+	speaker, body, ok := strings.Cut(text, ":  ")
+	if !ok || strings.TrimSpace(speaker) == e.name {
+		return triggerEvent{}, false
+	}
+	return triggerEvent{
+		source: triggerSourceChat,
+		from:   strings.TrimSpace(speaker),
+		chatID: chatID,
+		text:   body,
+		line:   text,
+	}, true
+}
+
+// match returns the rules an event sets off
+func (e *triggerEngine) match(ev triggerEvent) []triggerMatch {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var matches []triggerMatch
+	for _, t := range e.triggers {
+		if t.rule.Source != "" && t.rule.Source != ev.source {
+			continue
+		}
+		if t.rule.From != "" && !matchIgnorePattern(t.rule.From, ev.from) {
+			continue
+		}
+		if groups := t.re.FindStringSubmatch(ev.text); groups != nil {
+			matches = append(matches, triggerMatch{trigger: t, groups: groups})
+		}
+	}
+	return matches
+}
+
+// onServer reports whether the rule applies to a connection
+func (t *trigger) onServer(params *connectionParams) bool {
+	if t.rule.Server == "" {
+		return true
+	}
+	if params == nil {
+		return false
+	}
+	for _, name := range []string{params.bookmark, params.name, params.addr} {
+		if name != "" && matchIgnorePattern(t.rule.Server, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// expand fills in a template from a match
+func (t *trigger) expand(template string, ev triggerEvent, groups []string, server string) string {
+	return os.Expand(template, func(key string) string {
+		switch key {
+		case "$":
+			return "$"
+		case "from":
+			return ev.from
+		case "server":
+			return server
+		case "text":
+			return ev.text
+		}
+		i, err := strconv.Atoi(key)
+		if err != nil {
+			i = t.re.SubexpIndex(key)
+		}
+		if i < 0 || i >= len(groups) {
+			return ""
+		}
+		return groups[i]
+	})
+}
+
+// validTriggerFile checks that a File only uses the $server variable
+func validTriggerFile(file string) error {
+	var bad string
+	os.Expand(file, func(key string) string {
+		if key != "server" && key != "$" && bad == "" {
+			bad = key
+		}
+		return ""
+	})
+	if bad != "" {
+		return fmt.Errorf("file can only use $server, not $%s", bad)
+	}
+	return nil
+}
+
+// triggerFilePath expands $server in a rule's File. A server name that would
+// leave the directory the path names is refused.
+func triggerFilePath(file, server string) (string, error) {
+	if strings.ContainsAny(server, `/\`) || strings.Contains(server, "..") {
+		return "", fmt.Errorf("server name %q can't be used in a file name", server)
+	}
+	return os.Expand(file, func(key string) string {
+		switch key {
+		case "$":
+			return "$"
+		case "server":
+			return server
+		}
+		return ""
+	}), nil
+}
+
+// updateTriggers rebuilds the trigger rules after the name or rules change
+func (m *Model) updateTriggers() {
+	for _, err := range m.triggers.set(m.prefs.Triggers, m.prefs.Username) {
+		m.logger.Error("Invalid trigger rule", "err", err)
+	}
+}
+
+// checkTriggers hands the rules an event sets off to the UI to run. It is
+// called from transaction handlers.
+func (m *Model) checkTriggers(c *hotline.Client, ev triggerEvent) {
+	if matches := m.triggers.match(ev); len(matches) > 0 {
+		m.send(c, triggerMsg{event: ev, matches: matches})
+	}
+}
+
+func (m *Model) handleTriggerMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	triggered := msg.(triggerMsg)
+	now := time.Now()
+	for _, match := range triggered.matches {
+		t := match.trigger
+		if !t.onServer(m.activeConnection) {
+			continue
+		}
+
+		if m.triggerCoolingDown(t, now) {
+			m.logger.Debug("Trigger cooling down", "rule", t.name)
+			continue
+		}
+
+		m.runTrigger(t, triggered.event, match.groups)
+	}
+	return m, nil
+}
+
+// triggerCoolingDown rate limits each rule on each server. It reports whether t
+// fired here less than its cooldown before now, and otherwise records it firing.
+func (s *Session) triggerCoolingDown(t *trigger, now time.Time) bool {
+	if s.triggerFired == nil {
+		s.triggerFired = make(map[string]time.Time)
+	}
+	if last, ok := s.triggerFired[t.name]; ok && now.Sub(last) < t.cooldown {
+		return true
+	}
+	s.triggerFired[t.name] = now
+	return false
+}
+
+// runTrigger carries out a rule's actions, or logs them in a dry run
+func (m *Model) runTrigger(t *trigger, ev triggerEvent, groups []string) {
+	var server string
+	if m.activeConnection != nil {
+		server = m.activeConnection.name
+	}
+	expand := func(template string) string {
+		return t.expand(template, ev, groups, server)
+	}
+
+	type action struct {
+		desc string
+		run  func() error
+	}
+	var actions []action
+
+	if t.rule.Reply != "" {
+		text := expand(t.rule.Reply)
+		if ev.source == triggerSourcePrivate {
+			actions = append(actions, action{"reply " + text, func() error { return m.sendTriggerMessage(ev, text) }})
+		} else {
+			actions = append(actions, action{"reply " + text, func() error { return m.sendTriggerChat(ev.chatID, text) }})
+		}
+	}
+	if t.rule.Message != "" {
+		text := expand(t.rule.Message)
+		actions = append(actions, action{"message " + text, func() error { return m.sendTriggerMessage(ev, text) }})
+	}
+	if t.rule.Sound != "" {
+		actions = append(actions, action{"sound " + t.rule.Sound, func() error {
+			if m.soundPlayer != nil {
+				m.soundPlayer.PlayAsync(triggerSounds[t.rule.Sound])
+			}
+			return nil
+		}})
+	}
+	if len(t.rule.Command) > 0 {
+		args := make([]string, len(t.rule.Command))
+		for i, arg := range t.rule.Command {
+			args[i] = expand(arg)
+		}
+		actions = append(actions, action{"command " + strings.Join(args, " "), func() error { return m.runTriggerCommand(t, args) }})
+	}
+	if t.rule.File != "" {
+		path, err := triggerFilePath(t.rule.File, server)
+		if err != nil {
+			m.logger.Error("Trigger action failed", "rule", t.name, "action", "file", "err", err)
+		} else {
+			actions = append(actions, action{"file " + path, func() error { return appendTriggerFile(path, server, ev) }})
+		}
+	}
+
+	for _, a := range actions {
+		if t.dryRun {
+			m.logger.Info("Trigger matched (dry run)", "rule", t.name, "from", ev.from, "action", a.desc)
+			continue
+		}
+		m.logger.Info("Trigger fired", "rule", t.name, "from", ev.from, "action", a.desc)
+		if err := a.run(); err != nil {
+			m.logger.Error("Trigger action failed", "rule", t.name, "action", a.desc, "err", err)
+		}
+	}
+}
+
+// sendTriggerChat says text in public chat, or in a private chat
+func (m *Model) sendTriggerChat(chatID [4]byte, text string) error {
+	fields := []hotline.Field{hotline.NewField(hotline.FieldData, m.encodeText(strings.ReplaceAll(text, "\n", "\r")))}
+	if isPrivateChat(chatID) {
+		fields = append(fields, hotline.NewField(hotline.FieldChatID, chatID[:]))
+	}
+	return m.sendTransaction(hotline.NewTransaction(hotline.TranChatSend, [2]byte{}, fields...))
+}
+
+// sendTriggerMessage sends the sender of an event a private message, marked as
+// automatic so that other clients' auto-responders don't answer it
+func (m *Model) sendTriggerMessage(ev triggerEvent, text string) error {
+	userID := ev.userID
+	if userID == [2]byte{} {
+		u, ok := m.userNamed(ev.from)
+		if !ok {
+			return fmt.Errorf("%s is not online", ev.from)
+		}
+		userID = u.ID
+	}
+
+	return m.sendTransaction(hotline.NewTransaction(
+		hotline.TranSendInstantMsg,
+		[2]byte{},
+		hotline.NewField(hotline.FieldUserID, userID[:]),
+		hotline.NewField(hotline.FieldOptions, instantMsgAutoResponse),
+		hotline.NewField(hotline.FieldData, m.encodeText(strings.ReplaceAll(text, "\n", "\r"))),
+	))
+}
+
+// userNamed finds an online user by the name shown in front of their chat lines
+func (m *Model) userNamed(name string) (hotline.User, bool) {
+	for _, u := range m.userList {
+		if chatName(u.Name) == name {
+			return u, true
+		}
+	}
+	return hotline.User{}, false
+}
+
+// runTriggerCommand starts a rule's command without waiting for it
+func (m *Model) runTriggerCommand(t *trigger, args []string) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("%s not found", args[0])
+		}
+		return err
+	}
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			m.logger.Warn("Trigger command failed", "rule", t.name, "cmd", args[0], "err", err)
+		}
+	}()
+	return nil
+}
+
+// appendTriggerFile writes a message to the end of a file, with the time and server
+func appendTriggerFile(path, server string, ev triggerEvent) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	line := ev.line
+	if ev.source == triggerSourcePrivate {
+		line = fmt.Sprintf("[private message from %s] %s", ev.from, ev.text)
+	}
+	line = strings.ReplaceAll(line, "\n", " ")
+	_, err = fmt.Fprintf(f, "%s %s %s\n", time.Now().Format(time.DateTime), server, line)
+	return errors.Join(err, f.Close())
+}
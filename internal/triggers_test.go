@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompileTrigger(t *testing.T) {
+	tests := []struct {
+		rule TriggerRule
+		err  string // Substring of the error, empty if the rule is valid
+	}{
+		{rule: TriggerRule{Match: "hello", Reply: "hi"}},
+		{rule: TriggerRule{Match: "x", From: "admin*", Server: "*.example.com", Source: triggerSourcePrivate, Sound: "mention"}},
+		{rule: TriggerRule{Name: "bad", Match: "(", Reply: "hi"}, err: "trigger bad: error parsing regexp"},
+		{rule: TriggerRule{Match: "x", From: "[", Reply: "hi"}, err: `trigger #1: invalid pattern "["`},
+		{rule: TriggerRule{Match: "x", Server: " ", Reply: "hi"}, err: "empty pattern"},
+		{rule: TriggerRule{Match: "x", Source: "news", Reply: "hi"}, err: `unknown source "news"`},
+		{rule: TriggerRule{Match: "x", Sound: "bell"}, err: `unknown sound "bell"`},
+		{rule: TriggerRule{Match: "x"}, err: "no actions"},
+		{rule: TriggerRule{Match: "x", File: "/tmp/$server-$$.log"}},
+		{rule: TriggerRule{Match: "(.*)", File: "/tmp/$1"}, err: "file can only use $server, not $1"},
+		{rule: TriggerRule{Match: "x", File: "${from}.log"}, err: "not $from"},
+	}
+	for _, tt := range tests {
+		_, err := compileTrigger(tt.rule, 0)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("compileTrigger(%+v) error: %v", tt.rule, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("compileTrigger(%+v) error = %v, want %q", tt.rule, err, tt.err)
+		}
+	}
+}
+
+func TestTriggerEngineSet(t *testing.T) {
+	e, errs := newTriggerEngine(TriggerConfig{
+		DryRun: true,
+		Rules: []TriggerRule{
+			{Match: "x", Reply: "a", Cooldown: 60},
+			{Match: "(", Reply: "b"},
+			{Name: "named", Match: "y", Reply: "c"},
+		},
+	}, "me")
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "trigger #2") {
+		t.Errorf("errors = %v, want one for rule #2", errs)
+	}
+
+	var names []string
+	for _, tr := range e.triggers {
+		names = append(names, tr.name)
+		if !tr.dryRun {
+			t.Errorf("rule %s ignored the global dry run", tr.name)
+		}
+	}
+	if !slices.Equal(names, []string{"#1", "named"}) {
+		t.Errorf("rules = %q, want #1 and named", names)
+	}
+	if e.triggers[0].cooldown != time.Minute || e.triggers[1].cooldown != defaultTriggerCooldown {
+		t.Errorf("cooldowns = %v, %v", e.triggers[0].cooldown, e.triggers[1].cooldown)
+	}
+}
+
+func TestTriggerEngineMatch(t *testing.T) {
+	e, errs := newTriggerEngine(TriggerConfig{Rules: []TriggerRule{
+		{Name: "any", Match: "(?i)hello", Reply: "hi"},
+		{Name: "chat", Match: "hello", Source: triggerSourceChat, Reply: "hi"},
+		{Name: "private", Match: "hello", Source: triggerSourcePrivate, Reply: "hi"},
+		{Name: "admins", Match: "hello", From: "admin*", Reply: "hi"},
+	}}, "me")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	tests := []struct {
+		ev   triggerEvent
+		want []string
+	}{
+		{ev: triggerEvent{source: triggerSourceChat, from: "bob", text: "hello"}, want: []string{"any", "chat"}},
+		{ev: triggerEvent{source: triggerSourcePrivate, from: "bob", text: "hello"}, want: []string{"any", "private"}},
+		{ev: triggerEvent{source: triggerSourceChat, from: "Admin Ann", text: "hello"}, want: []string{"any", "chat", "admins"}},
+		{ev: triggerEvent{source: triggerSourceChat, from: "bob", text: "HELLO"}, want: []string{"any"}},
+		{ev: triggerEvent{source: triggerSourceChat, from: "bob", text: "bye"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, m := range e.match(tt.ev) {
+			got = append(got, m.trigger.name)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("match(%+v) = %q, want %q", tt.ev, got, tt.want)
+		}
+	}
+}
+
+func TestTriggerEngineChatEvent(t *testing.T) {
+	e, _ := newTriggerEngine(TriggerConfig{}, "me")
+
+	ev, ok := e.chatEvent("\r        bob:  hello there", [4]byte{})
+	if !ok || ev.from != "bob" || ev.text != "hello there" || ev.source != triggerSourceChat {
+		t.Errorf("chatEvent = %+v, %v; want bob saying hello there", ev, ok)
+	}
+	for _, line := range []string{"\r         me:  hello", "\r *** bob joined"} {
+		if _, ok := e.chatEvent(line, [4]byte{}); ok {
+			t.Errorf("chatEvent(%q) was an event", line)
+		}
+	}
+}
+
+func TestTriggerExpand(t *testing.T) {
+	tr, err := compileTrigger(TriggerRule{Match: `^(\w+) (?P<thing>\w+)`, Reply: "x"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := triggerEvent{from: "bob", text: "where files"}
+	groups := tr.re.FindStringSubmatch(ev.text)
+
+	tests := []struct {
+		template, want string
+	}{
+		{template: "$1 are ${thing}", want: "where are files"},
+		{template: "$2/$0", want: "files/where files"},
+		{template: "hi $from on $server: $text", want: "hi bob on Example: where files"},
+		{template: "costs $$5", want: "costs $5"},
+		{template: "[$3][${nope}]", want: "[][]"},
+	}
+	for _, tt := range tests {
+		if got := tr.expand(tt.template, ev, groups, "Example"); got != tt.want {
+			t.Errorf("expand(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestTriggerFilePath(t *testing.T) {
+	tests := []struct {
+		file, server, want string
+		err                bool
+	}{
+		{file: "/var/log/hotline.log", server: "Example", want: "/var/log/hotline.log"},
+		{file: "/var/log/$server.log", server: "Example", want: "/var/log/Example.log"},
+		{file: "/var/log/${server}-$$.log", server: "Example", want: "/var/log/Example-$.log"},
+		{file: "/var/log/$server.log", server: "../../home/me/.bashrc", err: true},
+		{file: "/var/log/$server.log", server: `..\x`, err: true},
+	}
+	for _, tt := range tests {
+		got, err := triggerFilePath(tt.file, tt.server)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("triggerFilePath(%q, %q) = %q, %v; want %q, error %v", tt.file, tt.server, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestTriggerFileHostileCapture(t *testing.T) {
+	// Other users control the message, and so anything Match captures
+	e, errs := newTriggerEngine(TriggerConfig{Rules: []TriggerRule{
+		{Match: `^log (?P<server>.*)`, File: "/var/log/$server.log"},
+	}}, "me")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	matches := e.match(triggerEvent{source: triggerSourceChat, from: "mallory", text: "log ../../home/me/.bashrc"})
+	if len(matches) != 1 {
+		t.Fatalf("got %d matches, want 1", len(matches))
+	}
+
+	path, err := triggerFilePath(matches[0].trigger.rule.File, "Example")
+	if err != nil || path != "/var/log/Example.log" {
+		t.Errorf("path = %q, %v; want the server name, not the capture", path, err)
+	}
+
+	// Rules that would put a capture in the path are refused outright
+	for _, file := range []string{"/var/log/$1", "/var/log/${from}.log", "$text"} {
+		if _, err := compileTrigger(TriggerRule{Match: "(.*)", File: file}, 0); err == nil {
+			t.Errorf("compileTrigger accepted File %q", file)
+		}
+	}
+}
+
+func TestTriggerCooldown(t *testing.T) {
+	cfg := TriggerConfig{Rules: []TriggerRule{
+		{Name: "greet", Match: "hello", Reply: "hi", Cooldown: 30},
+		{Name: "other", Match: "bye", Reply: "bye"},
+	}}
+	e, _ := newTriggerEngine(cfg, "me")
+	s := &Session{}
+	now := time.Now()
+
+	greet, other := e.triggers[0], e.triggers[1]
+	if s.triggerCoolingDown(greet, now) {
+		t.Fatal("first firing was cooling down")
+	}
+	if !s.triggerCoolingDown(greet, now.Add(10*time.Second)) {
+		t.Error("fired again within the cooldown")
+	}
+	if s.triggerCoolingDown(other, now.Add(10*time.Second)) {
+		t.Error("one rule's cooldown held back another")
+	}
+
+	// Rebuilding the rules, e.g. after /nick, keeps the cooldowns
+	e.set(cfg, "newname")
+	if !s.triggerCoolingDown(e.triggers[0], now.Add(20*time.Second)) {
+		t.Error("rebuilding the rules reset the cooldown")
+	}
+	if s.triggerCoolingDown(e.triggers[0], now.Add(31*time.Second)) {
+		t.Error("still cooling down after the cooldown passed")
+	}
+
+	// Cooldowns are per server
+	if (&Session{}).triggerCoolingDown(greet, now) {
+		t.Error("cooldown carried over to another session")
+	}
+}
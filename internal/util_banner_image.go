@@ -0,0 +1,246 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+	"os"
+	"strings"
+)
+
+// Banner graphics outputs, selected with Settings.BannerGraphics. Anything else,
+// including "auto", detects the terminal.
+const (
+	bannerGraphicsSixel     = "sixel"
+	bannerGraphicsKitty     = "kitty"
+	bannerGraphicsHalfBlock = "halfblock"
+	bannerGraphicsOff       = "off"
+)
+
+// Assumed terminal cell size in pixels, used to size Sixel images
+const (
+	cellPixelWidth  = 10
+	cellPixelHeight = 20
+)
+
+// kittyBannerID identifies the banner image to the kitty graphics protocol so that
+// redraws replace it rather than stacking copies
+const kittyBannerID = 4242
+
+// bannerGraphics returns the configured banner output, detecting the terminal's
+// capabilities when it is unset or "auto"
+func (cp *Settings) bannerGraphics() string {
+	switch g := strings.ToLower(cp.BannerGraphics); g {
+	case bannerGraphicsSixel, bannerGraphicsKitty, bannerGraphicsHalfBlock, bannerGraphicsOff:
+		return g
+	}
+	return detectBannerGraphics()
+}
+
+// detectBannerGraphics guesses the best image output from the environment. Terminals
+// can't be queried from inside the UI, so unknown terminals get half-blocks.
+func detectBannerGraphics() string {
+	term := os.Getenv("TERM")
+	program := os.Getenv("TERM_PROGRAM")
+
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "" || strings.Contains(term, "kitty") ||
+		program == "ghostty" || program == "WezTerm":
+		return bannerGraphicsKitty
+	case strings.Contains(term, "sixel") || term == "foot" || strings.HasPrefix(term, "mlterm") ||
+		program == "iTerm.app":
+		return bannerGraphicsSixel
+	}
+	return bannerGraphicsHalfBlock
+}
+
+// bannerCells returns the size in terminal cells to draw img at, keeping its aspect
+// ratio within maxCols by maxRows. Cells are assumed to be twice as tall as wide.
+func bannerCells(img image.Image, maxCols, maxRows int) (cols, rows int) {
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 || maxCols < 1 || maxRows < 1 {
+		return 0, 0
+	}
+
+	cols = min(maxCols, b.Dx())
+	rows = max(1, (cols*b.Dy()+b.Dx())/(2*b.Dx()))
+	if rows > maxRows {
+		rows = maxRows
+		cols = max(1, min(maxCols, rows*2*b.Dx()/b.Dy()))
+	}
+	return cols, rows
+}
+
+// renderBanner draws img in at most maxCols by maxRows cells with the given output.
+// It returns the rendered lines and the number of rows the image occupies.
+func renderBanner(img image.Image, graphics string, maxCols, maxRows int) (string, int) {
+	cols, rows := bannerCells(img, maxCols, maxRows)
+	if cols == 0 {
+		return "", 0
+	}
+
+	switch graphics {
+	case bannerGraphicsOff:
+		return "", 0
+	case bannerGraphicsSixel:
+		sixel := encodeSixel(scaleImage(img, cols*cellPixelWidth, rows*cellPixelHeight))
+		return sixel + strings.Repeat("\n", rows-1), rows
+	case bannerGraphicsKitty:
+		return encodeKitty(img, cols, rows) + strings.Repeat("\n", rows-1), rows
+	}
+	return renderHalfBlocks(scaleImage(img, cols, rows*2)), rows
+}
+
+// scaleImage resizes src to w by h by averaging the source pixels under each
+// destination pixel. Transparent areas come out black.
+func scaleImage(src image.Image, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	sb := src.Bounds()
+
+	for y := 0; y < h; y++ {
+		y0 := sb.Min.Y + y*sb.Dy()/h
+		y1 := max(y0+1, sb.Min.Y+(y+1)*sb.Dy()/h)
+		for x := 0; x < w; x++ {
+			x0 := sb.Min.X + x*sb.Dx()/w
+			x1 := max(x0+1, sb.Min.X+(x+1)*sb.Dx()/w)
+
+			var r, g, b, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, _ := src.At(sx, sy).RGBA()
+					r, g, b, n = r+pr, g+pg, b+pb, n+1
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return dst
+}
+
+// renderHalfBlocks draws two pixel rows per line using the upper half block
+// character, with the top pixel as foreground and the bottom as background
+func renderHalfBlocks(img *image.RGBA) string {
+	b := img.Bounds()
+	var out strings.Builder
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		if y > b.Min.Y {
+			out.WriteByte('\n')
+		}
+		for x := b.Min.X; x < b.Max.X; x++ {
+			top := img.RGBAAt(x, y)
+			bottom := top
+			if y+1 < b.Max.Y {
+				bottom = img.RGBAAt(x, y+1)
+			}
+			fmt.Fprintf(&out, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀",
+				top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+		}
+		out.WriteString("\x1b[0m")
+	}
+	return out.String()
+}
+
+// encodeSixel encodes img as a Sixel image using the web-safe palette
+func encodeSixel(img image.Image) string {
+	b := img.Bounds()
+	paletted := image.NewPaletted(b, palette.WebSafe)
+	draw.FloydSteinberg.Draw(paletted, b, img, b.Min)
+
+	var out strings.Builder
+	out.WriteString("\x1bPq")
+	fmt.Fprintf(&out, "\"1;1;%d;%d", b.Dx(), b.Dy())
+	for i, c := range paletted.Palette {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(&out, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+
+	row := make([]byte, b.Dx())
+	for y0 := b.Min.Y; y0 < b.Max.Y; y0 += 6 {
+		var used [256]bool
+		for y := y0; y < min(y0+6, b.Max.Y); y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				used[paletted.ColorIndexAt(x, y)] = true
+			}
+		}
+
+		first := true
+		for ci := range used {
+			if !used[ci] {
+				continue
+			}
+			if !first {
+				out.WriteByte('$') // Back to the start of the band for the next color
+			}
+			first = false
+
+			for x := b.Min.X; x < b.Max.X; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && y0+dy < b.Max.Y; dy++ {
+					if int(paletted.ColorIndexAt(x, y0+dy)) == ci {
+						bits |= 1 << dy
+					}
+				}
+				row[x-b.Min.X] = '?' + bits
+			}
+			fmt.Fprintf(&out, "#%d", ci)
+			writeSixelRun(&out, row)
+		}
+		out.WriteByte('-')
+	}
+
+	out.WriteString("\x1b\\")
+	return out.String()
+}
+
+// writeSixelRun writes a row of sixels, run-length encoding repeats
+func writeSixelRun(out *strings.Builder, row []byte) {
+	for i := 0; i < len(row); {
+		j := i
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			fmt.Fprintf(out, "!%d%c", n, row[i])
+		} else {
+			out.Write(row[i:j])
+		}
+		i = j
+	}
+}
+
+// encodeKitty transmits img with the kitty graphics protocol, letting the terminal
+// scale it to cols by rows cells
+func encodeKitty(img image.Image, cols, rows int) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return ""
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	const chunkSize = 4096
+	var out strings.Builder
+	for i := 0; i < len(data); i += chunkSize {
+		chunk := data[i:min(i+chunkSize, len(data))]
+		more := 0
+		if i+chunkSize < len(data) {
+			more = 1
+		}
+		if i == 0 {
+			// q=2 stops the terminal from answering, which would arrive as keypresses
+			fmt.Fprintf(&out, "\x1b_Ga=T,f=100,i=%d,p=1,q=2,C=1,c=%d,r=%d,m=%d;%s\x1b\\", kittyBannerID, cols, rows, more, chunk)
+		} else {
+			fmt.Fprintf(&out, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return out.String()
+}
@@ -14,6 +14,7 @@ const (
 	SoundLoggedIn
 	SoundNewNews
 	SoundTransferComplete
+	SoundMention
 )
 
 // SoundPlayer is a no-op implementation for Linux where CGO is not available
@@ -30,6 +30,7 @@ const (
 	SoundLoggedIn
 	SoundNewNews
 	SoundTransferComplete
+	SoundMention
 )
 
 // SoundPlayer manages sound playback for various client events
@@ -64,6 +65,7 @@ func NewSoundPlayer(enabled bool) (*SoundPlayer, error) {
 		SoundLoggedIn:         "sounds/logged-in.wav",
 		SoundNewNews:          "sounds/new-news.wav",
 		SoundTransferComplete: "sounds/transfer-complete.wav",
+		SoundMention:          "sounds/server-message.wav",
 	}
 
 	var loadErrs []error
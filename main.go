@@ -25,9 +25,27 @@ var logLevels = map[string]log.Level{
 func main() {
 	configPath := flag.String("config", defaultConfigPath(), "Path to config file")
 	logLevel := flag.String("log-level", "info", "Log level (debug, info)")
+	tracePath := flag.String("trace", "", "Record every server transaction to `FILE`")
+	replayPath := flag.String("replay", "", "Replay a trace recorded with -trace from `FILE` instead of connecting to a server")
 
+	bookmark := flag.Bool("bookmark", false, "Save the hotline:// URL argument as a bookmark")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [hotline://[user:pass@]host[:port][/path]]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
+	var hotlineURL *internal.HotlineURL
+	if flag.NArg() > 0 {
+		u, err := internal.ParseHotlineURL(flag.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		hotlineURL = u
+	}
+
 	// Check if config file exists before proceeding
 	if _, err := os.Stat(*configPath); os.IsNotExist(err) {
 		fmt.Fprintf(os.Stderr, "Error: config file not found: %s\n\n", *configPath)
@@ -49,6 +67,29 @@ func main() {
 	logger.Info("Started Mobius client", "Version", version)
 
 	model := internal.NewModel(*configPath, logger, db)
+	if *tracePath != "" {
+		if err := model.EnableTrace(*tracePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: unable to open trace file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *replayPath != "" {
+		if err := model.EnableReplay(*replayPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: unable to read trace file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if hotlineURL != nil {
+		if *bookmark {
+			if err := model.BookmarkURL(hotlineURL); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: unable to save bookmark: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if *replayPath == "" {
+			model.OpenURL(hotlineURL)
+		}
+	}
 	if err := model.Start(); err != nil {
 		logger.Error("Application error", "err", err)
 		os.Exit(1)